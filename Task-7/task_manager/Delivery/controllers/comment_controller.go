@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"net/http"
+	"task_manager/Domain"
+	"task_manager/Usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CommentController handles comment-related HTTP requests
+type CommentController struct {
+	commentUsecase Usecase.CommentUsecase
+}
+
+// NewCommentController creates a new CommentController
+func NewCommentController(commentUsecase Usecase.CommentUsecase) *CommentController {
+	return &CommentController{commentUsecase: commentUsecase}
+}
+
+// commentBody is the request body for creating or editing a comment.
+type commentBody struct {
+	Body string `json:"body"`
+}
+
+// CreateComment handles POST /tasks/:id/comments to comment on a task
+func (cc *CommentController) CreateComment(c *gin.Context) {
+	var body commentBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	comment, err := cc.commentUsecase.CreateComment(requestContext(c), c.Param("id"), Domain.Comment{Body: body.Body})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment created successfully",
+		"comment": comment,
+	})
+}
+
+// GetComments handles GET /tasks/:id/comments to list the comments on a
+// task, oldest first
+func (cc *CommentController) GetComments(c *gin.Context) {
+	query := Domain.CommentQuery{TaskID: c.Param("id")}
+	var err error
+	if query.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if query.Offset, err = parseIntQuery(c, "offset"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	page, err := cc.commentUsecase.ListComments(requestContext(c), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": page.Comments,
+		"count":    len(page.Comments),
+		"total":    page.Total,
+		"limit":    page.Limit,
+		"offset":   page.Offset,
+		"links":    pageLinks(c, page.Total, page.Limit, page.Offset),
+	})
+}
+
+// UpdateComment handles PUT /tasks/:id/comments/:comment_id to edit a comment
+func (cc *CommentController) UpdateComment(c *gin.Context) {
+	var body commentBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	comment, err := cc.commentUsecase.UpdateComment(requestContext(c), c.Param("id"), c.Param("comment_id"), body.Body)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment updated successfully",
+		"comment": comment,
+	})
+}
+
+// DeleteComment handles DELETE /tasks/:id/comments/:comment_id to delete a comment
+func (cc *CommentController) DeleteComment(c *gin.Context) {
+	if err := cc.commentUsecase.DeleteComment(requestContext(c), c.Param("id"), c.Param("comment_id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
@@ -1,8 +1,16 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"task_manager/Domain"
+	"task_manager/Infrastructure"
+	"time"
 	"task_manager/Usecase"
 
 	"github.com/gin-gonic/gin"
@@ -28,21 +36,42 @@ func NewUserController(userUsecase Usecase.UserUsecase) *UserController {
 	return &UserController{userUsecase: userUsecase}
 }
 
+// requestContext returns the request context carrying the Domain.Actor
+// that AuthMiddleware stored in the gin context.
+func requestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	userID := c.GetString("userID")
+	if userID == "" {
+		return ctx
+	}
+	return Domain.ContextWithActor(ctx, Domain.Actor{
+		UserID:   userID,
+		Username: c.GetString("username"),
+		Role:     Domain.UserRole(c.GetString("role")),
+	})
+}
+
+// invalidBody reports a request body that could not be decoded.
+func invalidBody(c *gin.Context, err error) {
+	_ = c.Error(fmt.Errorf("%w: invalid request body: %v", Domain.ErrInvalidRequest, err))
+}
+
 // CreateTask handles POST /tasks to create a new task
 func (tc *TaskController) CreateTask(c *gin.Context) {
 	var task Domain.Task
 	if err := c.ShouldBindJSON(&task); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		invalidBody(c, err)
 		return
 	}
 
-	ctx := c.Request.Context()
+	ctx := requestContext(c)
 	createdTask, err := tc.taskUsecase.CreateTask(ctx, task)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	setETag(c, createdTask)
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Task created successfully",
 		"task":    createdTask,
@@ -52,26 +81,229 @@ func (tc *TaskController) CreateTask(c *gin.Context) {
 // GetTask handles GET /tasks/:id to retrieve a task
 func (tc *TaskController) GetTask(c *gin.Context) {
 	id := c.Param("id")
-	ctx := c.Request.Context()
+	ctx := requestContext(c)
 	task, err := tc.taskUsecase.GetTaskByID(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	setETag(c, task)
 	c.JSON(http.StatusOK, gin.H{"task": task})
 }
 
-// GetAllTasks handles GET /tasks to retrieve all tasks
+// GetAllTasks handles GET /tasks to retrieve a filtered, sorted page of tasks
 func (tc *TaskController) GetAllTasks(c *gin.Context) {
-	ctx := c.Request.Context()
-	tasks, err := tc.taskUsecase.GetAllTasks(ctx)
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := requestContext(c)
+	page, err := tc.taskUsecase.GetAllTasks(ctx, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	writeTaskPage(c, page)
+}
+
+// SearchTasks handles GET /tasks/search to run a full-text search over the
+// title and description of tasks. It accepts the filter and pagination
+// parameters of GET /tasks.
+func (tc *TaskController) SearchTasks(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	query.Text = c.Query("q")
+
+	ctx := requestContext(c)
+	page, err := tc.taskUsecase.SearchTasks(ctx, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": page.Results,
+		"count":   len(page.Results),
+		"total":   page.Total,
+		"limit":   page.Limit,
+		"offset":  page.Offset,
+		"links":   pageLinks(c, page.Total, page.Limit, page.Offset),
+	})
+}
+
+// GetTags handles GET /tags to suggest the tags in use that start with a
+// prefix, most used first
+func (tc *TaskController) GetTags(c *gin.Context) {
+	query := Domain.TagQuery{Prefix: c.Query("prefix"), ProjectID: c.Query("project_id")}
+	var err error
+	if query.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	tags, err := tc.taskUsecase.ListTags(requestContext(c), query)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// GetWorkflow handles GET /workflow to describe the task statuses and the
+// transitions between them
+func (tc *TaskController) GetWorkflow(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"workflow": tc.taskUsecase.GetWorkflow(requestContext(c))})
+}
+
+// writeTaskPage writes a page of tasks together with its counts and links.
+func writeTaskPage(c *gin.Context, page Domain.TaskPage) {
+	c.JSON(http.StatusOK, gin.H{
+		"tasks":  page.Tasks,
+		"count":  len(page.Tasks),
+		"total":  page.Total,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+		"links":  pageLinks(c, page.Total, page.Limit, page.Offset),
+	})
+}
+
+// parseTaskQuery reads the filter, sort and pagination parameters of GET /tasks.
+func parseTaskQuery(c *gin.Context) (Domain.TaskQuery, error) {
+	query := Domain.TaskQuery{
+		Status:     Domain.Status(c.Query("status")),
+		SeriesID:   c.Query("series_id"),
+		ParentID:   c.Query("parent_id"),
+		ProjectID:  c.Query("project_id"),
+		Tag:        c.Query("tag"),
+		AssigneeID: c.Query("assignee_id"),
+	}
+
+	if value := c.Query("due_before"); value != "" {
+		dueBefore, err := parseTime(value)
+		if err != nil {
+			return Domain.TaskQuery{}, Domain.NewValidationError("due_before", "must be an RFC 3339 time or YYYY-MM-DD date")
+		}
+		query.DueBefore = &dueBefore
+	}
+	if value := c.Query("due_after"); value != "" {
+		dueAfter, err := parseTime(value)
+		if err != nil {
+			return Domain.TaskQuery{}, Domain.NewValidationError("due_after", "must be an RFC 3339 time or YYYY-MM-DD date")
+		}
+		query.DueAfter = &dueAfter
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		query.SortDesc = strings.HasPrefix(sort, "-")
+		query.SortBy = Domain.TaskSortField(strings.TrimLeft(sort, "+-"))
+	}
+
+	var err error
+	if query.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		return Domain.TaskQuery{}, err
+	}
+	if query.Offset, err = parseIntQuery(c, "offset"); err != nil {
+		return Domain.TaskQuery{}, err
+	}
+	return query, nil
+}
+
+// parseTime accepts either an RFC 3339 timestamp or a plain YYYY-MM-DD date.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// parseIntQuery reads an optional integer query parameter, returning 0 if it is absent.
+func parseIntQuery(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, Domain.NewValidationError(name, "must be an integer")
+	}
+	return n, nil
+}
+
+// pageLinks builds the next/prev links of a page, keeping all other query
+// parameters of the current request. A link is nil when there is no such page.
+func pageLinks(c *gin.Context, total int64, limit, offset int) gin.H {
+	link := func(offset int) string {
+		u := *c.Request.URL
+		values := u.Query()
+		values.Set("limit", strconv.Itoa(limit))
+		values.Set("offset", strconv.Itoa(offset))
+		u.RawQuery = values.Encode()
+		return u.RequestURI()
+	}
+
+	links := gin.H{"next": nil, "prev": nil}
+	if int64(offset+limit) < total {
+		links["next"] = link(offset + limit)
+	}
+	if offset > 0 {
+		links["prev"] = link(max(offset-limit, 0))
+	}
+	return links
+}
+
+// setETag sets the ETag response header to the task's version.
+func setETag(c *gin.Context, task Domain.Task) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(task.Version, 10)))
+}
+
+// ifMatchVersion returns the task version required by the If-Match request
+// header, or 0 when the header is absent or "*".
+func ifMatchVersion(c *gin.Context) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	etag := strings.TrimPrefix(header, "W/")
+	unquoted, err := strconv.Unquote(etag)
+	if err != nil {
+		return 0, Domain.NewError(Domain.ErrInvalidRequest, "If-Match must be a single entity tag such as \"3\"")
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version < 1 {
+		// A tag that is not one of our versions can never match.
+		return 0, fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, c.Param("id"))
+	}
+	return version, nil
+}
+
+// seriesScope reads the scope query parameter, which selects the
+// occurrences of a recurring task that an edit applies to. It defaults to
+// the given occurrence only.
+func seriesScope(c *gin.Context) (Domain.SeriesScope, error) {
+	scope := Domain.SeriesScope(c.DefaultQuery("scope", string(Domain.ScopeThis)))
+	if !scope.IsValid() {
+		return "", Domain.NewValidationError("scope", fmt.Sprintf("invalid scope: %s; must be this or future", scope))
+	}
+	return scope, nil
+}
+
+// subtaskPolicy reads the subtasks query parameter, which decides what
+// happens to the subtasks of a deleted task. It is empty if not given.
+func subtaskPolicy(c *gin.Context) (Domain.SubtaskPolicy, error) {
+	policy := Domain.SubtaskPolicy(c.Query("subtasks"))
+	if policy != "" && !policy.IsValid() {
+		return "", Domain.NewValidationError("subtasks", fmt.Sprintf("invalid policy: %s; must be cascade or reparent", policy))
+	}
+	return policy, nil
 }
 
 // UpdateTask handles PUT /tasks/:id to update a task
@@ -79,47 +311,322 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 	id := c.Param("id")
 	var task Domain.Task
 	if err := c.ShouldBindJSON(&task); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		invalidBody(c, err)
 		return
 	}
 
-	ctx := c.Request.Context()
-	updatedTask, err := tc.taskUsecase.UpdateTask(ctx, id, task)
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	scope, err := seriesScope(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	ctx := requestContext(c)
+	updatedTask, err := tc.taskUsecase.UpdateTask(ctx, id, task, version, scope)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, updatedTask)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Task updated successfully",
 		"task":    updatedTask,
 	})
 }
 
+// PatchTask handles PATCH /tasks/:id to partially update a task with an
+// RFC 7396 JSON Merge Patch body
+func (tc *TaskController) PatchTask(c *gin.Context) {
+	if contentType := c.ContentType(); contentType != "application/merge-patch+json" && contentType != "application/json" {
+		_ = c.Error(Domain.NewError(Domain.ErrInvalidRequest, "Content-Type must be application/merge-patch+json"))
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		invalidBody(c, err)
+		return
+	}
+	patch, err := Domain.ParseTaskMergePatch(body)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	scope, err := seriesScope(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	id := c.Param("id")
+	ctx := requestContext(c)
+	patchedTask, err := tc.taskUsecase.PatchTask(ctx, id, patch, version, scope)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, patchedTask)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task updated successfully",
+		"task":    patchedTask,
+	})
+}
+
+// GetTaskTree handles GET /tasks/:id/tree to retrieve a task with all its
+// subtasks
+func (tc *TaskController) GetTaskTree(c *gin.Context) {
+	tree, err := tc.taskUsecase.GetTaskTree(requestContext(c), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tree": tree})
+}
+
 // DeleteTask handles DELETE /tasks/:id to delete a task
 func (tc *TaskController) DeleteTask(c *gin.Context) {
 	id := c.Param("id")
-	ctx := c.Request.Context()
-	if err := tc.taskUsecase.DeleteTask(ctx, id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	policy, err := subtaskPolicy(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := requestContext(c)
+	if err := tc.taskUsecase.DeleteTask(ctx, id, version, policy); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task moved to trash"})
+}
+
+// bulkItemResult is the outcome of a single operation of POST /tasks/bulk.
+type bulkItemResult struct {
+	Index  int                       `json:"index"`
+	Op     Domain.BulkOp             `json:"op"`
+	Status int                       `json:"status"`
+	ID     string                    `json:"id,omitempty"`
+	Task   *Domain.Task              `json:"task,omitempty"`
+	Error  *Infrastructure.ErrorBody `json:"error,omitempty"`
+}
+
+// BulkTasks handles POST /tasks/bulk to create, update and delete many tasks
+// in one request. It responds with 200 if every operation succeeded and 207
+// otherwise, reporting the outcome of each operation.
+func (tc *TaskController) BulkTasks(c *gin.Context) {
+	var request struct {
+		Atomic     bool                   `json:"atomic"`
+		Operations []Domain.BulkOperation `json:"operations"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	ctx := requestContext(c)
+	results, err := tc.taskUsecase.BulkTasks(ctx, request.Operations, request.Atomic)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	items := make([]bulkItemResult, len(results))
+	failed := 0
+	for i, result := range results {
+		item := bulkItemResult{Index: i, Op: request.Operations[i].Op}
+		if !result.Task.ID.IsZero() {
+			item.ID = result.Task.ID.Hex()
+		}
+		switch {
+		case result.Err != nil:
+			status, body := Infrastructure.ErrorResponse(result.Err)
+			if status == http.StatusInternalServerError {
+				log.Printf("%s %s: operation %d: %v", c.Request.Method, c.Request.URL.Path, i, result.Err)
+			}
+			item.Status, item.Error = status, &body
+			failed++
+		case item.Op == Domain.BulkCreate:
+			item.Status, item.Task = http.StatusCreated, &results[i].Task
+		default:
+			item.Status, item.Task = http.StatusOK, &results[i].Task
+		}
+		items[i] = item
+	}
+
+	status := http.StatusOK
+	if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{
+		"atomic":    request.Atomic,
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+		"results":   items,
+	})
+}
+
+// GetTrash handles GET /trash to list deleted tasks. It accepts the same
+// query parameters as GET /tasks.
+func (tc *TaskController) GetTrash(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := requestContext(c)
+	page, err := tc.taskUsecase.GetTrash(ctx, query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	writeTaskPage(c, page)
+}
+
+// RestoreTask handles POST /tasks/:id/restore to move a task out of the trash
+func (tc *TaskController) RestoreTask(c *gin.Context) {
+	id := c.Param("id")
+	ctx := requestContext(c)
+	task, err := tc.taskUsecase.RestoreTask(ctx, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, task)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task restored successfully",
+		"task":    task,
+	})
+}
+
+// PurgeTask handles DELETE /trash/:id to permanently delete a trashed task
+func (tc *TaskController) PurgeTask(c *gin.Context) {
+	id := c.Param("id")
+	ctx := requestContext(c)
+	if err := tc.taskUsecase.PurgeTask(ctx, id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task permanently deleted"})
+}
+
+// GetTaskHistory handles GET /tasks/:id/history to list the changes made to a task
+func (tc *TaskController) GetTaskHistory(c *gin.Context) {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := requestContext(c)
+	page, err := tc.taskUsecase.GetTaskHistory(ctx, c.Param("id"), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	writeAuditPage(c, page)
+}
+
+// GetAuditLog handles GET /audit to list the changes made to all tasks
+func (tc *TaskController) GetAuditLog(c *gin.Context) {
+	query, err := parseAuditQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	query.TaskID = c.Query("task_id")
+
+	ctx := requestContext(c)
+	page, err := tc.taskUsecase.GetAuditLog(ctx, query)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+	writeAuditPage(c, page)
+}
+
+// writeAuditPage writes a page of audit entries together with its counts and links.
+func writeAuditPage(c *gin.Context, page Domain.AuditPage) {
+	c.JSON(http.StatusOK, gin.H{
+		"entries": page.Entries,
+		"count":   len(page.Entries),
+		"total":   page.Total,
+		"limit":   page.Limit,
+		"offset":  page.Offset,
+		"links":   pageLinks(c, page.Total, page.Limit, page.Offset),
+	})
+}
+
+// parseAuditQuery reads the user, time range and pagination parameters of
+// the audit listings.
+func parseAuditQuery(c *gin.Context) (Domain.AuditQuery, error) {
+	query := Domain.AuditQuery{UserID: c.Query("user_id")}
+
+	if value := c.Query("from"); value != "" {
+		from, err := parseTime(value)
+		if err != nil {
+			return Domain.AuditQuery{}, Domain.NewValidationError("from", "must be an RFC 3339 time or YYYY-MM-DD date")
+		}
+		query.From = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := parseTime(value)
+		if err != nil {
+			return Domain.AuditQuery{}, Domain.NewValidationError("to", "must be an RFC 3339 time or YYYY-MM-DD date")
+		}
+		query.To = &to
+	}
+
+	var err error
+	if query.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		return Domain.AuditQuery{}, err
+	}
+	if query.Offset, err = parseIntQuery(c, "offset"); err != nil {
+		return Domain.AuditQuery{}, err
+	}
+	return query, nil
 }
 
-// RegisterUser handles POST /register to create a new user
+// RegisterUser handles POST /register to create a new user. New users
+// always get the User role.
 func (uc *UserController) RegisterUser(c *gin.Context) {
-	var user Domain.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Email    string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		invalidBody(c, err)
 		return
 	}
 
 	ctx := c.Request.Context()
-	createdUser, err := uc.userUsecase.RegisterUser(ctx, user)
+	createdUser, err := uc.userUsecase.RegisterUser(ctx, Domain.User{Username: body.Username, Password: body.Password, Email: body.Email})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -137,16 +644,55 @@ func (uc *UserController) LogIn(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&loginData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		invalidBody(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	// ClientIP only follows X-Forwarded-For from the proxies the router
+	// trusts, so clients cannot dodge the throttling of their address.
+	tokens, err := uc.userUsecase.LogIn(ctx, loginData.Username, loginData.Password, c.ClientIP())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RefreshToken handles POST /token/refresh to rotate a refresh token
+func (uc *UserController) RefreshToken(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		invalidBody(c, err)
 		return
 	}
 
 	ctx := c.Request.Context()
-	token, err := uc.userUsecase.LogIn(ctx, loginData.Username, loginData.Password)
+	tokens, err := uc.userUsecase.RefreshToken(ctx, body.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LogOut handles POST /logout to revoke the caller's session
+func (uc *UserController) LogOut(c *gin.Context) {
+	claims, ok := c.MustGet("claims").(*Infrastructure.Claims)
+	if !ok {
+		_ = c.Error(Domain.NewError(Domain.ErrUnauthorized, "invalid token claims"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := uc.userUsecase.LogOut(ctx, claims); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
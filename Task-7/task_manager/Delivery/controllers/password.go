@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPassword handles POST /password/forgot to mail a password reset
+// token to a user. The response is the same whether or not the user
+// exists.
+func (uc *UserController) ForgotPassword(c *gin.Context) {
+	var body struct {
+		Username string `json:"username"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := uc.userUsecase.ForgotPassword(ctx, body.Username); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the account exists and has an email address, a reset token has been sent to it",
+	})
+}
+
+// ResetPassword handles POST /password/reset to set a new password with a
+// reset token. Every token issued to the user before stops working.
+func (uc *UserController) ResetPassword(c *gin.Context) {
+	var body struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := uc.userUsecase.ResetPassword(ctx, body.Token, body.Password); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// ChangePassword handles POST /me/password to change the caller's
+// password. Every other session of the caller is signed out, so the
+// response carries a new token pair.
+func (uc *UserController) ChangePassword(c *gin.Context) {
+	var body struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	ctx := requestContext(c)
+	tokens, err := uc.userUsecase.ChangePassword(ctx, body.CurrentPassword, body.NewPassword)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
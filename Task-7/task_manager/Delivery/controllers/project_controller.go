@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"task_manager/Domain"
+	"task_manager/Usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectController handles project-related HTTP requests
+type ProjectController struct {
+	projectUsecase Usecase.ProjectUsecase
+}
+
+// NewProjectController creates a new ProjectController
+func NewProjectController(projectUsecase Usecase.ProjectUsecase) *ProjectController {
+	return &ProjectController{projectUsecase: projectUsecase}
+}
+
+// CreateProject handles POST /projects to create a project owned by the caller
+func (pc *ProjectController) CreateProject(c *gin.Context) {
+	var project Domain.Project
+	if err := c.ShouldBindJSON(&project); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	created, err := pc.projectUsecase.CreateProject(requestContext(c), project)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Project created successfully",
+		"project": created,
+	})
+}
+
+// GetProjects handles GET /projects to list the projects the caller is a
+// member of
+func (pc *ProjectController) GetProjects(c *gin.Context) {
+	var query Domain.ProjectQuery
+	if value := c.Query("archived"); value != "" {
+		archived, err := strconv.ParseBool(value)
+		if err != nil {
+			_ = c.Error(Domain.NewValidationError("archived", "must be true or false"))
+			return
+		}
+		query.Archived = &archived
+	}
+	var err error
+	if query.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if query.Offset, err = parseIntQuery(c, "offset"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	page, err := pc.projectUsecase.ListProjects(requestContext(c), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"projects": page.Projects,
+		"count":    len(page.Projects),
+		"total":    page.Total,
+		"limit":    page.Limit,
+		"offset":   page.Offset,
+		"links":    pageLinks(c, page.Total, page.Limit, page.Offset),
+	})
+}
+
+// GetProject handles GET /projects/:id to retrieve a project
+func (pc *ProjectController) GetProject(c *gin.Context) {
+	project, err := pc.projectUsecase.GetProject(requestContext(c), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// UpdateProject handles PUT /projects/:id to rename, archive or change the
+// members of a project
+func (pc *ProjectController) UpdateProject(c *gin.Context) {
+	var project Domain.Project
+	if err := c.ShouldBindJSON(&project); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	updated, err := pc.projectUsecase.UpdateProject(requestContext(c), c.Param("id"), project)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project updated successfully",
+		"project": updated,
+	})
+}
+
+// DeleteProject handles DELETE /projects/:id to delete a project without tasks
+func (pc *ProjectController) DeleteProject(c *gin.Context) {
+	if err := pc.projectUsecase.DeleteProject(requestContext(c), c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+// GetProjectTasks handles GET /projects/:id/tasks to retrieve a filtered,
+// sorted page of the tasks of a project. It accepts the parameters of
+// GET /tasks.
+func (pc *ProjectController) GetProjectTasks(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	page, err := pc.projectUsecase.ListProjectTasks(requestContext(c), c.Param("id"), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	writeTaskPage(c, page)
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+	"task_manager/Domain"
+	"task_manager/Usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReminderController handles reminder-related HTTP requests
+type ReminderController struct {
+	reminderUsecase Usecase.ReminderUsecase
+}
+
+// NewReminderController creates a new ReminderController
+func NewReminderController(reminderUsecase Usecase.ReminderUsecase) *ReminderController {
+	return &ReminderController{reminderUsecase: reminderUsecase}
+}
+
+// GetPreferences handles GET /me/reminders to retrieve the caller's
+// reminder preferences
+func (rc *ReminderController) GetPreferences(c *gin.Context) {
+	prefs, err := rc.reminderUsecase.GetPreferences(requestContext(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// UpdatePreferences handles PUT /me/reminders to replace the caller's
+// reminder preferences
+func (rc *ReminderController) UpdatePreferences(c *gin.Context) {
+	var prefs Domain.ReminderPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	updated, err := rc.reminderUsecase.UpdatePreferences(requestContext(c), prefs)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Reminder preferences updated successfully",
+		"preferences": updated,
+	})
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"task_manager/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// assignmentRequest is the body of POST /tasks/:id/assign and
+// POST /tasks/:id/unassign.
+type assignmentRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// GetAssignedTasks handles GET /me/tasks to retrieve a filtered, sorted
+// page of the tasks assigned to the caller. It accepts the parameters of
+// GET /tasks.
+func (tc *TaskController) GetAssignedTasks(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	page, err := tc.taskUsecase.GetAssignedTasks(requestContext(c), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	writeTaskPage(c, page)
+}
+
+// AssignTask handles POST /tasks/:id/assign to assign a task to more users
+func (tc *TaskController) AssignTask(c *gin.Context) {
+	tc.changeAssignees(c, "Task assigned successfully", tc.taskUsecase.AssignTask)
+}
+
+// UnassignTask handles POST /tasks/:id/unassign to remove users from the
+// assignees of a task
+func (tc *TaskController) UnassignTask(c *gin.Context) {
+	tc.changeAssignees(c, "Task unassigned successfully", tc.taskUsecase.UnassignTask)
+}
+
+// changeAssignees reads an assignment request and applies it with change.
+func (tc *TaskController) changeAssignees(c *gin.Context, message string, change func(ctx context.Context, id string, userIDs []string, version int64) (Domain.Task, error)) {
+	var body assignmentRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		invalidBody(c, err)
+		return
+	}
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	task, err := change(requestContext(c), c.Param("id"), body.UserIDs, version)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setETag(c, task)
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"task":    task,
+	})
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"task_manager/Domain"
+	"task_manager/Infrastructure"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// eventHeartbeatInterval is how often an idle event stream sends a
+	// heartbeat so that proxies keep the connection open.
+	eventHeartbeatInterval = 15 * time.Second
+	// eventWriteTimeout is how long a client may take to accept a single
+	// message before the stream is closed.
+	eventWriteTimeout = 10 * time.Second
+)
+
+// StreamTaskEvents handles GET /tasks/events to push task changes as
+// Server-Sent Events
+func (tc *TaskController) StreamTaskEvents(c *gin.Context) {
+	afterID, err := lastEventID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx, cancel := streamContext(c)
+	defer cancel()
+	events, err := tc.taskUsecase.SubscribeTaskEvents(ctx, afterID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	rc := http.NewResponseController(c.Writer)
+	write := func(message string) error {
+		_ = rc.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+		if _, err := io.WriteString(c.Writer, message); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	streamEvents(ctx, events,
+		func(event Domain.TaskEvent) error {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			return write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data))
+		},
+		func() error {
+			return write(": heartbeat\n\n")
+		},
+	)
+}
+
+// StreamTaskEventsWebSocket handles GET /tasks/events/ws to push task
+// changes over a WebSocket. Every event is sent as a JSON text message;
+// heartbeats are sent as {"type":"heartbeat"}.
+func (tc *TaskController) StreamTaskEventsWebSocket(c *gin.Context) {
+	afterID, err := lastEventID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx, cancel := streamContext(c)
+	defer cancel()
+	events, err := tc.taskUsecase.SubscribeTaskEvents(ctx, afterID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	server := websocket.Server{
+		// Clients authenticate with a bearer token rather than a cookie, so
+		// connections from other origins need not be rejected.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			// Clients are not expected to send anything; reading only
+			// notices when they go away.
+			go func() {
+				_, _ = io.Copy(io.Discard, ws)
+				cancel()
+			}()
+
+			send := func(message any) error {
+				_ = ws.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+				return websocket.JSON.Send(ws, message)
+			}
+			streamEvents(ctx, events,
+				func(event Domain.TaskEvent) error {
+					return send(event)
+				},
+				func() error {
+					return send(gin.H{"type": "heartbeat"})
+				},
+			)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// streamEvents sends events until ctx is done, the subscription is closed
+// or sending fails, and sends a heartbeat whenever the stream has been idle
+// for eventHeartbeatInterval. A subscription is closed early when the client
+// cannot keep up; it is then expected to reconnect with the last event ID.
+func streamEvents(ctx context.Context, events <-chan Domain.TaskEvent, send func(Domain.TaskEvent) error, heartbeat func() error) {
+	ticker := time.NewTicker(eventHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok || send(event) != nil {
+				return
+			}
+			ticker.Reset(eventHeartbeatInterval)
+		case <-ticker.C:
+			if heartbeat() != nil {
+				return
+			}
+		}
+	}
+}
+
+// lastEventID returns the ID of the last event a reconnecting client has
+// received, taken from the Last-Event-ID header or the last_event_id query
+// parameter, or 0 for a new client.
+func lastEventID(c *gin.Context) (uint64, error) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, Domain.NewValidationError("last_event_id", "must be a non-negative integer")
+	}
+	return id, nil
+}
+
+// streamContext returns the request context carrying the actor, ending it
+// when the access token that opened the stream expires.
+func streamContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	ctx := requestContext(c)
+	if claims, ok := c.Value("claims").(*Infrastructure.Claims); ok && claims.ExpiresAt != 0 {
+		return context.WithDeadline(ctx, time.Unix(claims.ExpiresAt, 0))
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"task_manager/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUsers handles GET /users to list and search users
+func (uc *UserController) GetUsers(c *gin.Context) {
+	query := Domain.UserQuery{
+		Search: c.Query("q"),
+		Role:   Domain.UserRole(c.Query("role")),
+	}
+	if value := c.Query("disabled"); value != "" {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			_ = c.Error(Domain.NewValidationError("disabled", "must be true or false"))
+			return
+		}
+		query.Disabled = &disabled
+	}
+	var err error
+	if query.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if query.Offset, err = parseIntQuery(c, "offset"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	page, err := uc.userUsecase.ListUsers(requestContext(c), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":  page.Users,
+		"count":  len(page.Users),
+		"total":  page.Total,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+		"links":  pageLinks(c, page.Total, page.Limit, page.Offset),
+	})
+}
+
+// GetUser handles GET /users/:id to retrieve a user
+func (uc *UserController) GetUser(c *gin.Context) {
+	user, err := uc.userUsecase.GetUser(requestContext(c), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// UpdateUserRole handles PUT /users/:id/role to change the role of a user
+func (uc *UserController) UpdateUserRole(c *gin.Context) {
+	var body struct {
+		Role Domain.UserRole `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	user, err := uc.userUsecase.SetUserRole(requestContext(c), c.Param("id"), body.Role)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User role updated successfully",
+		"user":    user,
+	})
+}
+
+// DisableUser handles POST /users/:id/disable to lock a user out
+func (uc *UserController) DisableUser(c *gin.Context) {
+	uc.setDisabled(c, true, "User disabled successfully")
+}
+
+// EnableUser handles POST /users/:id/enable to let a disabled user back in
+func (uc *UserController) EnableUser(c *gin.Context) {
+	uc.setDisabled(c, false, "User enabled successfully")
+}
+
+// setDisabled disables or enables the user in the request path.
+func (uc *UserController) setDisabled(c *gin.Context, disabled bool, message string) {
+	user, err := uc.userUsecase.SetUserDisabled(requestContext(c), c.Param("id"), disabled)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"user":    user,
+	})
+}
+
+// UnlockUser handles POST /users/:id/unlock to lift the login delay or
+// lockout of a user
+func (uc *UserController) UnlockUser(c *gin.Context) {
+	user, err := uc.userUsecase.UnlockUser(requestContext(c), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unlocked successfully",
+		"user":    user,
+	})
+}
+
+// DeleteUser handles DELETE /users/:id to delete a user
+func (uc *UserController) DeleteUser(c *gin.Context) {
+	if err := uc.userUsecase.DeleteUser(requestContext(c), c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"net/http"
+	"task_manager/Domain"
+	"task_manager/Usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookController handles webhook-related HTTP requests
+type WebhookController struct {
+	webhookUsecase Usecase.WebhookUsecase
+}
+
+// NewWebhookController creates a new WebhookController
+func NewWebhookController(webhookUsecase Usecase.WebhookUsecase) *WebhookController {
+	return &WebhookController{webhookUsecase: webhookUsecase}
+}
+
+// CreateWebhook handles POST /webhooks to register a webhook subscription
+func (wc *WebhookController) CreateWebhook(c *gin.Context) {
+	var sub Domain.WebhookSubscription
+	if err := c.ShouldBindJSON(&sub); err != nil {
+		invalidBody(c, err)
+		return
+	}
+
+	created, err := wc.webhookUsecase.CreateSubscription(requestContext(c), sub)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"webhook": created.Redacted(),
+	})
+}
+
+// GetWebhooks handles GET /webhooks to list all webhook subscriptions
+func (wc *WebhookController) GetWebhooks(c *gin.Context) {
+	subs, err := wc.webhookUsecase.ListSubscriptions(requestContext(c))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	webhooks := make([]Domain.WebhookSubscription, len(subs))
+	for i, sub := range subs {
+		webhooks[i] = sub.Redacted()
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks, "count": len(webhooks)})
+}
+
+// GetWebhook handles GET /webhooks/:id to retrieve a webhook subscription
+func (wc *WebhookController) GetWebhook(c *gin.Context) {
+	sub, err := wc.webhookUsecase.GetSubscription(requestContext(c), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhook": sub.Redacted()})
+}
+
+// DeleteWebhook handles DELETE /webhooks/:id to remove a webhook subscription
+func (wc *WebhookController) DeleteWebhook(c *gin.Context) {
+	if err := wc.webhookUsecase.DeleteSubscription(requestContext(c), c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries handles GET /webhooks/deliveries to list recent webhook
+// deliveries, optionally filtered by subscription and status
+func (wc *WebhookController) GetDeliveries(c *gin.Context) {
+	query := Domain.WebhookDeliveryQuery{
+		SubscriptionID: c.Query("subscription_id"),
+		Status:         Domain.WebhookDeliveryStatus(c.Query("status")),
+	}
+	var err error
+	if query.Limit, err = parseIntQuery(c, "limit"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if query.Offset, err = parseIntQuery(c, "offset"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	page, err := wc.webhookUsecase.ListDeliveries(requestContext(c), query)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": page.Deliveries,
+		"count":      len(page.Deliveries),
+		"total":      page.Total,
+		"limit":      page.Limit,
+		"offset":     page.Offset,
+		"links":      pageLinks(c, page.Total, page.Limit, page.Offset),
+	})
+}
+
+// Redeliver handles POST /webhooks/deliveries/:id/redeliver to queue a
+// delivery again
+func (wc *WebhookController) Redeliver(c *gin.Context) {
+	delivery, err := wc.webhookUsecase.Redeliver(requestContext(c), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "Delivery queued successfully",
+		"delivery": delivery,
+	})
+}
@@ -5,11 +5,15 @@ import (
 	"context"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"task_manager/Delivery/controllers"
 	"task_manager/Delivery/routers"
+	"task_manager/Domain"
 	"task_manager/Infrastructure"
 	"task_manager/Repositories"
 	"task_manager/Usecase"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -29,6 +33,54 @@ func initMongoClient(connectionString string) *mongo.Client {
 	return client
 }
 
+// getEnv reads a variable from the environment, falling back to def when it is unset.
+func getEnv(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvDuration reads a duration such as "15m" from the environment,
+// falling back to def when the variable is unset.
+func getEnvDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", name, err)
+	}
+	return d
+}
+
+// getEnvInt reads a non-negative integer from the environment, falling back
+// to def when the variable is unset.
+func getEnvInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("invalid %s: must be a non-negative integer", name)
+	}
+	return n
+}
+
+// getEnvList reads a comma-separated list from the environment. It is empty
+// when the variable is unset.
+func getEnvList(name string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // main starts the Task Manager API server.
 func main() {
 	// Load .env file
@@ -37,6 +89,10 @@ func main() {
 	}
 
 	// Load environment variables
+	storageBackend := os.Getenv("STORAGE_BACKEND")
+	if storageBackend == "" {
+		storageBackend = "mongo"
+	}
 	connectionString := os.Getenv("MONGODB_URI")
 	if connectionString == "" {
 		connectionString = "mongodb://localhost:27017"
@@ -57,27 +113,255 @@ func main() {
 	if usersCollection == "" {
 		usersCollection = "users"
 	}
-
-	// Initialize MongoDB client
-	client := initMongoClient(connectionString)
-	defer client.Disconnect(context.Background())
+	revokedTokensCollection := os.Getenv("REVOKED_TOKENS_COLLECTION")
+	if revokedTokensCollection == "" {
+		revokedTokensCollection = "revoked_tokens"
+	}
+	taskHistoryCollection := getEnv("TASK_HISTORY_COLLECTION", "task_history")
+	commentsCollection := getEnv("COMMENTS_COLLECTION", "comments")
+	projectsCollection := getEnv("PROJECTS_COLLECTION", "projects")
+	trashRetentionDays := getEnvInt("TRASH_RETENTION_DAYS", 30)
+	trashRetention := time.Duration(trashRetentionDays) * 24 * time.Hour
+	trashPurgeInterval := getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour)
+	trashTTLIndex := getEnv("TRASH_TTL_INDEX", "false") == "true"
+	reminderPreferencesCollection := getEnv("REMINDER_PREFERENCES_COLLECTION", "reminder_preferences")
+	sentRemindersCollection := getEnv("SENT_REMINDERS_COLLECTION", "sent_reminders")
+	reminderInterval := getEnvDuration("REMINDER_INTERVAL", time.Minute)
+	reminderLeadTime := getEnvDuration("REMINDER_LEAD_TIME", 24*time.Hour)
+	reminderNotifier := getEnv("REMINDER_NOTIFIER", "log")
+	if reminderLeadTime > Domain.MaxReminderLead {
+		log.Fatalf("invalid REMINDER_LEAD_TIME: cannot exceed %s", Domain.MaxReminderLead)
+	}
+	webhookSubscriptionsCollection := getEnv("WEBHOOK_SUBSCRIPTIONS_COLLECTION", "webhook_subscriptions")
+	webhookDeliveriesCollection := getEnv("WEBHOOK_DELIVERIES_COLLECTION", "webhook_deliveries")
+	webhookInterval := getEnvDuration("WEBHOOK_INTERVAL", 5*time.Second)
+	webhookTimeout := getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	webhookRetry := Domain.WebhookRetryPolicy{
+		MaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		BaseDelay:   getEnvDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+		MaxDelay:    getEnvDuration("WEBHOOK_RETRY_MAX", time.Hour),
+	}
+	if webhookRetry.MaxAttempts < 1 || webhookRetry.BaseDelay <= 0 || webhookRetry.MaxDelay < webhookRetry.BaseDelay {
+		log.Fatal("invalid webhook retry settings: WEBHOOK_MAX_ATTEMPTS must be at least 1 and WEBHOOK_RETRY_MAX at least WEBHOOK_RETRY_BASE")
+	}
+	eventHistorySize := getEnvInt("EVENT_HISTORY_SIZE", 1000)
+	eventBufferSize := getEnvInt("EVENT_BUFFER_SIZE", 64)
+	workflow := Domain.DefaultWorkflow()
+	if workflowFile := os.Getenv("TASK_WORKFLOW_FILE"); workflowFile != "" {
+		data, err := os.ReadFile(workflowFile)
+		if err != nil {
+			log.Fatalf("invalid TASK_WORKFLOW_FILE: %v", err)
+		}
+		if workflow, err = Domain.ParseWorkflow(data); err != nil {
+			log.Fatalf("invalid TASK_WORKFLOW_FILE %s: %v", workflowFile, err)
+		}
+	}
+	policy := Domain.DefaultPolicy()
+	if policyFile := os.Getenv("ACCESS_POLICY_FILE"); policyFile != "" {
+		data, err := os.ReadFile(policyFile)
+		if err != nil {
+			log.Fatalf("invalid ACCESS_POLICY_FILE: %v", err)
+		}
+		if policy, err = Domain.ParsePolicy(data); err != nil {
+			log.Fatalf("invalid ACCESS_POLICY_FILE %s: %v", policyFile, err)
+		}
+	}
+	accessTokenTTL := getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	refreshTokenTTL := getEnvDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour)
+	passwordResetsCollection := getEnv("PASSWORD_RESETS_COLLECTION", "password_resets")
+	passwordResetTTL := getEnvDuration("PASSWORD_RESET_TTL", 30*time.Minute)
+	passwordRateLimit := getEnvInt("PASSWORD_RATE_LIMIT", 5)
+	passwordRateWindow := getEnvDuration("PASSWORD_RATE_WINDOW", 15*time.Minute)
+	if passwordResetTTL <= 0 || passwordRateLimit < 1 || passwordRateWindow <= 0 {
+		log.Fatal("invalid password reset settings: PASSWORD_RESET_TTL and PASSWORD_RATE_WINDOW must be positive and PASSWORD_RATE_LIMIT at least 1")
+	}
+	mailSender := getEnv("MAIL_SENDER", "smtp")
+	trustedProxies := getEnvList("TRUSTED_PROXIES")
+	loginAttemptsCollection := getEnv("LOGIN_ATTEMPTS_COLLECTION", "login_attempts")
+	userThrottle := Domain.DefaultUsernameThrottle()
+	userThrottle.FreeAttempts = getEnvInt("LOGIN_FREE_ATTEMPTS", userThrottle.FreeAttempts)
+	userThrottle.LockoutThreshold = getEnvInt("LOGIN_LOCKOUT_THRESHOLD", userThrottle.LockoutThreshold)
+	ipThrottle := Domain.DefaultIPThrottle()
+	ipThrottle.FreeAttempts = getEnvInt("LOGIN_IP_FREE_ATTEMPTS", ipThrottle.FreeAttempts)
+	ipThrottle.LockoutThreshold = getEnvInt("LOGIN_IP_LOCKOUT_THRESHOLD", ipThrottle.LockoutThreshold)
+	for _, throttle := range []*Domain.LoginThrottle{&userThrottle, &ipThrottle} {
+		throttle.BaseDelay = getEnvDuration("LOGIN_BASE_DELAY", throttle.BaseDelay)
+		throttle.MaxDelay = getEnvDuration("LOGIN_MAX_DELAY", throttle.MaxDelay)
+		throttle.LockoutDuration = getEnvDuration("LOGIN_LOCKOUT_DURATION", throttle.LockoutDuration)
+		if err := throttle.Validate(); err != nil {
+			log.Fatalf("invalid LOGIN_* throttling settings: %v", err)
+		}
+	}
+	passwordPolicy := Domain.DefaultPasswordPolicy()
+	passwordPolicy.MinLength = getEnvInt("PASSWORD_MIN_LENGTH", passwordPolicy.MinLength)
+	passwordPolicy.MinClasses = getEnvInt("PASSWORD_MIN_CLASSES", passwordPolicy.MinClasses)
+	if blocklistFile := os.Getenv("PASSWORD_BLOCKLIST_FILE"); blocklistFile != "" {
+		data, err := os.ReadFile(blocklistFile)
+		if err != nil {
+			log.Fatalf("invalid PASSWORD_BLOCKLIST_FILE: %v", err)
+		}
+		passwordPolicy.Blocklist = Domain.ParsePasswordList(data)
+	}
+	if err := passwordPolicy.Validate(); err != nil {
+		log.Fatalf("invalid PASSWORD_MIN_LENGTH or PASSWORD_MIN_CLASSES: %v", err)
+	}
 
 	// Initialize repositories
-	taskRepo := Repositories.NewMongoTaskRepository(client, dbName, tasksCollection)
-	userRepo := Repositories.NewMongoUserRepository(client, dbName, usersCollection)
+	var (
+		taskRepo        Domain.TaskRepository
+		userRepo        Domain.UserRepository
+		resetRepo       Domain.PasswordResetRepository
+		loginAttempts   Domain.LoginAttemptStore
+		revocationStore Domain.RevocationStore
+		auditRepo       Domain.AuditRepository
+		commentRepo     Domain.CommentRepository
+		projectRepo     Domain.ProjectRepository
+		reminderRepo    Domain.ReminderRepository
+		webhookRepo     Domain.WebhookRepository
+	)
+	switch storageBackend {
+	case "mongo":
+		client := initMongoClient(connectionString)
+		defer client.Disconnect(context.Background())
+
+		taskRepo = Repositories.NewMongoTaskRepository(client, dbName, tasksCollection)
+		if trashTTLIndex && trashRetention > 0 {
+			if err := Repositories.EnsureTaskTrashTTLIndex(context.Background(), client, dbName, tasksCollection, trashRetention); err != nil {
+				log.Fatal(err)
+			}
+		}
+		userRepo = Repositories.NewMongoUserRepository(client, dbName, usersCollection)
+		resetRepo = Repositories.NewMongoPasswordResetRepository(client, dbName, passwordResetsCollection)
+		loginAttempts = Repositories.NewMongoLoginAttemptStore(client, dbName, loginAttemptsCollection)
+		revocationStore = Repositories.NewMongoRevocationStore(client, dbName, revokedTokensCollection)
+		auditRepo = Repositories.NewMongoAuditRepository(client, dbName, taskHistoryCollection)
+		commentRepo = Repositories.NewMongoCommentRepository(client, dbName, commentsCollection)
+		projectRepo = Repositories.NewMongoProjectRepository(client, dbName, projectsCollection)
+		reminderRepo = Repositories.NewMongoReminderRepository(client, dbName, reminderPreferencesCollection, sentRemindersCollection)
+		webhookRepo = Repositories.NewMongoWebhookRepository(client, dbName, webhookSubscriptionsCollection, webhookDeliveriesCollection)
+	case "memory":
+		log.Println("Using in-memory storage; all data is lost when the server stops")
+		taskRepo = Repositories.NewInMemoryTaskRepository()
+		userRepo = Repositories.NewInMemoryUserRepository()
+		resetRepo = Repositories.NewInMemoryPasswordResetRepository()
+		loginAttempts = Repositories.NewInMemoryLoginAttemptStore()
+		revocationStore = Repositories.NewInMemoryRevocationStore()
+		auditRepo = Repositories.NewInMemoryAuditRepository()
+		commentRepo = Repositories.NewInMemoryCommentRepository()
+		projectRepo = Repositories.NewInMemoryProjectRepository()
+		reminderRepo = Repositories.NewInMemoryReminderRepository()
+		webhookRepo = Repositories.NewInMemoryWebhookRepository()
+	default:
+		log.Fatalf("unknown STORAGE_BACKEND %q: must be \"mongo\" or \"memory\"", storageBackend)
+	}
 
 	// Initialize services
-	jwtService := Infrastructure.NewJWTService(jwtSecret)
+	jwtService := Infrastructure.NewJWTService(jwtSecret, accessTokenTTL, refreshTokenTTL)
 	passwordService := Infrastructure.NewPasswordService()
+	taskEvents := Infrastructure.NewInMemoryTaskEventBroker(eventHistorySize, eventBufferSize)
+	smtpConfig := Infrastructure.SMTPConfig{
+		Host:     getEnv("SMTP_HOST", "localhost"),
+		Port:     getEnvInt("SMTP_PORT", 25),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     getEnv("SMTP_FROM", "task-manager@localhost"),
+	}
+	var notifier Domain.Notifier
+	switch reminderNotifier {
+	case "log":
+		notifier = Infrastructure.NewLogNotifier()
+	case "smtp":
+		notifier = Infrastructure.NewSMTPNotifier(smtpConfig)
+	case "webhook":
+		webhookURL := os.Getenv("REMINDER_WEBHOOK_URL")
+		if webhookURL == "" {
+			log.Fatal("REMINDER_WEBHOOK_URL is required when REMINDER_NOTIFIER is \"webhook\"")
+		}
+		notifier = Infrastructure.NewWebhookNotifier(webhookURL, 10*time.Second)
+	default:
+		log.Fatalf("unknown REMINDER_NOTIFIER %q: must be \"log\", \"smtp\" or \"webhook\"", reminderNotifier)
+	}
+	var mailer Domain.MailSender
+	switch mailSender {
+	case "log":
+		log.Println("MAIL_SENDER=log writes password reset tokens to the server log; use it for development only")
+		mailer = Infrastructure.NewLogMailSender()
+	case "smtp":
+		mailer = Infrastructure.NewSMTPMailSender(smtpConfig)
+	default:
+		log.Fatalf("unknown MAIL_SENDER %q: must be \"log\" or \"smtp\"", mailSender)
+	}
 
 	// Initialize use cases
-	taskUsecase := Usecase.NewTaskUsecase(taskRepo)
-	userUsecase := Usecase.NewUserUsecase(userRepo, jwtService, passwordService)
+	webhookUsecase := Usecase.NewWebhookUsecase(webhookRepo, Infrastructure.NewHTTPWebhookSender(webhookTimeout), webhookRetry, webhookTimeout+time.Minute, policy)
+	taskUsecase := Usecase.NewTaskUsecase(taskRepo, auditRepo, commentRepo, userRepo, projectRepo, taskEvents, webhookUsecase, workflow, policy)
+	commentUsecase := Usecase.NewCommentUsecase(taskRepo, commentRepo, projectRepo, policy)
+	projectUsecase := Usecase.NewProjectUsecase(projectRepo, taskRepo, policy)
+	userUsecase := Usecase.NewUserUsecase(userRepo, resetRepo, jwtService, passwordService, revocationStore, mailer, passwordPolicy, policy, passwordResetTTL, loginAttempts, userThrottle, ipThrottle)
+	reminderUsecase := Usecase.NewReminderUsecase(taskRepo, reminderRepo, notifier, Domain.ReminderPreferences{
+		Enabled:     reminderLeadTime > 0,
+		LeadMinutes: int(reminderLeadTime / time.Minute),
+	})
+
+	// Seed the first admin
+	if adminUsername := os.Getenv("BOOTSTRAP_ADMIN_USERNAME"); adminUsername != "" {
+		adminPassword := os.Getenv("BOOTSTRAP_ADMIN_PASSWORD")
+		if adminPassword == "" {
+			log.Fatal("BOOTSTRAP_ADMIN_PASSWORD is required when BOOTSTRAP_ADMIN_USERNAME is set")
+		}
+		created, err := userUsecase.BootstrapAdmin(context.Background(), adminUsername, adminPassword)
+		if err != nil {
+			log.Fatalf("Creating admin %s failed: %v", adminUsername, err)
+		}
+		if created {
+			log.Printf("Created admin %s", adminUsername)
+		}
+	}
+
+	// Start background jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if trashRetention > 0 {
+		go Infrastructure.RunPeriodically(ctx, trashPurgeInterval, func(ctx context.Context) {
+			purged, err := taskUsecase.PurgeTrash(ctx, trashRetention)
+			if err != nil {
+				log.Println("Trash purge failed:", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d task(s) from the trash", purged)
+			}
+		})
+	}
+
+	if reminderInterval > 0 {
+		go Infrastructure.RunPeriodically(ctx, reminderInterval, func(ctx context.Context) {
+			sent, err := reminderUsecase.SendDueReminders(ctx, time.Now())
+			if err != nil {
+				log.Println("Sending reminders failed:", err)
+			} else if sent > 0 {
+				log.Printf("Sent %d reminder(s)", sent)
+			}
+		})
+	}
+
+	if webhookInterval > 0 {
+		go Infrastructure.RunPeriodically(ctx, webhookInterval, func(ctx context.Context) {
+			if _, err := webhookUsecase.DeliverDue(ctx, time.Now()); err != nil {
+				log.Println("Delivering webhooks failed:", err)
+			}
+		})
+	}
 
 	// Initialize controllers and router
 	taskController := controllers.NewTaskController(taskUsecase)
 	userController := controllers.NewUserController(userUsecase)
-	router := routers.SetupRouter(taskController, userController, jwtService)
+	reminderController := controllers.NewReminderController(reminderUsecase)
+	webhookController := controllers.NewWebhookController(webhookUsecase)
+	commentController := controllers.NewCommentController(commentUsecase)
+	projectController := controllers.NewProjectController(projectUsecase)
+	router, err := routers.SetupRouter(taskController, userController, reminderController, webhookController, commentController, projectController, jwtService, revocationStore, userRepo, policy, Infrastructure.NewRateLimiter(passwordRateLimit, passwordRateWindow), trustedProxies)
+	if err != nil {
+		log.Fatal("invalid TRUSTED_PROXIES: ", err)
+	}
 
 	// Start server
 	log.Println("Server is running on http://localhost:8080")
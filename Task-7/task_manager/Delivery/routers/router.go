@@ -3,27 +3,117 @@ package routers
 
 import (
 	"task_manager/Delivery/controllers"
+	"task_manager/Domain"
 	"task_manager/Infrastructure"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(taskController *controllers.TaskController, userController *controllers.UserController, jwtService Infrastructure.JWTService) *gin.Engine {
+func SetupRouter(taskController *controllers.TaskController, userController *controllers.UserController, reminderController *controllers.ReminderController, webhookController *controllers.WebhookController, commentController *controllers.CommentController, projectController *controllers.ProjectController, jwtService Infrastructure.JWTService, revocations Domain.RevocationStore, userRepo Domain.UserRepository, authz Domain.Authorizer, passwordLimiter *Infrastructure.RateLimiter, trustedProxies []string) (*gin.Engine, error) {
 	r := gin.Default()
+	// The client IP keys rate limits and login throttling, so
+	// X-Forwarded-For and X-Real-IP are only believed when the request
+	// comes from one of the trusted proxies. By default none are.
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+	r.Use(Infrastructure.ErrorHandler())
+	auth := Infrastructure.AuthMiddleware(jwtService, revocations, userRepo)
+	// can declares the permissions a protected route requires.
+	can := func(permissions ...Domain.Permission) gin.HandlerFunc {
+		return Infrastructure.RequirePermission(authz, permissions...)
+	}
 
 	//Public routes
 	r.POST("/register", userController.RegisterUser)
 	r.POST("/login", userController.LogIn)
+	r.POST("/token/refresh", userController.RefreshToken)
+	// Password resets are rate limited per client IP.
+	password := r.Group("/password").Use(Infrastructure.RateLimitMiddleware(passwordLimiter))
+	{
+		password.POST("/forgot", userController.ForgotPassword)
+		password.POST("/reset", userController.ResetPassword)
+	}
 
 	//Protected routes
-	tasks := r.Group("/tasks").Use(Infrastructure.AuthMiddleware(jwtService)) 
+	r.POST("/logout", auth, userController.LogOut)
+
+	tasks := r.Group("/tasks").Use(auth) 
+	{
+		tasks.POST("", can(Domain.PermTaskCreate), taskController.CreateTask)
+		tasks.GET("", can(Domain.PermTaskRead), taskController.GetAllTasks)
+		// Each bulk operation is checked against the permission of its kind.
+		tasks.POST("/bulk", taskController.BulkTasks)
+		tasks.GET("/search", can(Domain.PermTaskRead), taskController.SearchTasks)
+		tasks.GET("/events", can(Domain.PermTaskRead), taskController.StreamTaskEvents)
+		tasks.GET("/events/ws", can(Domain.PermTaskRead), taskController.StreamTaskEventsWebSocket)
+		tasks.GET("/:id", can(Domain.PermTaskRead), taskController.GetTask)
+		tasks.PUT("/:id", can(Domain.PermTaskUpdate), taskController.UpdateTask)
+		tasks.PATCH("/:id", can(Domain.PermTaskUpdate), taskController.PatchTask)
+		tasks.DELETE("/:id", can(Domain.PermTaskDelete), taskController.DeleteTask)
+		tasks.POST("/:id/restore", can(Domain.PermTaskUpdate), taskController.RestoreTask)
+		tasks.GET("/:id/history", can(Domain.PermTaskRead), taskController.GetTaskHistory)
+		tasks.GET("/:id/tree", can(Domain.PermTaskRead), taskController.GetTaskTree)
+		tasks.POST("/:id/assign", can(Domain.PermTaskUpdate), taskController.AssignTask)
+		tasks.POST("/:id/unassign", can(Domain.PermTaskUpdate), taskController.UnassignTask)
+		tasks.POST("/:id/comments", can(Domain.PermTaskRead, Domain.PermCommentWrite), commentController.CreateComment)
+		tasks.GET("/:id/comments", can(Domain.PermTaskRead), commentController.GetComments)
+		tasks.PUT("/:id/comments/:comment_id", can(Domain.PermTaskRead, Domain.PermCommentWrite), commentController.UpdateComment)
+		tasks.DELETE("/:id/comments/:comment_id", can(Domain.PermTaskRead, Domain.PermCommentWrite), commentController.DeleteComment)
+	}
+
+	projects := r.Group("/projects").Use(auth)
+	{
+		projects.POST("", can(Domain.PermProjectWrite), projectController.CreateProject)
+		projects.GET("", can(Domain.PermProjectRead), projectController.GetProjects)
+		projects.GET("/:id", can(Domain.PermProjectRead), projectController.GetProject)
+		projects.PUT("/:id", can(Domain.PermProjectWrite), projectController.UpdateProject)
+		projects.DELETE("/:id", can(Domain.PermProjectWrite), projectController.DeleteProject)
+		projects.GET("/:id/tasks", can(Domain.PermProjectRead, Domain.PermTaskRead), projectController.GetProjectTasks)
+	}
+
+	r.GET("/tags", auth, can(Domain.PermTaskRead), taskController.GetTags)
+	r.GET("/workflow", auth, can(Domain.PermTaskRead), taskController.GetWorkflow)
+
+	trash := r.Group("/trash").Use(auth)
+	{
+		trash.GET("", can(Domain.PermTaskRead), taskController.GetTrash)
+		trash.DELETE("/:id", can(Domain.PermTaskPurge), taskController.PurgeTask)
+	}
+
+	me := r.Group("/me").Use(auth)
+	{
+		me.GET("/tasks", can(Domain.PermTaskRead), taskController.GetAssignedTasks)
+		me.POST("/password", userController.ChangePassword)
+		me.GET("/reminders", reminderController.GetPreferences)
+		me.PUT("/reminders", reminderController.UpdatePreferences)
+	}
+
+	audit := r.Group("/audit").Use(auth, can(Domain.PermAuditRead))
+	{
+		audit.GET("", taskController.GetAuditLog)
+	}
+
+	users := r.Group("/users").Use(auth, can(Domain.PermUserManage))
+	{
+		users.GET("", userController.GetUsers)
+		users.GET("/:id", userController.GetUser)
+		users.PUT("/:id/role", userController.UpdateUserRole)
+		users.POST("/:id/disable", userController.DisableUser)
+		users.POST("/:id/enable", userController.EnableUser)
+		users.POST("/:id/unlock", userController.UnlockUser)
+		users.DELETE("/:id", userController.DeleteUser)
+	}
+
+	webhooks := r.Group("/webhooks").Use(auth, can(Domain.PermWebhookManage))
 	{
-		tasks.POST("", taskController.CreateTask)
-		tasks.GET("", taskController.GetAllTasks)
-		tasks.GET("/:id", taskController.GetTask)
-		tasks.PUT("/:id", taskController.UpdateTask)
-		tasks.DELETE("/:id",Infrastructure.AdminOnlyMiddleware(),taskController.DeleteTask)
+		webhooks.POST("", webhookController.CreateWebhook)
+		webhooks.GET("", webhookController.GetWebhooks)
+		webhooks.GET("/deliveries", webhookController.GetDeliveries)
+		webhooks.POST("/deliveries/:id/redeliver", webhookController.Redeliver)
+		webhooks.GET("/:id", webhookController.GetWebhook)
+		webhooks.DELETE("/:id", webhookController.DeleteWebhook)
 	}
 
-	return r
+	return r, nil
 }
\ No newline at end of file
@@ -0,0 +1,247 @@
+package routers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"task_manager/Delivery/controllers"
+	"task_manager/Delivery/routers"
+	"task_manager/Domain"
+	"task_manager/Infrastructure"
+	"task_manager/Repositories"
+	"task_manager/Usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chanMailer implements Domain.MailSender by passing the mails to a channel.
+type chanMailer chan Domain.Mail
+
+// Send implements Domain.MailSender.
+func (m chanMailer) Send(ctx context.Context, mail Domain.Mail) error {
+	m <- mail
+	return nil
+}
+
+// testServer is the full API over in-memory repositories.
+type testServer struct {
+	router *gin.Engine
+	mails  chanMailer
+}
+
+// newTestServer creates a testServer that trusts the given proxies, allows
+// passwordRequests requests to the /password routes per client IP and
+// throttles failed logins per client IP with ipThrottle.
+func newTestServer(t *testing.T, trustedProxies []string, passwordRequests int, ipThrottle Domain.LoginThrottle) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	taskRepo := Repositories.NewInMemoryTaskRepository()
+	userRepo := Repositories.NewInMemoryUserRepository()
+	commentRepo := Repositories.NewInMemoryCommentRepository()
+	projectRepo := Repositories.NewInMemoryProjectRepository()
+	revocations := Repositories.NewInMemoryRevocationStore()
+	policy := Domain.DefaultPolicy()
+	jwtService := Infrastructure.NewJWTService("test-secret", 15*time.Minute, time.Hour)
+	mails := make(chanMailer, 10)
+
+	webhookUsecase := Usecase.NewWebhookUsecase(Repositories.NewInMemoryWebhookRepository(), Infrastructure.NewHTTPWebhookSender(time.Second), Domain.WebhookRetryPolicy{MaxAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Second}, time.Minute, policy)
+	taskUsecase := Usecase.NewTaskUsecase(taskRepo, Repositories.NewInMemoryAuditRepository(), commentRepo, userRepo, projectRepo, Infrastructure.NewInMemoryTaskEventBroker(10, 10), webhookUsecase, Domain.DefaultWorkflow(), policy)
+	userUsecase := Usecase.NewUserUsecase(userRepo, Repositories.NewInMemoryPasswordResetRepository(), jwtService, Infrastructure.NewPasswordService(), revocations, mails, Domain.DefaultPasswordPolicy(), policy, 30*time.Minute, Repositories.NewInMemoryLoginAttemptStore(), Domain.DefaultUsernameThrottle(), ipThrottle)
+	reminderUsecase := Usecase.NewReminderUsecase(taskRepo, Repositories.NewInMemoryReminderRepository(), Infrastructure.NewLogNotifier(), Domain.ReminderPreferences{})
+
+	router, err := routers.SetupRouter(
+		controllers.NewTaskController(taskUsecase),
+		controllers.NewUserController(userUsecase),
+		controllers.NewReminderController(reminderUsecase),
+		controllers.NewWebhookController(webhookUsecase),
+		controllers.NewCommentController(Usecase.NewCommentUsecase(taskRepo, commentRepo, projectRepo, policy)),
+		controllers.NewProjectController(Usecase.NewProjectUsecase(projectRepo, taskRepo, policy)),
+		jwtService, revocations, userRepo, policy,
+		Infrastructure.NewRateLimiter(passwordRequests, time.Hour),
+		trustedProxies,
+	)
+	if err != nil {
+		t.Fatalf("SetupRouter: %v", err)
+	}
+	return &testServer{router: router, mails: mails}
+}
+
+// do sends a request with a JSON body from remoteAddr, claiming to be
+// forwarded for forwardedFor if it is set.
+func (s *testServer) do(t *testing.T, method, path, remoteAddr, forwardedFor string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("failed to encode body: %v", err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Content-Type", "application/json")
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSetupRouterRejectsInvalidTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, err := routers.SetupRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, Domain.DefaultPolicy(), Infrastructure.NewRateLimiter(1, time.Hour), []string{"not-an-ip"})
+	if err == nil {
+		t.Error("SetupRouter accepted an invalid trusted proxy")
+	}
+}
+
+func TestPasswordRateLimitByClientIP(t *testing.T) {
+	forgot := func(s *testServer, remoteAddr, forwardedFor string) int {
+		t.Helper()
+		return s.do(t, http.MethodPost, "/password/forgot", remoteAddr, forwardedFor, map[string]string{"username": "nobody"}).Code
+	}
+
+	// Without trusted proxies, X-Forwarded-For cannot be used to get a new
+	// allowance.
+	s := newTestServer(t, nil, 2, Domain.DefaultIPThrottle())
+	for i, forwardedFor := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+		want := http.StatusAccepted
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if got := forgot(s, "192.0.2.1:1234", forwardedFor); got != want {
+			t.Errorf("request %d forwarded for %s: status %d, want %d", i+1, forwardedFor, got, want)
+		}
+	}
+	if got := forgot(s, "192.0.2.2:1234", ""); got != http.StatusAccepted {
+		t.Errorf("request from another client: status %d, want %d", got, http.StatusAccepted)
+	}
+
+	// Behind a trusted proxy, every forwarded client has its own allowance.
+	s = newTestServer(t, []string{"192.0.2.0/24"}, 2, Domain.DefaultIPThrottle())
+	for _, forwardedFor := range []string{"198.51.100.1", "198.51.100.1", "198.51.100.2"} {
+		if got := forgot(s, "192.0.2.1:1234", forwardedFor); got != http.StatusAccepted {
+			t.Errorf("request forwarded for %s by a trusted proxy: status %d, want %d", forwardedFor, got, http.StatusAccepted)
+		}
+	}
+	if got := forgot(s, "192.0.2.1:1234", "198.51.100.1"); got != http.StatusTooManyRequests {
+		t.Errorf("third request forwarded for 198.51.100.1: status %d, want %d", got, http.StatusTooManyRequests)
+	}
+}
+
+func TestForgotPasswordRespondsAlikeForUnknownUsers(t *testing.T) {
+	s := newTestServer(t, nil, 10, Domain.DefaultIPThrottle())
+	register := s.do(t, http.MethodPost, "/register", "192.0.2.1:1234", "", map[string]string{
+		"username": "alice", "password": "Passw0rd!x", "email": "alice@example.com",
+	})
+	if register.Code != http.StatusCreated {
+		t.Fatalf("register: status %d: %s", register.Code, register.Body)
+	}
+
+	known := s.do(t, http.MethodPost, "/password/forgot", "192.0.2.1:1234", "", map[string]string{"username": "alice"})
+	unknown := s.do(t, http.MethodPost, "/password/forgot", "192.0.2.1:1234", "", map[string]string{"username": "mallory"})
+	if known.Code != http.StatusAccepted || unknown.Code != known.Code || unknown.Body.String() != known.Body.String() {
+		t.Errorf("known user: %d %s; unknown user: %d %s; want the same 202 response", known.Code, known.Body, unknown.Code, unknown.Body)
+	}
+
+	// Only the known user gets a mail, sent in the background.
+	select {
+	case mail := <-s.mails:
+		if mail.To != "alice@example.com" {
+			t.Errorf("reset mail sent to %q, want alice@example.com", mail.To)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reset mail was sent")
+	}
+	select {
+	case mail := <-s.mails:
+		t.Errorf("unexpected mail to %q", mail.To)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestLoginLocksOutClientIPWhateverItForwards(t *testing.T) {
+	// The delays are negligible, so that only the lockout stops attempts.
+	ipThrottle := Domain.LoginThrottle{BaseDelay: time.Nanosecond, MaxDelay: time.Nanosecond, LockoutThreshold: 3, LockoutDuration: time.Hour}
+	s := newTestServer(t, nil, 10, ipThrottle)
+	register := s.do(t, http.MethodPost, "/register", "192.0.2.9:1234", "", map[string]string{"username": "alice", "password": "Passw0rd!x"})
+	if register.Code != http.StatusCreated {
+		t.Fatalf("register: status %d: %s", register.Code, register.Body)
+	}
+	login := func(remoteAddr, forwardedFor, username, password string) *httptest.ResponseRecorder {
+		t.Helper()
+		return s.do(t, http.MethodPost, "/login", remoteAddr, forwardedFor, map[string]string{"username": username, "password": password})
+	}
+
+	// Every failure uses another username and claims another client, so
+	// only the throttle of the connection's address can stop them.
+	for i, forwardedFor := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+		if w := login("192.0.2.1:1234", forwardedFor, "guess"+forwardedFor, "wrong"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("failed login %d: status %d, want %d", i+1, w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// The address is now locked out, even with the right password and
+	// whatever it claims to forward for.
+	for _, forwardedFor := range []string{"", "198.51.100.4", "203.0.113.7, 198.51.100.5"} {
+		w := login("192.0.2.1:4321", forwardedFor, "alice", "Passw0rd!x")
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("login forwarded for %q: status %d, want %d", forwardedFor, w.Code, http.StatusTooManyRequests)
+		}
+		if retryAfter := w.Header().Get("Retry-After"); retryAfter == "" {
+			t.Errorf("login forwarded for %q: no Retry-After header", forwardedFor)
+		}
+	}
+
+	if w := login("192.0.2.2:1234", "", "alice", "Passw0rd!x"); w.Code != http.StatusOK {
+		t.Errorf("login from another address: status %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+}
+
+func TestConcurrentLoginGuessesAreThrottled(t *testing.T) {
+	// After two free failures the client IP has to wait an hour.
+	ipThrottle := Domain.LoginThrottle{FreeAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour, LockoutThreshold: 3, LockoutDuration: time.Hour}
+	s := newTestServer(t, nil, 10, ipThrottle)
+	register := s.do(t, http.MethodPost, "/register", "192.0.2.9:1234", "", map[string]string{"username": "alice", "password": "Passw0rd!x"})
+	if register.Code != http.StatusCreated {
+		t.Fatalf("register: status %d: %s", register.Code, register.Body)
+	}
+	login := func(remoteAddr, username, password string) int {
+		t.Helper()
+		return s.do(t, http.MethodPost, "/login", remoteAddr, "", map[string]string{"username": username, "password": password}).Code
+	}
+
+	// Successful logins do not use up the free attempts.
+	for i := 0; i < 3; i++ {
+		if code := login("192.0.2.1:1234", "alice", "Passw0rd!x"); code != http.StatusOK {
+			t.Fatalf("login %d: status %d, want %d", i+1, code, http.StatusOK)
+		}
+	}
+
+	// Guesses sent at the same time, each for another user, are counted
+	// before their passwords are checked, so only the free attempts of the
+	// client IP get through.
+	codes := make([]int, 20)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = s.do(t, http.MethodPost, "/login", "192.0.2.1:1234", "", map[string]string{"username": "guess" + strconv.Itoa(i), "password": "wrong"}).Code
+		}()
+	}
+	wg.Wait()
+	count := make(map[int]int)
+	for _, code := range codes {
+		count[code]++
+	}
+	if count[http.StatusUnauthorized] != 2 || count[http.StatusTooManyRequests] != len(codes)-2 {
+		t.Errorf("concurrent guesses got statuses %v, want 2 × %d and the rest %d", count, http.StatusUnauthorized, http.StatusTooManyRequests)
+	}
+}
@@ -0,0 +1,54 @@
+package Domain
+
+import (
+	"fmt"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxAssignees is the largest number of users a task can be assigned to.
+const MaxAssignees = 20
+
+// validateAssignees checks the assignees of a task.
+func validateAssignees(verr *ValidationError, assignees []primitive.ObjectID) {
+	if len(assignees) > MaxAssignees {
+		verr.Add("assignees", fmt.Sprintf("cannot have more than %d users", MaxAssignees))
+	}
+	for i, id := range assignees {
+		switch {
+		case id.IsZero():
+			verr.Add("assignees", "must list user IDs")
+		case slices.Contains(assignees[:i], id):
+			verr.Add("assignees", fmt.Sprintf("lists user %s twice", id.Hex()))
+		}
+	}
+}
+
+// IsAssignedTo reports whether the task is assigned to the user userID.
+func (t Task) IsAssignedTo(userID primitive.ObjectID) bool {
+	return slices.Contains(t.Assignees, userID)
+}
+
+// AddAssignees returns assignees with the users in ids appended, skipping
+// the users already assigned.
+func AddAssignees(assignees, ids []primitive.ObjectID) []primitive.ObjectID {
+	result := slices.Clone(assignees)
+	for _, id := range ids {
+		if !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// RemoveAssignees returns assignees without the users in ids.
+func RemoveAssignees(assignees, ids []primitive.ObjectID) []primitive.ObjectID {
+	var result []primitive.ObjectID
+	for _, id := range assignees {
+		if !slices.Contains(ids, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
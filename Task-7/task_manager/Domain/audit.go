@@ -0,0 +1,138 @@
+package Domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditAction is the kind of change recorded by an AuditEntry.
+type AuditAction string
+
+const (
+	AuditCreated  AuditAction = "created"
+	AuditUpdated  AuditAction = "updated"
+	AuditDeleted  AuditAction = "deleted"
+	AuditRestored AuditAction = "restored"
+	AuditPurged   AuditAction = "purged"
+)
+
+// FieldChange is the old and new value of a single task field. Old is nil
+// for fields of a newly created task.
+type FieldChange struct {
+	Field string  `json:"field" bson:"field"`
+	Old   *string `json:"old" bson:"old"`
+	New   *string `json:"new" bson:"new"`
+}
+
+// AuditEntry records who changed a task, when and how.
+type AuditEntry struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id"`
+	TaskID        primitive.ObjectID `json:"task_id" bson:"task_id"`
+	Action        AuditAction        `json:"action" bson:"action"`
+	ActorID       primitive.ObjectID `json:"actor_id" bson:"actor_id"`
+	ActorUsername string             `json:"actor_username" bson:"actor_username"`
+	Changes       []FieldChange      `json:"changes" bson:"changes"`
+	Timestamp     time.Time          `json:"timestamp" bson:"timestamp"`
+}
+
+// DiffTasks returns the fields that differ between before and after. A nil
+// before describes a task that did not exist yet.
+func DiffTasks(before *Task, after Task) []FieldChange {
+	var oldValues []string
+	if before != nil {
+		oldValues = auditedFields(*before)
+	}
+	newValues := auditedFields(after)
+
+	changes := []FieldChange{}
+	for i, field := range auditedFieldNames {
+		change := FieldChange{Field: field, New: &newValues[i]}
+		if oldValues != nil {
+			if oldValues[i] == newValues[i] {
+				continue
+			}
+			change.Old = &oldValues[i]
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// auditedFieldNames lists the task fields tracked by DiffTasks, in the order
+// auditedFields returns their values.
+var auditedFieldNames = []string{"title", "description", "due_date", "status", "recurrence", "parent_id", "checklist", "blocked_by", "project_id", "tags", "assignees"}
+
+func auditedFields(task Task) []string {
+	parentID := ""
+	if task.ParentID != nil {
+		parentID = task.ParentID.Hex()
+	}
+	projectID := ""
+	if task.ProjectID != nil {
+		projectID = task.ProjectID.Hex()
+	}
+	return []string{
+		task.Title,
+		task.Description,
+		task.DueDate.UTC().Format(time.RFC3339Nano),
+		string(task.Status),
+		task.Recurrence,
+		parentID,
+		formatChecklist(task.Checklist),
+		formatIDs(task.BlockedBy),
+		projectID,
+		strings.Join(task.Tags, ","),
+		formatIDs(task.Assignees),
+	}
+}
+
+// AuditQuery describes how an audit log listing is filtered and paginated.
+// Zero values mean "no filter". Page sizes follow the task listing limits.
+type AuditQuery struct {
+	TaskID string
+	UserID string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// Normalize fills in default values and validates the query.
+func (q *AuditQuery) Normalize() error {
+	verr := &ValidationError{}
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		verr.Add("from", "must be before to")
+	}
+	if q.Limit < 0 || q.Limit > MaxTaskLimit {
+		verr.Add("limit", fmt.Sprintf("must be between 1 and %d", MaxTaskLimit))
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTaskLimit
+	}
+	if q.Offset < 0 {
+		verr.Add("offset", "cannot be negative")
+	}
+	return verr.ErrOrNil()
+}
+
+// AuditPage is a single page of audit entries, newest first.
+type AuditPage struct {
+	Entries []AuditEntry `json:"entries"`
+	Total   int64        `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+}
+
+// AuditRepository stores the change history of tasks. Entries are never
+// modified or removed, not even when their task is purged.
+type AuditRepository interface {
+	// RecordEntry stores entry under a newly assigned ID.
+	RecordEntry(ctx context.Context, entry AuditEntry) (AuditEntry, error)
+	// GetEntries returns the entries matching query, newest first. From is
+	// inclusive and To exclusive.
+	GetEntries(ctx context.Context, query AuditQuery) (AuditPage, error)
+}
@@ -0,0 +1,92 @@
+package Domain
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// BulkOp is the kind of a BulkOperation.
+type BulkOp string
+
+const (
+	BulkCreate BulkOp = "create"
+	BulkUpdate BulkOp = "update"
+	BulkDelete BulkOp = "delete"
+)
+
+// IsValid checks if a BulkOp value is valid
+func (o BulkOp) IsValid() bool {
+	return o == BulkCreate || o == BulkUpdate || o == BulkDelete
+}
+
+// MaxBulkOperations is the largest number of operations a single bulk
+// request may contain.
+const MaxBulkOperations = 1000
+
+// BulkOperation is a single create, update or delete in a batch.
+type BulkOperation struct {
+	Op BulkOp `json:"op"`
+	// ID identifies the task to update or delete.
+	ID string `json:"id,omitempty"`
+	// Version, if not zero, must equal the current version of the task to
+	// update or delete.
+	Version int64 `json:"version,omitempty"`
+	// Task holds the new task for creates and the replacement for updates.
+	Task Task `json:"task"`
+}
+
+// BulkResult is the outcome of a single BulkOperation.
+type BulkResult struct {
+	// Task is the task as written. When the operation failed, only the ID
+	// of the task it referred to is set.
+	Task Task
+	// Previous is the task before an update or delete.
+	Previous *Task
+	Err      error
+}
+
+// TaskBatch is a list of operations applied by TaskRepository.WriteTasks.
+type TaskBatch struct {
+	Operations []BulkOperation
+	// VisibleTo restricts updates and deletes to tasks visible to the given
+	// user ID, who is a member of VisibleProjects; see Task.VisibleTo.
+	// Other tasks are reported as not found.
+	VisibleTo       string
+	VisibleProjects []primitive.ObjectID
+	// Atomic applies either all operations or, if any of them fails, none.
+	// The operations that did not fail themselves then report
+	// ErrBatchAborted.
+	Atomic bool
+}
+
+// ErrBatchAborted is reported for the operations of an atomic batch that
+// were not applied because another operation failed.
+var ErrBatchAborted = NewError(ErrConflict, "not applied because another operation of the atomic batch failed")
+
+// ValidateBulkUpdate checks that the task of a bulk update only sets the
+// fields a bulk update replaces: title, description, status and due date.
+// Relations, tags, assignees, checklists and recurrence can only be changed
+// one task at a time, with the checks of PUT /tasks/:id.
+func (t Task) ValidateBulkUpdate() error {
+	verr := &ValidationError{}
+	const msg = "cannot be changed by a bulk update; update the task on its own"
+	if t.Recurrence != "" {
+		verr.Add("recurrence", msg)
+	}
+	if t.ParentID != nil {
+		verr.Add("parent_id", msg)
+	}
+	if len(t.Checklist) > 0 {
+		verr.Add("checklist", msg)
+	}
+	if len(t.BlockedBy) > 0 {
+		verr.Add("blocked_by", msg)
+	}
+	if t.ProjectID != nil {
+		verr.Add("project_id", msg)
+	}
+	if len(t.Tags) > 0 {
+		verr.Add("tags", msg)
+	}
+	if len(t.Assignees) > 0 {
+		verr.Add("assignees", msg)
+	}
+	return verr.ErrOrNil()
+}
@@ -0,0 +1,86 @@
+package Domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxCommentLength is the longest comment body, in characters.
+const MaxCommentLength = 5000
+
+// Comment is a message in the discussion thread of a task.
+type Comment struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	TaskID         primitive.ObjectID `json:"task_id" bson:"task_id"`
+	AuthorID       primitive.ObjectID `json:"author_id" bson:"author_id"`
+	AuthorUsername string             `json:"author_username" bson:"author_username"`
+	Body           string             `json:"body" bson:"body"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	// UpdatedAt is set once the comment has been edited.
+	UpdatedAt *time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// Validate validates the Comment data.
+func (c Comment) Validate() error {
+	verr := &ValidationError{}
+	if strings.TrimSpace(c.Body) == "" {
+		verr.Add("body", "cannot be empty")
+	}
+	if utf8.RuneCountInString(c.Body) > MaxCommentLength {
+		verr.Add("body", fmt.Sprintf("cannot exceed %d characters", MaxCommentLength))
+	}
+	return verr.ErrOrNil()
+}
+
+// CommentQuery selects a page of the comments on a task. Page sizes follow
+// the task listing limits.
+type CommentQuery struct {
+	TaskID string
+	Limit  int
+	Offset int
+}
+
+// Normalize fills in default values and validates the query.
+func (q *CommentQuery) Normalize() error {
+	verr := &ValidationError{}
+	if q.Limit < 0 || q.Limit > MaxTaskLimit {
+		verr.Add("limit", fmt.Sprintf("must be between 1 and %d", MaxTaskLimit))
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTaskLimit
+	}
+	if q.Offset < 0 {
+		verr.Add("offset", "cannot be negative")
+	}
+	return verr.ErrOrNil()
+}
+
+// CommentPage is a single page of comments, oldest first.
+type CommentPage struct {
+	Comments []Comment `json:"comments"`
+	Total    int64     `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
+
+// ErrCommentNotFound is returned when a comment does not exist on the given task.
+var ErrCommentNotFound = NewError(ErrNotFound, "comment not found")
+
+// CommentRepository stores the comments on tasks.
+type CommentRepository interface {
+	CreateComment(ctx context.Context, comment Comment) (Comment, error)
+	GetComment(ctx context.Context, id string) (Comment, error)
+	// ListComments returns the comments on query.TaskID, oldest first.
+	ListComments(ctx context.Context, query CommentQuery) (CommentPage, error)
+	// UpdateComment replaces the body and UpdatedAt of a comment.
+	UpdateComment(ctx context.Context, comment Comment) (Comment, error)
+	DeleteComment(ctx context.Context, id string) error
+	// DeleteTaskComments removes all comments on the given tasks and
+	// returns how many there were.
+	DeleteTaskComments(ctx context.Context, taskIDs ...primitive.ObjectID) (int64, error)
+}
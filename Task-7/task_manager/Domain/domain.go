@@ -5,12 +5,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// Status represents the status of a task.
+// Status represents the status of a task. The statuses in use and the
+// transitions between them are defined by a Workflow; the constants are
+// the statuses of DefaultWorkflow.
 type Status string
 
 const (
@@ -19,9 +25,14 @@ const (
 	NotDone   Status = "not-done"
 )
 
-//IsValid checks if Status value is valid
+// statusPattern is the syntax of status names: lower-case words joined by
+// hyphens.
+var statusPattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+//IsValid checks if Status value is a well-formed status name. Whether the
+//workflow has the status is checked by Workflow.
 func (s Status) IsValid() bool {
-	return s == Pending || s == Completed || s == NotDone
+	return len(s) <= 30 && statusPattern.MatchString(string(s))
 }
 
 // Task represents a task entity.
@@ -31,26 +42,190 @@ type Task struct {
 	Description string            `json:"description" bson:"description"`
 	DueDate     time.Time         `json:"due_date" bson:"due_date"`
 	Status      Status            `json:"status" bson:"status"`
+	OwnerID     primitive.ObjectID `json:"owner_id" bson:"owner_id"`
+	// Version starts at 1 and increases on every write.
+	Version int64 `json:"version" bson:"version"`
+	// DeletedAt is set while the task is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	// Recurrence is the recurrence rule of a recurring task, in the syntax
+	// of ParseRecurrence. Completing an occurrence generates the next one.
+	Recurrence string `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	// SeriesID is shared by all occurrences of a recurring task, which are
+	// numbered by Occurrence starting at 1.
+	SeriesID       *primitive.ObjectID `json:"series_id,omitempty" bson:"series_id,omitempty"`
+	Occurrence     int                 `json:"occurrence,omitempty" bson:"occurrence,omitempty"`
+	SeriesTemplate *SeriesTemplate     `json:"-" bson:"series_template,omitempty"`
+	// ParentID makes the task a subtask of another task.
+	ParentID  *primitive.ObjectID `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Checklist []ChecklistItem     `json:"checklist,omitempty" bson:"checklist,omitempty"`
+	// BlockedBy lists the tasks that must be completed before this one.
+	BlockedBy []primitive.ObjectID `json:"blocked_by,omitempty" bson:"blocked_by,omitempty"`
+	// ProjectID puts the task in a project, whose members can see it.
+	ProjectID *primitive.ObjectID `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	// Tags are free-form labels, normalized by NormalizeTags.
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	// Assignees are the users the task is assigned to, who can see and
+	// update it like its owner.
+	Assignees []primitive.ObjectID `json:"assignees,omitempty" bson:"assignees,omitempty"`
+	// StatusEnteredAt records when the task last entered each status. It
+	// is maintained by the server.
+	StatusEnteredAt map[Status]time.Time `json:"status_entered_at,omitempty" bson:"status_entered_at,omitempty"`
+}
+
+// VisibleTo reports whether t is visible to the user userID who is a member
+// of the given projects. Tasks are visible to their assignees; tasks in a
+// project are also visible to the project's members, other tasks to their
+// owner.
+func (t Task) VisibleTo(userID primitive.ObjectID, projects []primitive.ObjectID) bool {
+	if t.IsAssignedTo(userID) {
+		return true
+	}
+	if t.ProjectID == nil {
+		return t.OwnerID == userID
+	}
+	return slices.Contains(projects, *t.ProjectID)
 }
 
-// Validate validates the Task data.
+// Validate validates the Task data of a new or fully replaced task.
 func (t Task) Validate() error {
+	verr := &ValidationError{}
+	t.validateInvariants(verr)
+	validateDueDate(verr, t.DueDate)
+	return verr.ErrOrNil()
+}
+
+// ValidateInvariants checks the rules every stored task must satisfy at any
+// time. Unlike Validate it accepts due dates in the past, so that existing
+// overdue tasks can still be changed.
+func (t Task) ValidateInvariants() error {
+	verr := &ValidationError{}
+	t.validateInvariants(verr)
+	return verr.ErrOrNil()
+}
+
+func (t Task) validateInvariants(verr *ValidationError) {
 	if t.Title == "" {
-		return errors.New("title cannot be empty")
+		verr.Add("title", "cannot be empty")
 	}
 	if len(t.Title) > 100 {
-		return errors.New("title cannot exceed 100 characters")
+		verr.Add("title", "cannot exceed 100 characters")
 	}
 	if len(t.Description) > 1000 {
-		return errors.New("description cannot exceed 1000 characters")
+		verr.Add("description", "cannot exceed 1000 characters")
 	}
-	if t.DueDate.Before(time.Now()) {
-		return errors.New("due date cannot be in the past")
+	if t.DueDate.IsZero() {
+		verr.Add("due_date", "is required")
 	}
 	if !t.Status.IsValid() {
-		return fmt.Errorf("invalid status: %s", t.Status)
+		verr.Add("status", fmt.Sprintf("invalid status: %s", t.Status))
+	}
+	if t.Recurrence != "" {
+		if _, err := ParseRecurrence(t.Recurrence); err != nil {
+			verr.Add("recurrence", err.Error())
+		}
+	}
+	t.validateRelations(verr)
+	validateTags(verr, t.Tags)
+	validateAssignees(verr, t.Assignees)
+}
+
+// validateDueDate checks that a newly set due date is not in the past.
+func validateDueDate(verr *ValidationError, dueDate time.Time) {
+	if !dueDate.IsZero() && dueDate.Before(time.Now()) {
+		verr.Add("due_date", "cannot be in the past")
 	}
-	return nil
+}
+
+// TaskSortField is a task field that task listings can be sorted by.
+type TaskSortField string
+
+const (
+	SortByDueDate TaskSortField = "due_date"
+	SortByTitle   TaskSortField = "title"
+	SortByStatus  TaskSortField = "status"
+)
+
+// IsValid checks if a TaskSortField value is valid
+func (f TaskSortField) IsValid() bool {
+	return f == SortByDueDate || f == SortByTitle || f == SortByStatus
+}
+
+const (
+	// DefaultTaskLimit is the page size used when a TaskQuery has no limit.
+	DefaultTaskLimit = 20
+	// MaxTaskLimit is the largest page size a TaskQuery may request.
+	MaxTaskLimit = 100
+)
+
+// TaskQuery describes how a task listing is filtered, sorted and paginated.
+// Zero values mean "no filter"; an empty SortBy keeps insertion order.
+type TaskQuery struct {
+	// VisibleTo restricts the listing to tasks visible to the given user ID,
+	// who is a member of VisibleProjects; see Task.VisibleTo.
+	VisibleTo       string
+	VisibleProjects []primitive.ObjectID
+	// Deleted lists tasks in the trash instead of live tasks.
+	Deleted   bool
+	// Text is a full-text search in the syntax of TextSearch. It is only
+	// used by TaskRepository.SearchTasks.
+	Text      string
+	// SeriesID restricts the listing to the occurrences of a recurring task.
+	SeriesID  string
+	// ParentID restricts the listing to the subtasks of a task.
+	ParentID  string
+	// ProjectID restricts the listing to the tasks of a project.
+	ProjectID string
+	// Tag restricts the listing to the tasks carrying a tag.
+	Tag       string
+	// AssigneeID restricts the listing to the tasks assigned to a user.
+	AssigneeID string
+	Status    Status
+	// ExcludeStatuses leaves out the tasks with any of the given statuses.
+	ExcludeStatuses []Status
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	SortBy    TaskSortField
+	SortDesc  bool
+	Limit     int
+	Offset    int
+}
+
+// Normalize fills in default values and validates the query.
+func (q *TaskQuery) Normalize() error {
+	verr := &ValidationError{}
+	q.Tag = strings.ToLower(strings.TrimSpace(q.Tag))
+	if q.Status != "" && !q.Status.IsValid() {
+		verr.Add("status", fmt.Sprintf("invalid status: %s", q.Status))
+	}
+	for _, status := range q.ExcludeStatuses {
+		if !status.IsValid() {
+			verr.Add("exclude_statuses", fmt.Sprintf("invalid status: %s", status))
+		}
+	}
+	if q.SortBy != "" && !q.SortBy.IsValid() {
+		verr.Add("sort", fmt.Sprintf("invalid sort field: %s", q.SortBy))
+	}
+	if q.DueBefore != nil && q.DueAfter != nil && !q.DueAfter.Before(*q.DueBefore) {
+		verr.Add("due_after", "must be before due_before")
+	}
+	if q.Limit < 0 || q.Limit > MaxTaskLimit {
+		verr.Add("limit", fmt.Sprintf("must be between 1 and %d", MaxTaskLimit))
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTaskLimit
+	}
+	if q.Offset < 0 {
+		verr.Add("offset", "cannot be negative")
+	}
+	return verr.ErrOrNil()
+}
+
+// TaskPage is a single page of a task listing.
+type TaskPage struct {
+	Tasks  []Task `json:"tasks"`
+	Total  int64  `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
 }
 
 // UserRole represents a user's role.
@@ -66,45 +241,138 @@ func (r UserRole) IsValid() bool {
 	return r == RoleAdmin || r == RoleUser
 }
 
+// Actor is the authenticated user on whose behalf an operation runs.
+type Actor struct {
+	UserID   string
+	Username string
+	Role     UserRole
+}
+
+type actorContextKey struct{}
+
+// ContextWithActor returns a copy of ctx that carries the given actor.
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, if any.
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(Actor)
+	return actor, ok && actor.UserID != ""
+}
+
 // User represents a user entity.
 type User struct {
 	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Username string            `json:"username" bson:"username"`
-	Password string            `json:"password" bson:"password"`
+	Password string            `json:"password,omitempty" bson:"password"`
 	Role     UserRole          `json:"role" bson:"role"`
+	// Disabled accounts cannot log in or use their tokens.
+	Disabled bool `json:"disabled" bson:"disabled,omitempty"`
+	// Email is where password reset tokens are sent.
+	Email string `json:"email,omitempty" bson:"email,omitempty"`
+	// PasswordChangedAt is when the password was last changed or reset.
+	// Tokens issued before it are no longer accepted.
+	PasswordChangedAt *time.Time `json:"-" bson:"password_changed_at,omitempty"`
 }
 
-// Validate validates the User data.
-func (u User) Validate() error {
+// Validate validates the User data, checking the password with passwords.
+func (u User) Validate(passwords PasswordChecker) error {
+	verr := &ValidationError{}
 	if u.Username == "" {
-		return errors.New("username cannot be empty")
+		verr.Add("username", "cannot be empty")
 	}
 	if len(u.Username) > 50 {
-		return errors.New("username cannot exceed 50 characters")
+		verr.Add("username", "cannot exceed 50 characters")
 	}
-	if u.Password == "" {
-		return errors.New("password cannot be empty")
+	var perr *ValidationError
+	if err := passwords.CheckPassword(u.Username, u.Password); errors.As(err, &perr) {
+		verr.Fields = append(verr.Fields, perr.Fields...)
+	} else if err != nil {
+		return err
 	}
-	if len(u.Password) < 8 {
-		return errors.New("password must be at least 8 characters")
+	if u.Email != "" {
+		if addr, err := mail.ParseAddress(u.Email); err != nil || addr.Address != u.Email {
+			verr.Add("email", "must be a plain email address")
+		}
 	}
 	if !u.Role.IsValid() {
-		return fmt.Errorf("invalid role: %s", u.Role)
+		verr.Add("role", fmt.Sprintf("invalid role: %s", u.Role))
 	}
-	return nil
+	return verr.ErrOrNil()
+}
+
+// AcceptsTokenIssuedAt reports whether a token issued at issuedAt is still
+// valid for the user, i.e. whether it was issued after the last password
+// change.
+func (u User) AcceptsTokenIssuedAt(issuedAt time.Time) bool {
+	return u.PasswordChangedAt == nil || !issuedAt.Before(u.PasswordChangedAt.Truncate(time.Second))
 }
 
 // TaskRepository defines task data access methods.
+// Deleting a task only moves it to the trash; unless stated otherwise,
+// methods ignore trashed tasks.
 type TaskRepository interface {
+	// CreateTask fails with ErrOccurrenceExists if task is an occurrence of
+	// a series that already has a task, live or trashed, with that number.
 	CreateTask(ctx context.Context, task Task) (Task, error)
 	GetTaskByID(ctx context.Context, id string) (Task, error)
-	GetAllTasks(ctx context.Context) ([]Task, error)
-	UpdateTask(ctx context.Context, id string, task Task) (Task, error)
-	DeleteTask(ctx context.Context, id string) error
+	GetAllTasks(ctx context.Context, query TaskQuery) (TaskPage, error)
+	// SearchTasks returns the tasks matching the full-text search in
+	// query.Text and the other filters of query, most relevant first. The
+	// SortBy and SortDesc fields are ignored.
+	SearchTasks(ctx context.Context, query TaskQuery) (TaskSearchPage, error)
+	// ListTags returns the tags of the live tasks matching query, most
+	// used first.
+	ListTags(ctx context.Context, query TagQuery) ([]TagCount, error)
+	// UpdateTask, PatchTask and DeleteTask only write while the stored
+	// version equals version and fail with ErrVersionMismatch otherwise.
+	// A zero version writes unconditionally. UpdateTask also replaces the
+	// recurrence and series fields.
+	UpdateTask(ctx context.Context, id string, task Task, version int64) (Task, error)
+	// PatchTask sets only the fields present in patch.
+	PatchTask(ctx context.Context, id string, patch TaskPatch, version int64) (Task, error)
+	DeleteTask(ctx context.Context, id string, version int64) error
+	// WriteTasks applies the operations of batch in order and reports the
+	// outcome of each one. Deletes move tasks to the trash. The error is
+	// only set when the batch as a whole could not be processed.
+	WriteTasks(ctx context.Context, batch TaskBatch) ([]BulkResult, error)
+	// GetTrashedTaskByID returns a task that is in the trash.
+	GetTrashedTaskByID(ctx context.Context, id string) (Task, error)
+	// RestoreTask moves a task out of the trash.
+	RestoreTask(ctx context.Context, id string) (Task, error)
+	// PurgeTask permanently removes a task that is in the trash.
+	PurgeTask(ctx context.Context, id string) error
+	// PurgeDeletedBefore permanently removes every task trashed before cutoff
+	// and returns the IDs of the removed tasks.
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]primitive.ObjectID, error)
 }
 
 // UserRepository defines user data access methods.
 type UserRepository interface{
 	CreateUser(ctx context.Context, user User) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
-}
\ No newline at end of file
+	// GetUserByID fails with ErrUserNotFound if no user has the given ID.
+	GetUserByID(ctx context.Context, id string) (User, error)
+	// ListUsers returns a page of users sorted by username, without their
+	// password hashes.
+	ListUsers(ctx context.Context, query UserQuery) (UserPage, error)
+	// UpdateUser replaces the role and disabled flag of a user and returns
+	// it without its password hash.
+	UpdateUser(ctx context.Context, user User) (User, error)
+	// SetPassword replaces the password hash of a user and records
+	// changedAt as its PasswordChangedAt.
+	SetPassword(ctx context.Context, id string, hash string, changedAt time.Time) error
+	DeleteUser(ctx context.Context, id string) error
+}
+
+// RevocationStore records revoked token IDs (the "jti" claim) and token
+// family IDs. Entries only need to be kept until the tokens they refer to
+// expire.
+type RevocationStore interface {
+	// Revoke marks id as revoked until expiresAt. It reports whether id was
+	// newly revoked, i.e. false means it had already been revoked before.
+	Revoke(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+	// IsRevoked reports whether any of the given ids is revoked.
+	IsRevoked(ctx context.Context, ids ...string) (bool, error)
+}
@@ -0,0 +1,123 @@
+package Domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Error kinds. Every error returned by the repositories and use cases wraps
+// one of these, so callers can classify failures with errors.Is regardless
+// of the concrete message.
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+	// ErrPreconditionFailed is returned when a conditional write's precondition does not hold.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrValidation         = errors.New("validation failed")
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	// ErrTooManyRequests is returned when a client exceeds a rate limit.
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrInternal        = errors.New("internal error")
+)
+
+var (
+	// ErrTaskNotFound is returned when a task does not exist or is not visible to the caller.
+	ErrTaskNotFound = NewError(ErrNotFound, "task not found")
+	// ErrUserNotFound is returned when a user does not exist.
+	ErrUserNotFound = NewError(ErrNotFound, "user not found")
+	// ErrUsernameTaken is returned when registering a username that is already in use.
+	ErrUsernameTaken = NewError(ErrConflict, "username already taken")
+	// ErrVersionMismatch is returned when a task changed since the version the caller expected.
+	ErrVersionMismatch = NewError(ErrPreconditionFailed, "task has been modified; version does not match")
+	// ErrNoActor is returned when an operation requires an authenticated user but none is known.
+	ErrNoActor = NewError(ErrUnauthorized, "authenticated user required")
+	// ErrTokenRevoked is returned when a token or its token family has been revoked.
+	ErrTokenRevoked = NewError(ErrUnauthorized, "token has been revoked")
+)
+
+// kindError is an error with its own message that matches an error kind.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+func (e *kindError) Unwrap() error { return e.kind }
+
+// NewError returns an error with the given message that matches kind
+// (one of ErrNotFound, ErrConflict, ...) with errors.Is.
+func NewError(kind error, msg string) error {
+	return &kindError{kind: kind, msg: msg}
+}
+
+// Internal wraps an unexpected failure, such as a database error, as ErrInternal.
+// Its message is meant for logs and must not be shown to clients.
+func Internal(msg string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrInternal, msg, err)
+}
+
+// RetryAfterError is an ErrTooManyRequests error that tells the client how
+// long to wait before trying again.
+type RetryAfterError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+// NewRetryAfterError returns a RetryAfterError with the given message.
+func NewRetryAfterError(msg string, retryAfter time.Duration) *RetryAfterError {
+	return &RetryAfterError{Message: msg, RetryAfter: retryAfter}
+}
+
+func (e *RetryAfterError) Error() string { return e.Message }
+
+// Is makes a RetryAfterError match ErrTooManyRequests.
+func (e *RetryAfterError) Is(target error) bool {
+	return target == ErrTooManyRequests
+}
+
+// FieldError describes why a single field failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field that failed validation. It matches
+// ErrValidation with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError returns a ValidationError for a single field.
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add records a failed field.
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// ErrOrNil returns e if any field failed and nil otherwise.
+func (e *ValidationError) ErrOrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Is makes a ValidationError match ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
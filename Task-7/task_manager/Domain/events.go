@@ -0,0 +1,47 @@
+package Domain
+
+import (
+	"context"
+	"time"
+)
+
+// TaskEventType is the kind of change announced by a TaskEvent.
+type TaskEventType string
+
+const (
+	TaskCreated TaskEventType = "task.created"
+	TaskUpdated TaskEventType = "task.updated"
+	// TaskCompleted is published in addition to TaskUpdated when an update
+	// sets the status of a task to Completed.
+	TaskCompleted TaskEventType = "task.completed"
+	TaskDeleted   TaskEventType = "task.deleted"
+	TaskRestored  TaskEventType = "task.restored"
+	// TaskEventsReset tells a subscriber that events it asked to resume from
+	// are no longer available, so it has to reload the tasks it shows.
+	TaskEventsReset TaskEventType = "reset"
+)
+
+// TaskEvent announces a change to a task.
+type TaskEvent struct {
+	// ID is assigned by the broker and increases with every published event.
+	ID   uint64        `json:"id"`
+	Type TaskEventType `json:"type"`
+	// Task is the task after the change. It is nil for reset events.
+	Task *Task     `json:"task,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// TaskEventBroker distributes task events to subscribers.
+type TaskEventBroker interface {
+	// Publish assigns the event an ID and delivers it to every subscriber
+	// whose filter accepts it. It never blocks on slow subscribers or on
+	// their filters.
+	Publish(ctx context.Context, event TaskEvent) error
+	// Subscribe returns a channel of the events accepted by filter. When
+	// afterID is not zero, buffered events published after it are replayed
+	// first, or a TaskEventsReset event is sent if they are no longer
+	// available. The channel is closed once ctx is done, or early when the
+	// subscriber falls too far behind; it can then resubscribe from the ID
+	// of the last event it received.
+	Subscribe(ctx context.Context, afterID uint64, filter func(TaskEvent) bool) (<-chan TaskEvent, error)
+}
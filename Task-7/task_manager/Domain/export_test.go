@@ -0,0 +1,4 @@
+package Domain
+
+// NextMonthDay exports nextMonthDay for tests.
+var NextMonthDay = nextMonthDay
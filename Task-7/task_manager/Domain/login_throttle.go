@@ -0,0 +1,95 @@
+package Domain
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttempts are the recent failed logins recorded for a key, such as a
+// username or a client IP.
+type LoginAttempts struct {
+	Key         string    `json:"-" bson:"_id"`
+	Failures    int       `json:"failures" bson:"failures"`
+	LastFailure time.Time `json:"last_failure" bson:"last_failure"`
+	// ExpiresAt is when the failures are forgotten.
+	ExpiresAt time.Time `json:"-" bson:"expires_at"`
+}
+
+// LoginAttemptStore records failed logins. A login attempt is recorded as
+// a failure before the password is checked, so that concurrent attempts
+// cannot all pass the throttle, and taken back if it succeeds.
+type LoginAttemptStore interface {
+	// GetAttempts returns the failures recorded for key that have not
+	// expired at now. Keys without any have zero LoginAttempts.
+	GetAttempts(ctx context.Context, key string, now time.Time) (LoginAttempts, error)
+	// ReserveAttempt adds a failure at now to key and keeps the record
+	// until expiresAt, but only if the record is still seen, as returned
+	// by GetAttempts at now. It reports whether it did; if not, another
+	// attempt has changed the record in the meantime. The count starts
+	// again at one if the earlier record had expired.
+	ReserveAttempt(ctx context.Context, key string, seen LoginAttempts, now, expiresAt time.Time) (bool, error)
+	// ReleaseAttempt takes back a failure added by ReserveAttempt for an
+	// attempt that succeeded.
+	ReleaseAttempt(ctx context.Context, key string) error
+	// ResetAttempts forgets the failures of key.
+	ResetAttempts(ctx context.Context, key string) error
+}
+
+// LoginThrottle decides how long a key has to wait after failed logins.
+// After FreeAttempts failures, each further attempt has to wait BaseDelay,
+// doubling with every failure up to MaxDelay. After LockoutThreshold
+// failures, the key is locked for LockoutDuration. Failures are forgotten
+// LockoutDuration after the last one.
+type LoginThrottle struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// DefaultUsernameThrottle returns the throttle applied to a username.
+func DefaultUsernameThrottle() LoginThrottle {
+	return LoginThrottle{FreeAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute, LockoutThreshold: 10, LockoutDuration: 15 * time.Minute}
+}
+
+// DefaultIPThrottle returns the throttle applied to a client IP. It is
+// more lenient than DefaultUsernameThrottle, as many users may share an
+// IP.
+func DefaultIPThrottle() LoginThrottle {
+	return LoginThrottle{FreeAttempts: 10, BaseDelay: time.Second, MaxDelay: time.Minute, LockoutThreshold: 50, LockoutDuration: 15 * time.Minute}
+}
+
+// Validate checks that the throttle's settings are usable.
+func (t LoginThrottle) Validate() error {
+	verr := &ValidationError{}
+	if t.FreeAttempts < 0 {
+		verr.Add("free_attempts", "cannot be negative")
+	}
+	if t.BaseDelay <= 0 || t.MaxDelay < t.BaseDelay {
+		verr.Add("delay", "base delay must be positive and max delay at least the base delay")
+	}
+	if t.LockoutThreshold <= t.FreeAttempts {
+		verr.Add("lockout_threshold", "must exceed the free attempts")
+	}
+	if t.LockoutDuration < t.MaxDelay {
+		verr.Add("lockout_duration", "must be at least the max delay")
+	}
+	return verr.ErrOrNil()
+}
+
+// RetryAt returns when the next login for a key with the given failures
+// may be attempted. It is zero if it may be attempted right away.
+func (t LoginThrottle) RetryAt(attempts LoginAttempts) time.Time {
+	switch {
+	case attempts.Failures >= t.LockoutThreshold:
+		return attempts.LastFailure.Add(t.LockoutDuration)
+	case attempts.Failures < t.FreeAttempts || attempts.Failures == 0:
+		return time.Time{}
+	}
+	delay := t.BaseDelay
+	for i := t.FreeAttempts; i < attempts.Failures && delay < t.MaxDelay; i++ {
+		delay *= 2
+	}
+	return attempts.LastFailure.Add(min(delay, t.MaxDelay))
+}
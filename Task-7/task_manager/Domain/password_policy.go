@@ -0,0 +1,117 @@
+package Domain
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxPasswordBytes is the longest password that can be hashed; bcrypt
+// ignores everything after it.
+const MaxPasswordBytes = 72
+
+// PasswordChecker decides whether a password may be used. Failures are
+// ValidationErrors on the field "password".
+type PasswordChecker interface {
+	CheckPassword(username, password string) error
+}
+
+// PasswordPolicy is the PasswordChecker configured for the server.
+type PasswordPolicy struct {
+	// MinLength is the minimum number of characters.
+	MinLength int
+	// MinClasses is how many of the character classes lower case letters,
+	// upper case letters, digits and symbols a password must contain.
+	MinClasses int
+	// Blocklist holds common and breached passwords, in lower case, that
+	// may not be used whatever their case.
+	Blocklist map[string]bool
+}
+
+// DefaultPasswordPolicy returns the policy of passwords with at least 8
+// characters from at least two character classes.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: 8, MinClasses: 2}
+}
+
+// ParsePasswordList decodes a list of passwords with one password per
+// line, such as a list of common or breached passwords, for use as a
+// Blocklist. Empty lines are ignored.
+func ParsePasswordList(data []byte) map[string]bool {
+	list := make(map[string]bool)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		password := strings.TrimSuffix(string(line), "\r")
+		if password != "" {
+			list[strings.ToLower(password)] = true
+		}
+	}
+	return list
+}
+
+// Validate checks that the policy's settings are usable.
+func (p PasswordPolicy) Validate() error {
+	verr := &ValidationError{}
+	if p.MinLength < 1 || p.MinLength > MaxPasswordBytes {
+		verr.Add("min_length", fmt.Sprintf("must be between 1 and %d", MaxPasswordBytes))
+	}
+	if p.MinClasses < 0 || p.MinClasses > 4 {
+		verr.Add("min_classes", "must be between 0 and 4")
+	}
+	return verr.ErrOrNil()
+}
+
+// CheckPassword implements PasswordChecker. It reports every rule the
+// password breaks.
+func (p PasswordPolicy) CheckPassword(username, password string) error {
+	verr := &ValidationError{}
+	if password == "" {
+		verr.Add("password", "cannot be empty")
+		return verr
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		verr.Add("password", fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if len(password) > MaxPasswordBytes {
+		verr.Add("password", fmt.Sprintf("cannot exceed %d bytes", MaxPasswordBytes))
+	}
+	if passwordClasses(password) < p.MinClasses {
+		verr.Add("password", fmt.Sprintf("must contain at least %d of: lower case letters, upper case letters, digits, symbols", p.MinClasses))
+	}
+	if containsUsername(password, username) {
+		verr.Add("password", "cannot contain the username")
+	}
+	if p.Blocklist[strings.ToLower(password)] {
+		verr.Add("password", "is too common or has appeared in a data breach")
+	}
+	return verr.ErrOrNil()
+}
+
+// containsUsername reports whether password contains username, ignoring
+// case. Usernames shorter than three characters only count if they are
+// the whole password, so that they do not rule out most passwords.
+func containsUsername(password, username string) bool {
+	if utf8.RuneCountInString(username) < 3 {
+		return username != "" && strings.EqualFold(password, username)
+	}
+	return strings.Contains(strings.ToLower(password), strings.ToLower(username))
+}
+
+// passwordClasses counts the character classes password contains.
+func passwordClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
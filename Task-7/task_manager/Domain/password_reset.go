@@ -0,0 +1,55 @@
+package Domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordReset is a pending password reset. Only a hash of its token is
+// stored; the token itself is mailed to the user and can be used once.
+type PasswordReset struct {
+	UserID    primitive.ObjectID `bson:"user_id"`
+	TokenHash string             `bson:"token_hash"`
+	CreatedAt time.Time          `bson:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+}
+
+// HashResetToken returns the hash under which a reset token is stored.
+// Reset tokens are long random strings, so a fast hash is enough.
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ErrResetTokenNotFound is returned when a reset token does not exist, has
+// already been used or has expired.
+var ErrResetTokenNotFound = NewError(ErrNotFound, "reset token not found")
+
+// PasswordResetRepository stores pending password resets. A user has at
+// most one pending reset.
+type PasswordResetRepository interface {
+	// CreateReset stores reset, replacing the user's earlier reset if it was
+	// created at or before notBefore. If the earlier reset is newer, it
+	// stores nothing and reports false.
+	CreateReset(ctx context.Context, reset PasswordReset, notBefore time.Time) (bool, error)
+	// ConsumeReset removes and returns the reset with the given token hash.
+	// It fails with ErrResetTokenNotFound if there is none or it expired
+	// before now.
+	ConsumeReset(ctx context.Context, tokenHash string, now time.Time) (PasswordReset, error)
+}
+
+// Mail is a plain-text email.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// MailSender delivers emails to users.
+type MailSender interface {
+	Send(ctx context.Context, mail Mail) error
+}
@@ -0,0 +1,130 @@
+package Domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Permission names an action an actor may be allowed to take.
+type Permission string
+
+const (
+	// PermTaskRead allows reading the tasks an actor owns, is assigned to
+	// or can see through a project, and their comments and history.
+	PermTaskRead Permission = "task:read"
+	// PermTaskReadAny allows reading every task.
+	PermTaskReadAny   Permission = "task:read:any"
+	PermTaskCreate    Permission = "task:create"
+	PermTaskUpdate    Permission = "task:update"
+	PermTaskUpdateAny Permission = "task:update:any"
+	// PermTaskDelete allows moving a task the actor may update to the trash.
+	PermTaskDelete Permission = "task:delete"
+	// PermTaskPurge allows permanently deleting any trashed task.
+	PermTaskPurge Permission = "task:purge"
+	// PermCommentWrite allows commenting on the tasks an actor can see and
+	// editing their own comments.
+	PermCommentWrite Permission = "comment:write"
+	// PermCommentModerate allows editing and deleting other users'
+	// comments.
+	PermCommentModerate Permission = "comment:moderate"
+	PermProjectRead     Permission = "project:read"
+	// PermProjectWrite allows creating projects and changing the projects
+	// an actor owns.
+	PermProjectWrite Permission = "project:write"
+	// PermProjectManageAny allows seeing and changing every project.
+	PermProjectManageAny Permission = "project:manage:any"
+	PermAuditRead        Permission = "audit:read"
+	PermWebhookManage    Permission = "webhook:manage"
+	// PermUserManage allows listing users, changing their roles, disabling,
+	// unlocking and deleting them.
+	PermUserManage Permission = "user:manage"
+)
+
+// Permissions lists every permission in the order they are documented.
+var Permissions = []Permission{
+	PermTaskRead, PermTaskReadAny, PermTaskCreate, PermTaskUpdate, PermTaskUpdateAny,
+	PermTaskDelete, PermTaskPurge, PermCommentWrite, PermCommentModerate,
+	PermProjectRead, PermProjectWrite, PermProjectManageAny, PermAuditRead, PermWebhookManage,
+	PermUserManage,
+}
+
+// IsValid checks if a Permission value is valid.
+func (p Permission) IsValid() bool {
+	return slices.Contains(Permissions, p)
+}
+
+// Authorizer decides whether an actor holds a permission. It is consulted
+// by both the HTTP middleware and the use cases, so the same rules apply
+// whichever way an operation is invoked.
+type Authorizer interface {
+	Can(actor Actor, permission Permission) bool
+}
+
+// Authorize fails with ErrForbidden unless actor holds every one of
+// permissions.
+func Authorize(authz Authorizer, actor Actor, permissions ...Permission) error {
+	for _, permission := range permissions {
+		if !authz.Can(actor, permission) {
+			return NewError(ErrForbidden, fmt.Sprintf("permission %s required", permission))
+		}
+	}
+	return nil
+}
+
+// Policy maps each role to the permissions it grants. It is loaded from
+// configuration; DefaultPolicy is used when none is configured. Roles the
+// policy does not list have no permissions.
+type Policy struct {
+	Roles map[UserRole][]Permission `json:"roles"`
+}
+
+// DefaultPolicy returns the policy in which users work with their own and
+// shared tasks and projects, and admins may do everything.
+func DefaultPolicy() Policy {
+	return Policy{Roles: map[UserRole][]Permission{
+		RoleUser: {
+			PermTaskRead, PermTaskCreate, PermTaskUpdate, PermCommentWrite,
+			PermProjectRead, PermProjectWrite,
+		},
+		RoleAdmin: slices.Clone(Permissions),
+	}}
+}
+
+// ParsePolicy decodes and validates a JSON policy definition.
+func ParsePolicy(data []byte) (Policy, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var p Policy
+	if err := decoder.Decode(&p); err != nil {
+		return Policy{}, fmt.Errorf("invalid policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// Validate checks that the policy only names known roles and permissions.
+func (p Policy) Validate() error {
+	verr := &ValidationError{}
+	for _, role := range slices.Sorted(maps.Keys(p.Roles)) {
+		permissions := p.Roles[role]
+		if !role.IsValid() {
+			verr.Add("roles", fmt.Sprintf("invalid role: %s", role))
+		}
+		for _, permission := range permissions {
+			if !permission.IsValid() {
+				verr.Add("roles", fmt.Sprintf("invalid permission for %s: %q", role, permission))
+			}
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+// Can implements Authorizer.
+func (p Policy) Can(actor Actor, permission Permission) bool {
+	return slices.Contains(p.Roles[actor.Role], permission)
+}
@@ -0,0 +1,119 @@
+package Domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxProjectMembers is the largest number of members a project can have,
+// not counting its owner.
+const MaxProjectMembers = 100
+
+// Project groups tasks. The tasks of a project are visible to its owner and
+// members instead of only to the users who created them.
+type Project struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id"`
+	Name        string               `json:"name" bson:"name"`
+	Description string               `json:"description" bson:"description"`
+	OwnerID     primitive.ObjectID   `json:"owner_id" bson:"owner_id"`
+	Members     []primitive.ObjectID `json:"members" bson:"members"`
+	// Archived projects keep their tasks, but no tasks can be added to them.
+	Archived  bool      `json:"archived" bson:"archived"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate validates the Project data.
+func (p Project) Validate() error {
+	verr := &ValidationError{}
+	if strings.TrimSpace(p.Name) == "" {
+		verr.Add("name", "cannot be empty")
+	}
+	if len(p.Name) > 100 {
+		verr.Add("name", "cannot exceed 100 characters")
+	}
+	if len(p.Description) > 1000 {
+		verr.Add("description", "cannot exceed 1000 characters")
+	}
+	if len(p.Members) > MaxProjectMembers {
+		verr.Add("members", fmt.Sprintf("cannot have more than %d members", MaxProjectMembers))
+	}
+	for i, id := range p.Members {
+		switch {
+		case id.IsZero():
+			verr.Add("members", "must list user IDs")
+		case slices.Contains(p.Members[:i], id):
+			verr.Add("members", fmt.Sprintf("lists user %s twice", id.Hex()))
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+// HasMember reports whether the user with the given ID is the owner or a
+// member of p.
+func (p Project) HasMember(userID string) bool {
+	return p.OwnerID.Hex() == userID || slices.ContainsFunc(p.Members, func(id primitive.ObjectID) bool {
+		return id.Hex() == userID
+	})
+}
+
+// ProjectQuery selects a page of projects, sorted by name. Page sizes follow
+// the task listing limits.
+type ProjectQuery struct {
+	// MemberID restricts the listing to the projects the given user ID owns
+	// or is a member of.
+	MemberID string
+	// Archived, if set, only lists projects that are or are not archived.
+	Archived *bool
+	Limit    int
+	Offset   int
+}
+
+// Normalize fills in default values and validates the query.
+func (q *ProjectQuery) Normalize() error {
+	verr := &ValidationError{}
+	if q.Limit < 0 || q.Limit > MaxTaskLimit {
+		verr.Add("limit", fmt.Sprintf("must be between 1 and %d", MaxTaskLimit))
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTaskLimit
+	}
+	if q.Offset < 0 {
+		verr.Add("offset", "cannot be negative")
+	}
+	return verr.ErrOrNil()
+}
+
+// ProjectPage is a single page of a project listing.
+type ProjectPage struct {
+	Projects []Project `json:"projects"`
+	Total    int64     `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
+
+var (
+	// ErrProjectNotFound is returned when a project does not exist or is not visible to the caller.
+	ErrProjectNotFound = NewError(ErrNotFound, "project not found")
+	// ErrProjectNotEmpty is returned when deleting a project that still has tasks, live or trashed.
+	ErrProjectNotEmpty = NewError(ErrConflict, "project still has tasks")
+)
+
+// ProjectRepository stores projects.
+type ProjectRepository interface {
+	CreateProject(ctx context.Context, project Project) (Project, error)
+	GetProject(ctx context.Context, id string) (Project, error)
+	ListProjects(ctx context.Context, query ProjectQuery) (ProjectPage, error)
+	// UpdateProject replaces the name, description, members, archived flag
+	// and UpdatedAt of a project.
+	UpdateProject(ctx context.Context, project Project) (Project, error)
+	DeleteProject(ctx context.Context, id string) error
+	// MemberProjectIDs returns the IDs of all projects, archived or not,
+	// that the given user ID owns or is a member of.
+	MemberProjectIDs(ctx context.Context, userID string) ([]primitive.ObjectID, error)
+}
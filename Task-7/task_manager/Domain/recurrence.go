@@ -0,0 +1,383 @@
+package Domain
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Frequency is how often a recurring task repeats.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// IsValid checks if a Frequency value is valid
+func (f Frequency) IsValid() bool {
+	return f == Daily || f == Weekly || f == Monthly
+}
+
+// Recurrence is a parsed recurrence rule. It supports the following subset
+// of RFC 5545 RRULE parts: FREQ (DAILY, WEEKLY or MONTHLY), INTERVAL, BYDAY
+// for weekly rules (plain weekdays such as MO,WE), BYMONTHDAY for monthly
+// rules (1 to 31, or -1 to -31 counting from the end of the month), and
+// either UNTIL or COUNT. Weeks start on Monday.
+type Recurrence struct {
+	Frequency  Frequency
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Until      *time.Time
+	Count      int
+}
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ParseRecurrence parses a recurrence rule such as
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10". An optional "RRULE:" prefix
+// is ignored.
+func ParseRecurrence(rule string) (Recurrence, error) {
+	rule = strings.TrimSpace(rule)
+	if len(rule) >= 6 && strings.EqualFold(rule[:6], "RRULE:") {
+		rule = rule[6:]
+	}
+
+	r := Recurrence{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || name == "" || value == "" {
+			return Recurrence{}, fmt.Errorf("invalid rule part %q", part)
+		}
+		if seen[name] {
+			return Recurrence{}, fmt.Errorf("%s is given more than once", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Frequency = Frequency(value)
+			if !r.Frequency.IsValid() {
+				err = fmt.Errorf("unsupported FREQ %s; must be DAILY, WEEKLY or MONTHLY", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(name, value)
+		case "COUNT":
+			r.Count, err = parsePositive(name, value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day := slices.Index(weekdayCodes, code)
+				if day < 0 {
+					return Recurrence{}, fmt.Errorf("invalid BYDAY value %q; must be a list of weekdays such as MO,WE", code)
+				}
+				r.ByDay = append(r.ByDay, time.Weekday(day))
+			}
+		case "BYMONTHDAY":
+			for _, s := range strings.Split(value, ",") {
+				day, convErr := strconv.Atoi(s)
+				if convErr != nil || day == 0 || day < -31 || day > 31 {
+					return Recurrence{}, fmt.Errorf("invalid BYMONTHDAY value %q; must be between 1 and 31 or -31 and -1", s)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, day)
+			}
+		default:
+			err = fmt.Errorf("unsupported rule part %s", name)
+		}
+		if err != nil {
+			return Recurrence{}, err
+		}
+	}
+
+	switch {
+	case r.Frequency == "":
+		return Recurrence{}, fmt.Errorf("FREQ is required")
+	case r.Until != nil && r.Count != 0:
+		return Recurrence{}, fmt.Errorf("UNTIL and COUNT cannot both be given")
+	case len(r.ByDay) > 0 && r.Frequency != Weekly:
+		return Recurrence{}, fmt.Errorf("BYDAY is only supported for WEEKLY rules")
+	case len(r.ByMonthDay) > 0 && r.Frequency != Monthly:
+		return Recurrence{}, fmt.Errorf("BYMONTHDAY is only supported for MONTHLY rules")
+	}
+	slices.SortFunc(r.ByDay, func(a, b time.Weekday) int { return weekdayOffset(a) - weekdayOffset(b) })
+	r.ByDay = slices.Compact(r.ByDay)
+	return r, nil
+}
+
+func parsePositive(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// parseUntil parses an UNTIL date ("20060102"), which includes the whole
+// day, or a UTC date-time ("20060102T150405Z").
+func parseUntil(value string) (*time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return &until, nil
+	}
+	if day, err := time.Parse("20060102", value); err == nil {
+		until := day.Add(24*time.Hour - time.Second)
+		return &until, nil
+	}
+	return nil, fmt.Errorf("UNTIL must be a date (YYYYMMDD) or a UTC date-time (YYYYMMDDTHHMMSSZ)")
+}
+
+// String returns the rule in canonical form, e.g. "FREQ=WEEKLY;BYDAY=MO,TH".
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Frequency)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = weekdayCodes[day]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the rule after the occurrence at
+// current. The time of day of current is kept.
+func (r Recurrence) Next(current time.Time) time.Time {
+	interval := max(r.Interval, 1)
+	switch r.Frequency {
+	case Weekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{current.Weekday()}
+		}
+		offset := weekdayOffset(current.Weekday())
+		for _, day := range days {
+			if weekdayOffset(day) > offset {
+				return current.AddDate(0, 0, weekdayOffset(day)-offset)
+			}
+		}
+		weekStart := current.AddDate(0, 0, -offset)
+		return weekStart.AddDate(0, 0, 7*interval+weekdayOffset(days[0]))
+	case Monthly:
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{current.Day()}
+		}
+		year, month, _ := current.Date()
+		after := current.Day()
+		// A day such as the 31st does not occur in every month; such months
+		// are skipped. Every day occurs at least once in 7 years.
+		for i := 0; i < 84; i++ {
+			if next, ok := nextMonthDay(current, year, month, after, days); ok {
+				return next
+			}
+			month += time.Month(interval)
+			after = 0
+		}
+	}
+	return current.AddDate(0, 0, interval)
+}
+
+// nextMonthDay returns the earliest of days in the given month that comes
+// after the day after, at the time of day of current.
+func nextMonthDay(current time.Time, year int, month time.Month, after int, days []int) (time.Time, bool) {
+	first := time.Date(year, month, 1, current.Hour(), current.Minute(), current.Second(), current.Nanosecond(), current.Location())
+	length := first.AddDate(0, 1, -1).Day()
+	best := 0
+	for _, day := range days {
+		if day < 0 {
+			day += length + 1
+		}
+		if day >= 1 && day <= length && day > after && (best == 0 || day < best) {
+			best = day
+		}
+	}
+	if best == 0 {
+		return time.Time{}, false
+	}
+	return first.AddDate(0, 0, best-1), true
+}
+
+// Allows reports whether the rule still produces the given occurrence,
+// numbered from 1, when it falls on due.
+func (r Recurrence) Allows(occurrence int, due time.Time) bool {
+	if r.Count > 0 && occurrence > r.Count {
+		return false
+	}
+	return r.Until == nil || !due.After(*r.Until)
+}
+
+// weekdayOffset returns the position of day in a week that starts on Monday.
+func weekdayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// SeriesScope selects which occurrences of a recurring task an edit applies
+// to.
+type SeriesScope string
+
+const (
+	// ScopeThis edits only the given occurrence.
+	ScopeThis SeriesScope = "this"
+	// ScopeFuture edits the given occurrence and every later one, including
+	// those that are yet to be generated.
+	ScopeFuture SeriesScope = "future"
+)
+
+// IsValid checks if a SeriesScope value is valid
+func (s SeriesScope) IsValid() bool {
+	return s == ScopeThis || s == ScopeFuture
+}
+
+// SeriesTemplate holds the fields that the next occurrences of a series are
+// generated from. Edits of a single occurrence leave it unchanged.
+type SeriesTemplate struct {
+	Title       string `bson:"title"`
+	Description string `bson:"description"`
+}
+
+// ErrOccurrenceExists is returned when creating an occurrence that its
+// series already has.
+var ErrOccurrenceExists = NewError(ErrConflict, "occurrence already exists")
+
+// IsRecurring reports whether the task is part of a series that is still
+// generating occurrences.
+func (t Task) IsRecurring() bool {
+	return t.Recurrence != "" && t.SeriesID != nil
+}
+
+// StartSeries makes t the first occurrence of a new series if it has a
+// recurrence rule, and clears any series fields otherwise. The rule is
+// stored in canonical form; it must already have been validated.
+func (t *Task) StartSeries() {
+	t.SeriesID, t.Occurrence, t.SeriesTemplate = nil, 0, nil
+	if t.Recurrence == "" {
+		return
+	}
+	if rule, err := ParseRecurrence(t.Recurrence); err == nil {
+		t.Recurrence = rule.String()
+	}
+	seriesID := primitive.NewObjectID()
+	t.SeriesID = &seriesID
+	t.Occurrence = 1
+	t.SeriesTemplate = &SeriesTemplate{Title: t.Title, Description: t.Description}
+}
+
+// EditSeries returns updated, an edit of existing, with its series fields
+// set for the given scope. rule is the recurrence rule the edit sets: nil
+// keeps the current one and "" ends the series. Changing the rule of an
+// existing series requires ScopeFuture.
+func EditSeries(existing, updated Task, rule *string, scope SeriesScope) (Task, error) {
+	updated.Recurrence = existing.Recurrence
+	updated.SeriesID = existing.SeriesID
+	updated.Occurrence = existing.Occurrence
+	updated.SeriesTemplate = existing.SeriesTemplate
+
+	if rule != nil {
+		canonical := ""
+		if *rule != "" {
+			parsed, err := ParseRecurrence(*rule)
+			if err != nil {
+				return Task{}, NewValidationError("recurrence", err.Error())
+			}
+			canonical = parsed.String()
+		}
+		switch {
+		case canonical == existing.Recurrence:
+		case existing.IsRecurring() && scope != ScopeFuture:
+			return Task{}, NewValidationError("recurrence", "can only be changed for all future occurrences (scope=future)")
+		case existing.SeriesID == nil:
+			updated.Recurrence = canonical
+			updated.StartSeries()
+			return updated, nil
+		default:
+			updated.Recurrence = canonical
+		}
+	}
+
+	if updated.SeriesID != nil && scope == ScopeFuture {
+		if updated.Recurrence == "" {
+			updated.SeriesTemplate = nil
+		} else {
+			updated.SeriesTemplate = &SeriesTemplate{Title: updated.Title, Description: updated.Description}
+		}
+	}
+	return updated, nil
+}
+
+// NextOccurrence returns the occurrence that follows t in its series, or
+// false if the series has ended. Occurrences that would already be due
+// before now are skipped, but still count towards the rule's COUNT.
+func (t Task) NextOccurrence(now time.Time) (Task, bool) {
+	if !t.IsRecurring() {
+		return Task{}, false
+	}
+	rule, err := ParseRecurrence(t.Recurrence)
+	if err != nil {
+		return Task{}, false
+	}
+
+	due, occurrence := t.DueDate, t.Occurrence
+	for {
+		due, occurrence = rule.Next(due), occurrence+1
+		if !rule.Allows(occurrence, due) {
+			return Task{}, false
+		}
+		if due.After(now) {
+			break
+		}
+	}
+
+	template := SeriesTemplate{Title: t.Title, Description: t.Description}
+	if t.SeriesTemplate != nil {
+		template = *t.SeriesTemplate
+	}
+	// Subtasks recur under the same parent, with a fresh checklist, and
+	// occurrences stay in the same project with the same tags and
+	// assignees.
+	var checklist []ChecklistItem
+	for _, item := range t.Checklist {
+		checklist = append(checklist, ChecklistItem{Text: item.Text})
+	}
+	return Task{
+		Title:          template.Title,
+		Description:    template.Description,
+		DueDate:        due,
+		Status:         Pending,
+		OwnerID:        t.OwnerID,
+		Recurrence:     t.Recurrence,
+		SeriesID:       t.SeriesID,
+		Occurrence:     occurrence,
+		SeriesTemplate: &template,
+		ParentID:       t.ParentID,
+		Checklist:      checklist,
+		ProjectID:      t.ProjectID,
+		Tags:           t.Tags,
+		Assignees:      t.Assignees,
+	}, true
+}
@@ -0,0 +1,208 @@
+package Domain_test
+
+import (
+	"testing"
+	"time"
+
+	"task_manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// at returns the given day of 2024 at 09:30 UTC.
+func at(month time.Month, day int) time.Time {
+	return time.Date(2024, month, day, 9, 30, 0, 0, time.UTC)
+}
+
+func TestParseRecurrence(t *testing.T) {
+	for _, tt := range []struct {
+		rule  string
+		want  string
+		until time.Time
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY", time.Time{}},
+		{"RRULE:freq=weekly; interval=2 ;byday=th,mo,MO", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", time.Time{}},
+		{"FREQ=WEEKLY;BYDAY=SU,MO", "FREQ=WEEKLY;BYDAY=MO,SU", time.Time{}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1,15;COUNT=5", "FREQ=MONTHLY;BYMONTHDAY=-1,15;COUNT=5", time.Time{}},
+		// A date includes the whole day, a date-time ends at that instant.
+		{"FREQ=DAILY;UNTIL=20240131", "FREQ=DAILY;UNTIL=20240131T235959Z", time.Date(2024, time.January, 31, 23, 59, 59, 0, time.UTC)},
+		{"FREQ=DAILY;UNTIL=20240131T120000Z", "FREQ=DAILY;UNTIL=20240131T120000Z", time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, err := Domain.ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence: %v", err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("String = %q, want %q", got, tt.want)
+			}
+			if tt.until.IsZero() != (rule.Until == nil) || rule.Until != nil && !rule.Until.Equal(tt.until) {
+				t.Errorf("Until = %v, want %v", rule.Until, tt.until)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRejectsInvalidRules(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;",
+		"FREQ=DAILY;INTERVAL",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=many",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ=DAILY;UNTIL=2024-01-31",
+		"FREQ=DAILY;UNTIL=20240131T120000",
+		"FREQ=DAILY;UNTIL=20240131;COUNT=3",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYDAY=MO,",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=-32",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			if r, err := Domain.ParseRecurrence(rule); err == nil {
+				t.Errorf("ParseRecurrence = %q, want an error", r)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  []time.Time
+	}{
+		{"daily", "FREQ=DAILY", at(time.January, 30), []time.Time{at(time.January, 31), at(time.February, 1)}},
+		{"daily with interval", "FREQ=DAILY;INTERVAL=3", at(time.February, 27), []time.Time{at(time.March, 1), at(time.March, 4)}},
+		// 2024-01-01 is a Monday.
+		{"weekly on the start's weekday", "FREQ=WEEKLY", at(time.January, 3), []time.Time{at(time.January, 10), at(time.January, 17)}},
+		{"weekly by day", "FREQ=WEEKLY;BYDAY=MO,TH", at(time.January, 1), []time.Time{at(time.January, 4), at(time.January, 8), at(time.January, 11)}},
+		{"weekly by day with interval", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", at(time.January, 1), []time.Time{at(time.January, 4), at(time.January, 15), at(time.January, 18), at(time.January, 29)}},
+		{"weekly by day after the last day of the week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", at(time.January, 3), []time.Time{at(time.January, 15), at(time.January, 29)}},
+		{"weekly on sunday, the end of the week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,MO", at(time.January, 1), []time.Time{at(time.January, 7), at(time.January, 15), at(time.January, 21)}},
+		{"monthly on the start's day", "FREQ=MONTHLY", at(time.January, 15), []time.Time{at(time.February, 15), at(time.March, 15)}},
+		{"monthly by day", "FREQ=MONTHLY;BYMONTHDAY=1,15", at(time.January, 15), []time.Time{at(time.February, 1), at(time.February, 15), at(time.March, 1)}},
+		{"monthly on the last day", "FREQ=MONTHLY;BYMONTHDAY=-1", at(time.January, 31), []time.Time{at(time.February, 29), at(time.March, 31), at(time.April, 30)}},
+		{"monthly on the second to last day", "FREQ=MONTHLY;BYMONTHDAY=-2", at(time.January, 30), []time.Time{at(time.February, 28), at(time.March, 30)}},
+		{"monthly skipping short months", "FREQ=MONTHLY;BYMONTHDAY=31", at(time.January, 31), []time.Time{at(time.March, 31), at(time.May, 31), at(time.July, 31), at(time.August, 31)}},
+		{"monthly on the 30th skipping february", "FREQ=MONTHLY", at(time.January, 30), []time.Time{at(time.March, 30), at(time.April, 30)}},
+		{"monthly with interval skipping short months", "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=31", at(time.July, 31), []time.Time{time.Date(2025, time.January, 31, 9, 30, 0, 0, time.UTC), time.Date(2025, time.March, 31, 9, 30, 0, 0, time.UTC)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Domain.ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence: %v", err)
+			}
+			current := tt.start
+			for _, want := range tt.want {
+				next := rule.Next(current)
+				if !next.Equal(want) {
+					t.Fatalf("Next(%s) = %s, want %s", current.Format(time.DateTime), next.Format(time.DateTime), want.Format(time.DateTime))
+				}
+				current = next
+			}
+		})
+	}
+}
+
+func TestNextMonthDay(t *testing.T) {
+	current := at(time.January, 31)
+	for _, tt := range []struct {
+		name  string
+		month time.Month
+		after int
+		days  []int
+		want  int
+	}{
+		{"first day", time.March, 0, []int{1}, 1},
+		{"earliest of several days", time.March, 0, []int{20, 10, 15}, 10},
+		{"after a day", time.March, 10, []int{10, 15, 20}, 15},
+		{"after all days", time.March, 20, []int{10, 15, 20}, 0},
+		{"last day", time.February, 0, []int{-1}, 29},
+		{"counted from the end", time.April, 0, []int{-30}, 1},
+		{"negative and positive days", time.April, 15, []int{-1, 20}, 20},
+		{"day past the end of the month", time.April, 0, []int{31}, 0},
+		{"negative day past the start of the month", time.February, 0, []int{-30}, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Domain.NextMonthDay(current, 2024, tt.month, tt.after, tt.days)
+			if tt.want == 0 {
+				if ok {
+					t.Errorf("nextMonthDay = %s, want none", got.Format(time.DateTime))
+				}
+				return
+			}
+			if want := at(tt.month, tt.want); !ok || !got.Equal(want) {
+				t.Errorf("nextMonthDay = %s, %v, want %s", got.Format(time.DateTime), ok, want.Format(time.DateTime))
+			}
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	seriesID := primitive.NewObjectID()
+	now := at(time.January, 1).Add(-time.Hour)
+	for _, tt := range []struct {
+		name       string
+		rule       string
+		due        time.Time
+		occurrence int
+		now        time.Time
+		// want is the due date of the next occurrence, or zero if the
+		// series has ended.
+		want           time.Time
+		wantOccurrence int
+	}{
+		{"first", "FREQ=DAILY", at(time.January, 1), 1, now, at(time.January, 2), 2},
+		{"within COUNT", "FREQ=DAILY;COUNT=3", at(time.January, 2), 2, now, at(time.January, 3), 3},
+		{"after COUNT", "FREQ=DAILY;COUNT=3", at(time.January, 3), 3, now, time.Time{}, 0},
+		// A date includes the whole day.
+		{"on the UNTIL date", "FREQ=DAILY;UNTIL=20240103", at(time.January, 2), 2, now, at(time.January, 3), 3},
+		{"after the UNTIL date", "FREQ=DAILY;UNTIL=20240103", at(time.January, 3), 3, now, time.Time{}, 0},
+		{"at the UNTIL date-time", "FREQ=DAILY;UNTIL=20240103T093000Z", at(time.January, 2), 2, now, at(time.January, 3), 3},
+		{"after the UNTIL date-time", "FREQ=DAILY;UNTIL=20240103T080000Z", at(time.January, 2), 2, now, time.Time{}, 0},
+		// Occurrences already due are skipped but counted.
+		{"skipping past occurrences", "FREQ=DAILY", at(time.January, 1), 1, at(time.January, 5).Add(time.Hour), at(time.January, 6), 6},
+		{"skipping past COUNT", "FREQ=DAILY;COUNT=5", at(time.January, 1), 1, at(time.January, 5).Add(time.Hour), time.Time{}, 0},
+		{"skipping past UNTIL", "FREQ=DAILY;UNTIL=20240105", at(time.January, 1), 1, at(time.January, 5).Add(time.Hour), time.Time{}, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			task := Domain.Task{Title: "Stand-up", DueDate: tt.due, Recurrence: tt.rule, SeriesID: &seriesID, Occurrence: tt.occurrence, Status: Domain.Completed}
+			next, ok := task.NextOccurrence(tt.now)
+			if tt.want.IsZero() {
+				if ok {
+					t.Errorf("NextOccurrence = %s, want none", next.DueDate.Format(time.DateTime))
+				}
+				return
+			}
+			if !ok || !next.DueDate.Equal(tt.want) || next.Occurrence != tt.wantOccurrence {
+				t.Fatalf("NextOccurrence = %s #%d, %v, want %s #%d", next.DueDate.Format(time.DateTime), next.Occurrence, ok, tt.want.Format(time.DateTime), tt.wantOccurrence)
+			}
+			if next.Status != Domain.Pending || next.SeriesID != task.SeriesID || next.Recurrence != task.Recurrence || next.Title != task.Title {
+				t.Errorf("NextOccurrence = %+v, want a pending occurrence of the same series", next)
+			}
+		})
+	}
+
+	// A task that is not part of a series, or whose series has ended, has
+	// no next occurrence.
+	for _, task := range []Domain.Task{
+		{Title: "One-off", DueDate: at(time.January, 1), Status: Domain.Completed},
+		{Title: "Ended series", DueDate: at(time.January, 1), SeriesID: &seriesID, Occurrence: 3, Status: Domain.Completed},
+		{Title: "Invalid rule", DueDate: at(time.January, 1), Recurrence: "FREQ=YEARLY", SeriesID: &seriesID, Status: Domain.Completed},
+	} {
+		if next, ok := task.NextOccurrence(now); ok {
+			t.Errorf("NextOccurrence of %q = %s, want none", task.Title, next.DueDate.Format(time.DateTime))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package Domain
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxReminderLead is the longest time before a due date that a reminder
+// may be sent.
+const MaxReminderLead = 7 * 24 * time.Hour
+
+// ReminderPreferences are a user's settings for due-date reminders.
+type ReminderPreferences struct {
+	UserID  primitive.ObjectID `json:"-" bson:"_id"`
+	Enabled bool               `json:"enabled" bson:"enabled"`
+	// LeadMinutes is how long before the due date of a task its reminder
+	// is sent.
+	LeadMinutes int `json:"lead_minutes" bson:"lead_minutes"`
+	// Email is the address the SMTP notifier sends reminders to.
+	Email string `json:"email" bson:"email"`
+}
+
+// LeadTime returns LeadMinutes as a duration.
+func (p ReminderPreferences) LeadTime() time.Duration {
+	return time.Duration(p.LeadMinutes) * time.Minute
+}
+
+// Validate validates the ReminderPreferences data.
+func (p ReminderPreferences) Validate() error {
+	verr := &ValidationError{}
+	if p.LeadMinutes < 1 || p.LeadTime() > MaxReminderLead {
+		verr.Add("lead_minutes", fmt.Sprintf("must be between 1 and %d", int(MaxReminderLead/time.Minute)))
+	}
+	if p.Email != "" {
+		if addr, err := mail.ParseAddress(p.Email); err != nil || addr.Address != p.Email {
+			verr.Add("email", "must be a plain email address")
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+// Reminder tells a user that one of their tasks is due soon.
+type Reminder struct {
+	Task   Task               `json:"task"`
+	UserID primitive.ObjectID `json:"user_id"`
+	// Email is the user's reminder address, if they have set one.
+	Email string `json:"email,omitempty"`
+}
+
+// Notifier delivers reminders.
+type Notifier interface {
+	// Notify sends a single reminder. It returns ErrNoReminderRecipient if
+	// the notifier has no way to reach the user.
+	Notify(ctx context.Context, reminder Reminder) error
+}
+
+var (
+	// ErrReminderPreferencesNotFound is returned when a user has not set reminder preferences.
+	ErrReminderPreferencesNotFound = NewError(ErrNotFound, "reminder preferences not found")
+	// ErrNoReminderRecipient is returned by a Notifier that cannot reach the user of a reminder.
+	ErrNoReminderRecipient = NewError(ErrValidation, "no address to send the reminder to")
+)
+
+// ReminderRepository stores reminder preferences and remembers which
+// reminders have been sent.
+type ReminderRepository interface {
+	GetPreferences(ctx context.Context, userID string) (ReminderPreferences, error)
+	SavePreferences(ctx context.Context, prefs ReminderPreferences) (ReminderPreferences, error)
+	// MarkReminderSent records that the reminder for a task due at dueDate
+	// has been sent. It reports whether the reminder was newly recorded,
+	// i.e. false means it had already been sent. Records only need to be
+	// kept until dueDate, and a task whose due date changes is reminded of
+	// again.
+	MarkReminderSent(ctx context.Context, taskID primitive.ObjectID, dueDate time.Time) (bool, error)
+	// UnmarkReminderSent removes the record of a reminder, so that a
+	// reminder that could not be delivered is sent again.
+	UnmarkReminderSent(ctx context.Context, taskID primitive.ObjectID, dueDate time.Time) error
+}
@@ -0,0 +1,368 @@
+package Domain
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// TitleSearchWeight and DescriptionSearchWeight weigh matches in the
+	// title and description of a task when ranking search results.
+	TitleSearchWeight       = 3
+	DescriptionSearchWeight = 1
+)
+
+// TaskSearchResult is a task matched by a full-text search.
+type TaskSearchResult struct {
+	Task Task `json:"task"`
+	// Score ranks the result; higher scores are more relevant.
+	Score float64 `json:"score"`
+	// Highlights holds an HTML snippet of every matched field, with the
+	// text escaped and the matched words wrapped in <mark> tags.
+	Highlights map[string]string `json:"highlights"`
+}
+
+// TaskSearchPage is a single page of search results, most relevant first.
+type TaskSearchPage struct {
+	Results []TaskSearchResult `json:"results"`
+	Total   int64              `json:"total"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+}
+
+// TextSearch is a parsed full-text search. It follows the syntax of MongoDB
+// text search: a task matches if it contains any of the terms, every phrase
+// (written in double quotes) and none of the excluded terms (prefixed with
+// a minus sign).
+type TextSearch struct {
+	Terms    []string
+	Excluded []string
+	Phrases  []string
+}
+
+// ParseTextSearch parses a search string. Terms are normalized with
+// SearchTerms.
+func ParseTextSearch(text string) TextSearch {
+	var search TextSearch
+	for text != "" {
+		if strings.HasPrefix(text, `"`) {
+			phrase, rest, _ := strings.Cut(text[1:], `"`)
+			if phrase = strings.TrimSpace(strings.ToLower(phrase)); phrase != "" {
+				search.Phrases = append(search.Phrases, phrase)
+				search.Terms = append(search.Terms, SearchTerms(phrase)...)
+			}
+			text = rest
+			continue
+		}
+
+		end := strings.IndexFunc(text, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		text = strings.TrimLeftFunc(text[end:], unicode.IsSpace)
+		if excluded, ok := strings.CutPrefix(word, "-"); ok {
+			search.Excluded = append(search.Excluded, SearchTerms(excluded)...)
+		} else {
+			search.Terms = append(search.Terms, SearchTerms(word)...)
+		}
+	}
+	search.Terms = uniqueStrings(search.Terms)
+	search.Excluded = uniqueStrings(search.Excluded)
+	return search
+}
+
+// IsEmpty reports whether the search has nothing to look for, e.g. because
+// it only consists of stop words.
+func (s TextSearch) IsEmpty() bool {
+	return len(s.Terms) == 0
+}
+
+// Matches reports whether task satisfies the search.
+func (s TextSearch) Matches(task Task) bool {
+	terms := make(map[string]bool)
+	for _, term := range SearchTerms(task.Title) {
+		terms[term] = true
+	}
+	for _, term := range SearchTerms(task.Description) {
+		terms[term] = true
+	}
+
+	for _, term := range s.Excluded {
+		if terms[term] {
+			return false
+		}
+	}
+	title, description := strings.ToLower(task.Title), strings.ToLower(task.Description)
+	for _, phrase := range s.Phrases {
+		if !strings.Contains(title, phrase) && !strings.Contains(description, phrase) {
+			return false
+		}
+	}
+	for _, term := range s.Terms {
+		if terms[term] {
+			return true
+		}
+	}
+	return false
+}
+
+// Score ranks task for the search the way MongoDB scores text index
+// matches: every occurrence of a term counts half as much as the previous
+// one, terms weigh more in short fields, and title matches weigh
+// TitleSearchWeight times as much as description matches.
+func (s TextSearch) Score(task Task) float64 {
+	return scoreField(task.Title, TitleSearchWeight, s.Terms) +
+		scoreField(task.Description, DescriptionSearchWeight, s.Terms)
+}
+
+func scoreField(text string, weight float64, terms []string) float64 {
+	tokens := SearchTerms(text)
+	type termFrequency struct {
+		count int
+		freq  float64
+	}
+	frequencies := make(map[string]*termFrequency)
+	for _, token := range tokens {
+		f, ok := frequencies[token]
+		if !ok {
+			f = &termFrequency{}
+			frequencies[token] = f
+		}
+		f.freq += math.Pow(0.5, float64(f.count))
+		f.count++
+	}
+
+	var score float64
+	for _, term := range terms {
+		f, ok := frequencies[term]
+		if !ok {
+			continue
+		}
+		coefficient := 0.5*float64(f.count)/float64(len(tokens)) + 0.5
+		adjustment := 1.0
+		if strings.ToLower(text) == term {
+			adjustment = 1.1
+		}
+		score += weight * f.freq * coefficient * adjustment
+	}
+	return score
+}
+
+// Highlight returns an HTML snippet of every field of task that the search
+// matches, with the text escaped and the matched words wrapped in <mark>
+// tags.
+func (s TextSearch) Highlight(task Task) map[string]string {
+	highlights := make(map[string]string)
+	if snippet, ok := s.highlightField(task.Title, 0); ok {
+		highlights["title"] = snippet
+	}
+	if snippet, ok := s.highlightField(task.Description, 160); ok {
+		highlights["description"] = snippet
+	}
+	return highlights
+}
+
+// highlightField marks the matches in text. If maxLen is positive and text
+// is longer, only a window of about maxLen bytes around the first match is
+// returned.
+func (s TextSearch) highlightField(text string, maxLen int) (string, bool) {
+	terms := make(map[string]bool, len(s.Terms))
+	for _, term := range s.Terms {
+		terms[term] = true
+	}
+
+	var matches [][2]int
+	for _, word := range words(text) {
+		if stem := normalizeWord(text[word[0]:word[1]]); stem != "" && terms[stem] {
+			matches = append(matches, word)
+		}
+	}
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Offsets into lower would not match text; only mark single words.
+		lower = ""
+	}
+	for _, phrase := range s.Phrases {
+		for offset := 0; ; {
+			i := strings.Index(lower[offset:], phrase)
+			if i < 0 {
+				break
+			}
+			matches = append(matches, [2]int{offset + i, offset + i + len(phrase)})
+			offset += i + len(phrase)
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+	matches = mergeRanges(matches)
+
+	start, end := 0, len(text)
+	if maxLen > 0 && len(text) > maxLen {
+		start = max(matches[0][0]-maxLen/4, 0)
+		end = max(min(start+maxLen, len(text)), matches[0][1])
+		start, end = snapToWords(text, start, end, matches[0])
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m[0] < start || m[1] > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:m[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[m[0]:m[1]]))
+		b.WriteString("</mark>")
+		pos = m[1]
+	}
+	if end < len(text) {
+		b.WriteString(html.EscapeString(strings.TrimRightFunc(text[pos:end], unicode.IsSpace)))
+		b.WriteString("…")
+	} else {
+		b.WriteString(html.EscapeString(text[pos:end]))
+	}
+	return b.String(), true
+}
+
+// mergeRanges sorts byte ranges and merges overlapping ones.
+func mergeRanges(ranges [][2]int) [][2]int {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1] {
+			last[1] = max(last[1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// snapToWords shrinks the window [start, end) of text so that it does not
+// cut through a word, but never beyond the given match.
+func snapToWords(text string, start, end int, match [2]int) (int, int) {
+	for start > 0 && start < match[0] && !isSpaceBefore(text, start) {
+		start++
+	}
+	for end < len(text) && end > match[1] && !isSpaceBefore(text, end) {
+		end--
+	}
+	return start, end
+}
+
+func isSpaceBefore(text string, i int) bool {
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	return unicode.IsSpace(r)
+}
+
+// SearchTerms splits text into the normalized terms used by full-text
+// search: lower-cased, without English stop words and with plural forms
+// reduced to their singular.
+func SearchTerms(text string) []string {
+	var terms []string
+	for _, word := range words(text) {
+		if term := normalizeWord(text[word[0]:word[1]]); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// words returns the byte ranges of the runs of letters and digits in text.
+func words(text string) [][2]int {
+	var ranges [][2]int
+	start := -1
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && start < 0 {
+			start = i
+		} else if !isWordRune && start >= 0 {
+			ranges = append(ranges, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		ranges = append(ranges, [2]int{start, len(text)})
+	}
+	return ranges
+}
+
+// normalizeWord returns the search term for a single word, or "" for a
+// stop word.
+func normalizeWord(word string) string {
+	word = strings.ToLower(word)
+	if stopWords[word] {
+		return ""
+	}
+	return stem(word)
+}
+
+// stem removes plural suffixes following step 1a of the Porter2 stemmer
+// that MongoDB uses for English text indexes. Other suffixes are kept, so
+// "reports" matches "report" but "reporting" does not.
+func stem(word string) string {
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ied"), strings.HasSuffix(word, "ies"):
+		if len(word) > 4 {
+			return word[:len(word)-2]
+		}
+		return word[:len(word)-1]
+	case strings.HasSuffix(word, "us"), strings.HasSuffix(word, "ss"):
+		return word
+	case strings.HasSuffix(word, "s") && len(word) > 2:
+		// Only drop the s if a vowel appears before the letter preceding it.
+		if strings.ContainsAny(word[:len(word)-2], "aeiouy") {
+			return word[:len(word)-1]
+		}
+	}
+	return word
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := values[:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// stopWords are common English words that are not indexed.
+var stopWords = map[string]bool{
+	"a": true, "about": true, "above": true, "after": true, "again": true, "against": true,
+	"all": true, "am": true, "an": true, "and": true, "any": true, "are": true, "as": true,
+	"at": true, "be": true, "because": true, "been": true, "before": true, "being": true,
+	"below": true, "between": true, "both": true, "but": true, "by": true, "can": true,
+	"did": true, "do": true, "does": true, "doing": true, "down": true, "during": true,
+	"each": true, "few": true, "for": true, "from": true, "further": true, "had": true,
+	"has": true, "have": true, "having": true, "he": true, "her": true, "here": true,
+	"hers": true, "herself": true, "him": true, "himself": true, "his": true, "how": true,
+	"i": true, "if": true, "in": true, "into": true, "is": true, "it": true, "its": true,
+	"itself": true, "just": true, "me": true, "more": true, "most": true, "my": true,
+	"myself": true, "no": true, "nor": true, "not": true, "now": true, "of": true, "off": true,
+	"on": true, "once": true, "only": true, "or": true, "other": true, "our": true,
+	"ours": true, "ourselves": true, "out": true, "over": true, "own": true, "same": true,
+	"she": true, "should": true, "so": true, "some": true, "such": true, "than": true,
+	"that": true, "the": true, "their": true, "theirs": true, "them": true,
+	"themselves": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "those": true, "through": true, "to": true, "too": true, "under": true,
+	"until": true, "up": true, "very": true, "was": true, "we": true, "were": true,
+	"what": true, "when": true, "where": true, "which": true, "while": true, "who": true,
+	"whom": true, "why": true, "will": true, "with": true, "you": true, "your": true,
+	"yours": true, "yourself": true, "yourselves": true,
+}
@@ -0,0 +1,64 @@
+package Domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"task_manager/Domain"
+)
+
+func TestHighlightEscapesHTML(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		search string
+		task   Domain.Task
+		want   map[string]string
+	}{
+		{
+			name:   "plain text",
+			search: "report",
+			task:   Domain.Task{Title: "Quarterly report"},
+			want:   map[string]string{"title": "Quarterly <mark>report</mark>"},
+		},
+		{
+			name:   "markup around a match",
+			search: "alert",
+			task:   Domain.Task{Title: `<script>alert("hi")</script>`, Description: `<img src=x onerror="alert(1)">`},
+			want: map[string]string{
+				"title":       `&lt;script&gt;<mark>alert</mark>(&#34;hi&#34;)&lt;/script&gt;`,
+				"description": `&lt;img src=x onerror=&#34;<mark>alert</mark>(1)&#34;&gt;`,
+			},
+		},
+		{
+			name:   "markup in a phrase",
+			search: `"r&d <b>budget"`,
+			task:   Domain.Task{Title: "Plan r&d <b>budget</b>"},
+			// The words of the phrase are terms as well.
+			want: map[string]string{"title": "Plan <mark>r&amp;d &lt;b&gt;budget</mark>&lt;/<mark>b</mark>&gt;"},
+		},
+		{
+			name:   "markup in a snippet",
+			search: "report",
+			task:   Domain.Task{Title: "Report", Description: strings.Repeat("<i>filler</i> ", 20) + "the report " + strings.Repeat("<i>filler</i> ", 20)},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Domain.ParseTextSearch(tt.search).Highlight(tt.task)
+			for field, snippet := range got {
+				// Only the <mark> tags are markup.
+				text := strings.NewReplacer("<mark>", "", "</mark>", "").Replace(snippet)
+				if strings.ContainsAny(text, `<>"`) {
+					t.Errorf("%s snippet %q is not escaped", field, snippet)
+				}
+				if want, ok := tt.want[field]; ok && snippet != want {
+					t.Errorf("%s snippet = %q, want %q", field, snippet, want)
+				}
+			}
+			for field := range tt.want {
+				if _, ok := got[field]; !ok {
+					t.Errorf("no %s snippet", field)
+				}
+			}
+		})
+	}
+}
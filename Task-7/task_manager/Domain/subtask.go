@@ -0,0 +1,114 @@
+package Domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// MaxChecklistItems is the largest number of checklist items in a task.
+	MaxChecklistItems = 100
+	// MaxChecklistItemLength is the longest checklist item text.
+	MaxChecklistItemLength = 200
+	// MaxBlockers is the largest number of tasks a task can be blocked by.
+	MaxBlockers = 50
+)
+
+// ChecklistItem is a step inside a task. Checklist items are informational
+// and do not prevent completing their task.
+type ChecklistItem struct {
+	Text string `json:"text" bson:"text"`
+	Done bool   `json:"done" bson:"done"`
+}
+
+// SubtaskPolicy decides what happens to the subtasks of a deleted task.
+type SubtaskPolicy string
+
+const (
+	// SubtasksCascade deletes all descendants together with the task.
+	SubtasksCascade SubtaskPolicy = "cascade"
+	// SubtasksReparent moves the subtasks up to the parent of the deleted
+	// task, or to the top level.
+	SubtasksReparent SubtaskPolicy = "reparent"
+)
+
+// IsValid checks if a SubtaskPolicy value is valid
+func (p SubtaskPolicy) IsValid() bool {
+	return p == SubtasksCascade || p == SubtasksReparent
+}
+
+// TaskTree is a task together with its subtasks, recursively.
+type TaskTree struct {
+	Task
+	Subtasks []TaskTree `json:"subtasks"`
+}
+
+var (
+	// ErrTaskBlocked is returned when a task is completed while a task it is
+	// blocked by or one of its subtasks is still open.
+	ErrTaskBlocked = NewError(ErrConflict, "task has open blockers or subtasks")
+	// ErrHasSubtasks is returned when a task with subtasks is deleted without
+	// a SubtaskPolicy.
+	ErrHasSubtasks = NewError(ErrConflict, "task has subtasks")
+)
+
+// validateRelations checks the parent, checklist and blockers of t that can
+// be checked without looking at other tasks.
+func (t Task) validateRelations(verr *ValidationError) {
+	if t.ParentID != nil && (t.ParentID.IsZero() || *t.ParentID == t.ID) {
+		verr.Add("parent_id", "must be another task")
+	}
+	if len(t.Checklist) > MaxChecklistItems {
+		verr.Add("checklist", fmt.Sprintf("cannot have more than %d items", MaxChecklistItems))
+	}
+	for i, item := range t.Checklist {
+		if strings.TrimSpace(item.Text) == "" {
+			verr.Add(fmt.Sprintf("checklist[%d].text", i), "cannot be empty")
+		}
+		if len(item.Text) > MaxChecklistItemLength {
+			verr.Add(fmt.Sprintf("checklist[%d].text", i), fmt.Sprintf("cannot exceed %d characters", MaxChecklistItemLength))
+		}
+	}
+	if len(t.BlockedBy) > MaxBlockers {
+		verr.Add("blocked_by", fmt.Sprintf("cannot have more than %d tasks", MaxBlockers))
+	}
+	for i, id := range t.BlockedBy {
+		switch {
+		case id.IsZero() || id == t.ID:
+			verr.Add("blocked_by", "must list other tasks")
+		case slices.Contains(t.BlockedBy[:i], id):
+			verr.Add("blocked_by", fmt.Sprintf("lists task %s twice", id.Hex()))
+		}
+	}
+}
+
+// SameRelations reports whether a and b have the same parent and blockers.
+func SameRelations(a, b Task) bool {
+	sameParent := (a.ParentID == nil) == (b.ParentID == nil) && (a.ParentID == nil || *a.ParentID == *b.ParentID)
+	return sameParent && slices.Equal(a.BlockedBy, b.BlockedBy)
+}
+
+// formatChecklist renders a checklist for the audit log.
+func formatChecklist(items []ChecklistItem) string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		mark := "[ ]"
+		if item.Done {
+			mark = "[x]"
+		}
+		lines[i] = mark + " " + item.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatIDs renders a list of IDs for the audit log.
+func formatIDs(ids []primitive.ObjectID) string {
+	hex := make([]string, len(ids))
+	for i, id := range ids {
+		hex[i] = id.Hex()
+	}
+	return strings.Join(hex, ",")
+}
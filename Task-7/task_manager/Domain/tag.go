@@ -0,0 +1,82 @@
+package Domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// MaxTags is the largest number of tags on a task.
+	MaxTags = 20
+	// MaxTagLength is the longest tag, in characters.
+	MaxTagLength = 50
+	// DefaultTagLimit is the number of suggestions returned when a TagQuery
+	// has no limit.
+	DefaultTagLimit = 10
+	// MaxTagLimit is the largest number of suggestions a TagQuery may request.
+	MaxTagLimit = 50
+)
+
+// NormalizeTags returns tags trimmed, lower-cased and without empty tags or
+// duplicates, keeping the order in which they first appear.
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// validateTags checks the tags of a task, which must already be normalized.
+func validateTags(verr *ValidationError, tags []string) {
+	if len(tags) > MaxTags {
+		verr.Add("tags", fmt.Sprintf("cannot have more than %d tags", MaxTags))
+	}
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			verr.Add("tags", fmt.Sprintf("tag %q exceeds %d characters", tag, MaxTagLength))
+		}
+	}
+}
+
+// TagQuery asks for the tags in use that start with Prefix, for
+// autocompletion.
+type TagQuery struct {
+	// VisibleTo and VisibleProjects restrict the tags to those of tasks
+	// visible to a user, as in TaskQuery.
+	VisibleTo       string
+	VisibleProjects []primitive.ObjectID
+	// ProjectID restricts the tags to those of the tasks of a project.
+	ProjectID string
+	Prefix    string
+	Limit     int
+}
+
+// Normalize fills in default values, normalizes the prefix like a tag and
+// validates the query.
+func (q *TagQuery) Normalize() error {
+	q.Prefix = strings.ToLower(strings.TrimSpace(q.Prefix))
+	if q.Limit < 0 || q.Limit > MaxTagLimit {
+		return NewValidationError("limit", fmt.Sprintf("must be between 1 and %d", MaxTagLimit))
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTagLimit
+	}
+	return nil
+}
+
+// TagCount is a tag together with the number of live tasks carrying it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
@@ -0,0 +1,193 @@
+package Domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskPatch is a partial update of a task. Nil fields are left unchanged.
+type TaskPatch struct {
+	Title       *string
+	Description *string
+	DueDate     *time.Time
+	Status      *Status
+	// Recurrence sets the recurrence rule; an empty rule ends the series.
+	Recurrence *string
+	// ParentID moves the task under another task; a zero ID moves it to
+	// the top level.
+	ParentID  *primitive.ObjectID
+	Checklist *[]ChecklistItem
+	BlockedBy *[]primitive.ObjectID
+	// ProjectID moves the task into a project; a zero ID takes it out of
+	// its project.
+	ProjectID *primitive.ObjectID
+	Tags      *[]string
+	Assignees *[]primitive.ObjectID
+	// EnteredStatusAt is the time the task entered the patched status. It
+	// is set by the use case, not by clients.
+	EnteredStatusAt *time.Time
+}
+
+// IsEmpty reports whether the patch changes nothing.
+func (p TaskPatch) IsEmpty() bool {
+	return p.Title == nil && p.Description == nil && p.DueDate == nil && p.Status == nil && p.Recurrence == nil &&
+		p.ParentID == nil && p.Checklist == nil && p.BlockedBy == nil && p.ProjectID == nil && p.Tags == nil &&
+		p.Assignees == nil
+}
+
+// Apply returns a copy of task with the patch applied.
+func (p TaskPatch) Apply(task Task) Task {
+	if p.Title != nil {
+		task.Title = *p.Title
+	}
+	if p.Description != nil {
+		task.Description = *p.Description
+	}
+	if p.DueDate != nil {
+		task.DueDate = *p.DueDate
+	}
+	if p.Status != nil {
+		task.Status = *p.Status
+		if p.EnteredStatusAt != nil {
+			task.EnterStatus(*p.EnteredStatusAt)
+		}
+	}
+	if p.Recurrence != nil {
+		task.Recurrence = *p.Recurrence
+	}
+	if p.ParentID != nil {
+		task.ParentID = nil
+		if !p.ParentID.IsZero() {
+			parentID := *p.ParentID
+			task.ParentID = &parentID
+		}
+	}
+	if p.Checklist != nil {
+		task.Checklist = *p.Checklist
+	}
+	if p.BlockedBy != nil {
+		task.BlockedBy = *p.BlockedBy
+	}
+	if p.ProjectID != nil {
+		task.ProjectID = nil
+		if !p.ProjectID.IsZero() {
+			projectID := *p.ProjectID
+			task.ProjectID = &projectID
+		}
+	}
+	if p.Tags != nil {
+		task.Tags = *p.Tags
+	}
+	if p.Assignees != nil {
+		task.Assignees = *p.Assignees
+	}
+	return task
+}
+
+// Validate checks the fields changed by the patch and the invariants of the
+// task that results from applying it to task. Unchanged fields are not
+// revalidated, so an overdue task can still be completed.
+func (p TaskPatch) Validate(task Task) error {
+	verr := &ValidationError{}
+	if p.DueDate != nil {
+		validateDueDate(verr, *p.DueDate)
+	}
+	p.Apply(task).validateInvariants(verr)
+	return verr.ErrOrNil()
+}
+
+// ParseTaskMergePatch decodes an RFC 7396 JSON Merge Patch document for a
+// task. A null value removes a member, which is only allowed for the
+// optional description, recurrence rule, parent, checklist, blockers,
+// project, tags and assignees.
+// Arrays are replaced as a whole. Members that are unknown or read-only are
+// rejected.
+func ParseTaskMergePatch(data []byte) (TaskPatch, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil || members == nil {
+		return TaskPatch{}, NewError(ErrInvalidRequest, "merge patch must be a JSON object")
+	}
+
+	var patch TaskPatch
+	verr := &ValidationError{}
+	for _, name := range slices.Sorted(maps.Keys(members)) {
+		raw := members[name]
+		isNull := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+		switch name {
+		case "title":
+			if isNull {
+				verr.Add(name, "cannot be removed")
+			} else if err := json.Unmarshal(raw, &patch.Title); err != nil {
+				verr.Add(name, "must be a string")
+			}
+		case "description":
+			patch.Description = new(string)
+			if !isNull && json.Unmarshal(raw, patch.Description) != nil {
+				verr.Add(name, "must be a string")
+			}
+		case "due_date":
+			if isNull {
+				verr.Add(name, "cannot be removed")
+			} else if err := json.Unmarshal(raw, &patch.DueDate); err != nil {
+				verr.Add(name, "must be an RFC 3339 time")
+			}
+		case "status":
+			if isNull {
+				verr.Add(name, "cannot be removed")
+			} else if err := json.Unmarshal(raw, &patch.Status); err != nil {
+				verr.Add(name, "must be a string")
+			}
+		case "recurrence":
+			patch.Recurrence = new(string)
+			if !isNull && json.Unmarshal(raw, patch.Recurrence) != nil {
+				verr.Add(name, "must be a string")
+			}
+		case "parent_id":
+			patch.ParentID = new(primitive.ObjectID)
+			if !isNull && json.Unmarshal(raw, patch.ParentID) != nil {
+				verr.Add(name, "must be a task ID")
+			}
+		case "checklist":
+			patch.Checklist = new([]ChecklistItem)
+			if !isNull && json.Unmarshal(raw, patch.Checklist) != nil {
+				verr.Add(name, "must be an array of checklist items")
+			}
+		case "blocked_by":
+			patch.BlockedBy = new([]primitive.ObjectID)
+			if !isNull && json.Unmarshal(raw, patch.BlockedBy) != nil {
+				verr.Add(name, "must be an array of task IDs")
+			}
+		case "project_id":
+			patch.ProjectID = new(primitive.ObjectID)
+			if !isNull && json.Unmarshal(raw, patch.ProjectID) != nil {
+				verr.Add(name, "must be a project ID")
+			}
+		case "tags":
+			patch.Tags = new([]string)
+			if !isNull && json.Unmarshal(raw, patch.Tags) != nil {
+				verr.Add(name, "must be an array of strings")
+			} else {
+				*patch.Tags = NormalizeTags(*patch.Tags)
+			}
+		case "assignees":
+			patch.Assignees = new([]primitive.ObjectID)
+			if !isNull && json.Unmarshal(raw, patch.Assignees) != nil {
+				verr.Add(name, "must be an array of user IDs")
+			}
+		case "id", "owner_id", "series_id", "occurrence", "status_entered_at":
+			verr.Add(name, "is read-only")
+		default:
+			verr.Add(name, fmt.Sprintf("unknown field %q", name))
+		}
+	}
+	if err := verr.ErrOrNil(); err != nil {
+		return TaskPatch{}, err
+	}
+	return patch, nil
+}
@@ -0,0 +1,59 @@
+package Domain
+
+import "fmt"
+
+// MaxUserSearchLength is the longest username search a UserQuery accepts.
+const MaxUserSearchLength = 50
+
+// UserQuery selects a page of users, sorted by username. Page sizes follow
+// the task listing limits.
+type UserQuery struct {
+	// Search, if set, only lists users whose username contains it,
+	// ignoring case.
+	Search string
+	// Role, if set, only lists users with that role.
+	Role UserRole
+	// Disabled, if set, only lists users that are or are not disabled.
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
+
+// Normalize fills in default values and validates the query.
+func (q *UserQuery) Normalize() error {
+	verr := &ValidationError{}
+	if len(q.Search) > MaxUserSearchLength {
+		verr.Add("q", fmt.Sprintf("cannot exceed %d characters", MaxUserSearchLength))
+	}
+	if q.Role != "" && !q.Role.IsValid() {
+		verr.Add("role", fmt.Sprintf("invalid role: %s", q.Role))
+	}
+	if q.Limit < 0 || q.Limit > MaxTaskLimit {
+		verr.Add("limit", fmt.Sprintf("must be between 1 and %d", MaxTaskLimit))
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTaskLimit
+	}
+	if q.Offset < 0 {
+		verr.Add("offset", "cannot be negative")
+	}
+	return verr.ErrOrNil()
+}
+
+// UserPage is a single page of a user listing.
+type UserPage struct {
+	Users  []User `json:"users"`
+	Total  int64  `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+var (
+	// ErrAccountDisabled is returned when a disabled user logs in or uses a token.
+	ErrAccountDisabled = NewError(ErrUnauthorized, "account is disabled")
+	// ErrAccountDeleted is returned when a token belongs to a user that no longer exists.
+	ErrAccountDeleted = NewError(ErrUnauthorized, "account no longer exists")
+	// ErrInvalidCredentials is returned for every failed login, whether the
+	// username does not exist or the password is wrong.
+	ErrInvalidCredentials = NewError(ErrUnauthorized, "invalid username or password")
+)
@@ -0,0 +1,186 @@
+package Domain
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookEventTypes are the task events a webhook can subscribe to.
+var WebhookEventTypes = []TaskEventType{TaskCreated, TaskUpdated, TaskCompleted, TaskDeleted, TaskRestored}
+
+// MinWebhookSecretLength is the minimum length of a webhook signing secret.
+const MinWebhookSecretLength = 16
+
+// WebhookSubscription sends the task events of the given types to a URL.
+type WebhookSubscription struct {
+	ID  primitive.ObjectID `json:"id" bson:"_id"`
+	URL string             `json:"url" bson:"url"`
+	// Secret signs every delivery. It is never returned by the API.
+	Secret    string          `json:"secret,omitempty" bson:"secret"`
+	Events    []TaskEventType `json:"events" bson:"events"`
+	CreatedAt time.Time       `json:"created_at" bson:"created_at"`
+}
+
+// Validate validates the WebhookSubscription data.
+func (s WebhookSubscription) Validate() error {
+	verr := &ValidationError{}
+	if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add("url", "must be an absolute http or https URL")
+	}
+	if len(s.Secret) < MinWebhookSecretLength {
+		verr.Add("secret", fmt.Sprintf("must be at least %d characters", MinWebhookSecretLength))
+	}
+	if len(s.Events) == 0 {
+		verr.Add("events", "must list at least one event type")
+	}
+	for _, event := range s.Events {
+		if !slices.Contains(WebhookEventTypes, event) {
+			verr.Add("events", fmt.Sprintf("invalid event type: %s", event))
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+// Redacted returns a copy of the subscription without its secret.
+func (s WebhookSubscription) Redacted() WebhookSubscription {
+	s.Secret = ""
+	return s
+}
+
+// WebhookDeliveryStatus is the state of a WebhookDelivery.
+type WebhookDeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their first attempt or
+	// for a retry.
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// DeliveryDead deliveries have failed too often and are no longer
+	// retried. They form the dead-letter list and can be redelivered.
+	DeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// IsValid checks if a WebhookDeliveryStatus value is valid
+func (s WebhookDeliveryStatus) IsValid() bool {
+	return s == DeliveryPending || s == DeliverySucceeded || s == DeliveryDead
+}
+
+// WebhookDelivery is a single event queued for a single subscription.
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	SubscriptionID primitive.ObjectID `json:"subscription_id" bson:"subscription_id"`
+	// EventID identifies the event. Receivers can use it to ignore
+	// duplicates; it is kept when a delivery is redelivered.
+	EventID   primitive.ObjectID `json:"event_id" bson:"event_id"`
+	EventType TaskEventType      `json:"event_type" bson:"event_type"`
+	// Payload is the JSON request body.
+	Payload       string                `json:"payload" bson:"payload"`
+	Status        WebhookDeliveryStatus `json:"status" bson:"status"`
+	Attempts      int                   `json:"attempts" bson:"attempts"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"`
+	LastAttemptAt *time.Time            `json:"last_attempt_at,omitempty" bson:"last_attempt_at,omitempty"`
+	// LastStatusCode is the HTTP status of the last response, or 0 if the
+	// last attempt did not get one.
+	LastStatusCode int       `json:"last_status_code,omitempty" bson:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
+}
+
+// WebhookDeliveryQuery describes how a delivery listing is filtered and
+// paginated. Zero values mean "no filter". Page sizes follow the task
+// listing limits.
+type WebhookDeliveryQuery struct {
+	SubscriptionID string
+	Status         WebhookDeliveryStatus
+	Limit          int
+	Offset         int
+}
+
+// Normalize fills in default values and validates the query.
+func (q *WebhookDeliveryQuery) Normalize() error {
+	verr := &ValidationError{}
+	if q.Status != "" && !q.Status.IsValid() {
+		verr.Add("status", fmt.Sprintf("invalid status: %s", q.Status))
+	}
+	if q.Limit < 0 || q.Limit > MaxTaskLimit {
+		verr.Add("limit", fmt.Sprintf("must be between 1 and %d", MaxTaskLimit))
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTaskLimit
+	}
+	if q.Offset < 0 {
+		verr.Add("offset", "cannot be negative")
+	}
+	return verr.ErrOrNil()
+}
+
+// WebhookDeliveryPage is a single page of deliveries, newest first.
+type WebhookDeliveryPage struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int64             `json:"total"`
+	Limit      int               `json:"limit"`
+	Offset     int               `json:"offset"`
+}
+
+var (
+	// ErrWebhookNotFound is returned when a webhook subscription does not exist.
+	ErrWebhookNotFound = NewError(ErrNotFound, "webhook not found")
+	// ErrDeliveryNotFound is returned when a webhook delivery does not exist.
+	ErrDeliveryNotFound = NewError(ErrNotFound, "webhook delivery not found")
+)
+
+// WebhookRepository stores webhook subscriptions and their deliveries.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub WebhookSubscription) (WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id string) (WebhookSubscription, error)
+	// ListSubscriptions returns all subscriptions, oldest first.
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	// DeleteSubscription removes a subscription and its pending deliveries.
+	DeleteSubscription(ctx context.Context, id string) error
+
+	// CreateDeliveries stores deliveries under newly assigned IDs.
+	CreateDeliveries(ctx context.Context, deliveries []WebhookDelivery) ([]WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id string) (WebhookDelivery, error)
+	// ListDeliveries returns the deliveries matching query, newest first.
+	ListDeliveries(ctx context.Context, query WebhookDeliveryQuery) (WebhookDeliveryPage, error)
+	// ClaimDueDeliveries returns up to limit pending deliveries whose next
+	// attempt is due at now, and moves their next attempt to now+lease so
+	// that no other worker claims them while they are being sent.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]WebhookDelivery, error)
+	// UpdateDelivery stores the outcome of an attempt.
+	UpdateDelivery(ctx context.Context, delivery WebhookDelivery) (WebhookDelivery, error)
+}
+
+// WebhookSender sends deliveries to their subscribers.
+type WebhookSender interface {
+	// Send posts the payload of delivery to the subscription's URL and
+	// returns the HTTP status of the response. Any status other than 2xx
+	// is returned together with an error.
+	Send(ctx context.Context, sub WebhookSubscription, delivery WebhookDelivery) (int, error)
+}
+
+// WebhookRetryPolicy decides when failed deliveries are retried.
+type WebhookRetryPolicy struct {
+	// MaxAttempts is the number of attempts after which a failing delivery
+	// is moved to the dead-letter list.
+	MaxAttempts int
+	// BaseDelay is the wait after the first failed attempt. It doubles with
+	// every further failure, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Backoff returns how long to wait before the next attempt of a delivery
+// that has failed attempts times.
+func (p WebhookRetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
@@ -0,0 +1,186 @@
+package Domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Workflow defines the statuses a task can have and the transitions
+// between them. It is loaded from configuration; DefaultWorkflow is used
+// when none is configured.
+type Workflow struct {
+	// Initial is the status of new tasks that do not set one.
+	Initial     Status               `json:"initial"`
+	States      []Status             `json:"states"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// WorkflowTransition allows moving a task from the status From to any of
+// the statuses To. Roles restricts the transition to actors with one of
+// the given roles; an empty list allows every role.
+type WorkflowTransition struct {
+	From  Status     `json:"from"`
+	To    []Status   `json:"to"`
+	Roles []UserRole `json:"roles,omitempty"`
+}
+
+// DefaultWorkflow returns the workflow of pending, completed and not-done
+// tasks, in which every status can be changed to every other.
+func DefaultWorkflow() Workflow {
+	states := []Status{Pending, Completed, NotDone}
+	w := Workflow{Initial: Pending, States: states}
+	for _, from := range states {
+		var to []Status
+		for _, state := range states {
+			if state != from {
+				to = append(to, state)
+			}
+		}
+		w.Transitions = append(w.Transitions, WorkflowTransition{From: from, To: to})
+	}
+	return w
+}
+
+// ParseWorkflow decodes and validates a JSON workflow definition.
+func ParseWorkflow(data []byte) (Workflow, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var w Workflow
+	if err := decoder.Decode(&w); err != nil {
+		return Workflow{}, fmt.Errorf("invalid workflow: %w", err)
+	}
+	if err := w.Validate(); err != nil {
+		return Workflow{}, err
+	}
+	return w, nil
+}
+
+// Validate checks that the workflow is well-formed. It must contain its
+// initial status and Completed, which the dependency and recurrence rules
+// rely on.
+func (w Workflow) Validate() error {
+	verr := &ValidationError{}
+	seen := make(map[Status]bool)
+	for _, state := range w.States {
+		if !state.IsValid() {
+			verr.Add("states", fmt.Sprintf("invalid status: %q", state))
+		}
+		if seen[state] {
+			verr.Add("states", fmt.Sprintf("duplicate status: %s", state))
+		}
+		seen[state] = true
+	}
+	if !seen[w.Initial] {
+		verr.Add("initial", fmt.Sprintf("%q is not one of the states", w.Initial))
+	}
+	if !seen[Completed] {
+		verr.Add("states", fmt.Sprintf("must contain %s", Completed))
+	}
+	for _, transition := range w.Transitions {
+		if !seen[transition.From] {
+			verr.Add("transitions", fmt.Sprintf("unknown status %q", transition.From))
+		}
+		if len(transition.To) == 0 {
+			verr.Add("transitions", fmt.Sprintf("transition from %s has no target", transition.From))
+		}
+		for _, to := range transition.To {
+			if !seen[to] {
+				verr.Add("transitions", fmt.Sprintf("unknown status %q", to))
+			}
+		}
+		for _, role := range transition.Roles {
+			if !role.IsValid() {
+				verr.Add("transitions", fmt.Sprintf("invalid role: %s", role))
+			}
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+// HasState reports whether status is one of the workflow's statuses.
+func (w Workflow) HasState(status Status) bool {
+	return slices.Contains(w.States, status)
+}
+
+// NextStates returns the statuses an actor with the given role may move a
+// task in status from to, in the order of w.States. Tasks in a status the
+// workflow no longer has can move to the statuses new tasks can start in.
+func (w Workflow) NextStates(from Status, role UserRole) []Status {
+	if !w.HasState(from) {
+		return w.InitialStates(role)
+	}
+	allowed := make(map[Status]bool)
+	for _, transition := range w.Transitions {
+		if transition.From == from && (len(transition.Roles) == 0 || slices.Contains(transition.Roles, role)) {
+			for _, to := range transition.To {
+				allowed[to] = true
+			}
+		}
+	}
+	var next []Status
+	for _, state := range w.States {
+		if allowed[state] && state != from {
+			next = append(next, state)
+		}
+	}
+	return next
+}
+
+// InitialStates returns the statuses a new task created by an actor with
+// the given role can start in: the initial status and the statuses the
+// actor may move it to from there.
+func (w Workflow) InitialStates(role UserRole) []Status {
+	return append([]Status{w.Initial}, w.NextStates(w.Initial, role)...)
+}
+
+// CheckTransition checks that an actor with the given role may change the
+// status of a task from from to to. Keeping the status is always allowed.
+func (w Workflow) CheckTransition(from, to Status, role UserRole) error {
+	if from == to {
+		return nil
+	}
+	next := w.NextStates(from, role)
+	if slices.Contains(next, to) {
+		return nil
+	}
+	return NewValidationError("status", fmt.Sprintf("cannot change from %s to %s; allowed next states: %s", from, to, joinStates(next)))
+}
+
+// CheckInitial checks that a task created by an actor with the given role
+// may start in status.
+func (w Workflow) CheckInitial(status Status, role UserRole) error {
+	initial := w.InitialStates(role)
+	if slices.Contains(initial, status) {
+		return nil
+	}
+	return NewValidationError("status", fmt.Sprintf("new tasks cannot start in %s; allowed states: %s", status, joinStates(initial)))
+}
+
+// joinStates lists states for an error message.
+func joinStates(states []Status) string {
+	if len(states) == 0 {
+		return "none"
+	}
+	names := make([]string, len(states))
+	for i, state := range states {
+		names[i] = string(state)
+	}
+	return strings.Join(names, ", ")
+}
+
+// EnterStatus records at as the time the task entered its current status.
+// The map is copied rather than changed in place, since it may be shared
+// with a stored task.
+func (t *Task) EnterStatus(at time.Time) {
+	entered := maps.Clone(t.StatusEnteredAt)
+	if entered == nil {
+		entered = make(map[Status]time.Time)
+	}
+	entered[t.Status] = at
+	t.StatusEnteredAt = entered
+}
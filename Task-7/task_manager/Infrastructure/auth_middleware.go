@@ -2,58 +2,96 @@
 package Infrastructure
 
 import (
-	"net/http"
+	"errors"
 	"strings"
+	"task_manager/Domain"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-
-func AuthMiddleware (jwtService JWTService) gin.HandlerFunc {
+// AuthMiddleware validates the bearer access token, rejects tokens whose
+// jti or token family has been revoked, that were issued before the user's
+// last password reset or whose user has been disabled or deleted, and
+// stores the caller's identity and claims in the gin context.
+// The stored role is the user's current one, so role changes apply to
+// tokens issued before them.
+func AuthMiddleware (jwtService JWTService, revocations Domain.RevocationStore, users Domain.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error":"authorization header required"})
-			c.Abort()
+			abortWithError(c, Domain.NewError(Domain.ErrUnauthorized, "authorization header required"))
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
-			c.Abort()
+			abortWithError(c, Domain.NewError(Domain.ErrUnauthorized, "invalid authorization header format"))
 			return 
 		}
 
 		claims, err := jwtService.ValidateToken(parts[1])
 		if err != nil{
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			c.Abort()
+			abortWithError(c, err)
 			return 
 		}
 
-		c.Set("userID", claims["id"].(string))
-		c.Set("role", claims["role"])
+		revoked, err := revocations.IsRevoked(c.Request.Context(), claims.Id, claims.Family)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if revoked {
+			abortWithError(c, Domain.ErrTokenRevoked)
+			return
+		}
+
+		user, err := users.GetUserByID(c.Request.Context(), claims.UserID)
+		if errors.Is(err, Domain.ErrNotFound) || errors.Is(err, Domain.ErrValidation) {
+			abortWithError(c, Domain.ErrAccountDeleted)
+			return
+		}
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if user.Disabled {
+			abortWithError(c, Domain.ErrAccountDisabled)
+			return
+		}
+		if !user.AcceptsTokenIssuedAt(time.Unix(claims.IssuedAt, 0)) {
+			abortWithError(c, Domain.ErrTokenRevoked)
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("username", user.Username)
+		c.Set("role", string(user.Role))
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
 
-//AdminOnlyMiddleware restricts access to admin users.
-func AdminOnlyMiddleware() gin.HandlerFunc{
+// RequirePermission restricts access to callers whose role is granted
+// every one of permissions by authz. It must run after AuthMiddleware.
+func RequirePermission(authz Domain.Authorizer, permissions ...Domain.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "role not found in token"})
-			c.Abort()
-			return 
+		role := c.GetString("role")
+		if role == "" {
+			abortWithError(c, Domain.NewError(Domain.ErrUnauthorized, "role not found in token"))
+			return
 		}
 
-		if role != "Admin" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "admin role required"})
-			c.Abort()
-			return 
+		actor := Domain.Actor{
+			UserID:   c.GetString("userID"),
+			Username: c.GetString("username"),
+			Role:     Domain.UserRole(role),
+		}
+		if err := Domain.Authorize(authz, actor, permissions...); err != nil {
+			abortWithError(c, err)
+			return
 		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,80 @@
+package Infrastructure
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"task_manager/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorBody is the JSON error envelope returned by every endpoint:
+//
+//	{"error": {"code": "not_found", "message": "task not found: 42"}}
+type ErrorBody struct {
+	Code    string              `json:"code"`
+	Message string              `json:"message"`
+	Details []Domain.FieldError `json:"details,omitempty"`
+}
+
+// ErrorHandler turns the last error attached to the gin context with
+// c.Error into an HTTP response. The status code follows the Domain error
+// kind; errors of unknown kind are logged and reported as a generic 500 so
+// that internal details never reach the client. A Domain.RetryAfterError
+// also sets the Retry-After header.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		status, body := ErrorResponse(err)
+		var rerr *Domain.RetryAfterError
+		if errors.As(err, &rerr) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(rerr.RetryAfter.Seconds()))))
+		}
+		if status == http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		c.JSON(status, gin.H{"error": body})
+	}
+}
+
+// ErrorResponse maps an error to its HTTP status code and envelope.
+func ErrorResponse(err error) (int, ErrorBody) {
+	var verr *Domain.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return http.StatusUnprocessableEntity, ErrorBody{Code: "validation_failed", Message: verr.Error(), Details: verr.Fields}
+	case errors.Is(err, Domain.ErrInternal):
+		return http.StatusInternalServerError, ErrorBody{Code: "internal", Message: "internal server error"}
+	case errors.Is(err, Domain.ErrNotFound):
+		return http.StatusNotFound, ErrorBody{Code: "not_found", Message: err.Error()}
+	case errors.Is(err, Domain.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, ErrorBody{Code: "precondition_failed", Message: err.Error()}
+	case errors.Is(err, Domain.ErrConflict):
+		return http.StatusConflict, ErrorBody{Code: "conflict", Message: err.Error()}
+	case errors.Is(err, Domain.ErrInvalidRequest):
+		return http.StatusBadRequest, ErrorBody{Code: "invalid_request", Message: err.Error()}
+	case errors.Is(err, Domain.ErrUnauthorized):
+		return http.StatusUnauthorized, ErrorBody{Code: "unauthorized", Message: err.Error()}
+	case errors.Is(err, Domain.ErrForbidden):
+		return http.StatusForbidden, ErrorBody{Code: "forbidden", Message: err.Error()}
+	case errors.Is(err, Domain.ErrTooManyRequests):
+		return http.StatusTooManyRequests, ErrorBody{Code: "too_many_requests", Message: err.Error()}
+	default:
+		return http.StatusInternalServerError, ErrorBody{Code: "internal", Message: "internal server error"}
+	}
+}
+
+// abortWithError attaches err to the context for ErrorHandler and stops the
+// handler chain without writing a response itself.
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,127 @@
+package Infrastructure
+
+import (
+	"context"
+	"sync"
+	"task_manager/Domain"
+	"time"
+)
+
+// InMemoryTaskEventBroker implements Domain.TaskEventBroker within a single
+// process. It keeps the most recent events so that subscribers can resume
+// after a reconnect. Event IDs start again at 1 when the process restarts.
+type InMemoryTaskEventBroker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Domain.TaskEvent
+	historySize int
+	bufferSize  int
+	subscribers map[*taskSubscriber]struct{}
+}
+
+// taskSubscriber buffers the events published for one subscriber. They are
+// filtered on the subscriber's own goroutine, since filters may query the
+// database and must not hold up the publisher.
+type taskSubscriber struct {
+	incoming chan Domain.TaskEvent
+}
+
+// Publish implements Domain.TaskEventBroker. A subscriber whose buffer is
+// full is dropped instead of blocking the publisher.
+func (b *InMemoryTaskEventBroker) Publish(ctx context.Context, event Domain.TaskEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event.ID = b.lastID
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if b.historySize > 0 {
+		if len(b.history) == b.historySize {
+			b.history = append(b.history[:0], b.history[1:]...)
+		}
+		b.history = append(b.history, event)
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.incoming <- event:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.incoming)
+		}
+	}
+	return nil
+}
+
+// Subscribe implements Domain.TaskEventBroker.
+func (b *InMemoryTaskEventBroker) Subscribe(ctx context.Context, afterID uint64, filter func(Domain.TaskEvent) bool) (<-chan Domain.TaskEvent, error) {
+	b.mu.Lock()
+	replay := b.replay(afterID)
+	sub := &taskSubscriber{incoming: make(chan Domain.TaskEvent, b.bufferSize+len(replay))}
+	for _, event := range replay {
+		sub.incoming <- event
+	}
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	events := make(chan Domain.TaskEvent)
+	go sub.forward(ctx, filter, events)
+	go func() {
+		<-ctx.Done()
+		b.unsubscribe(sub)
+	}()
+	return events, nil
+}
+
+// forward passes the buffered events that filter accepts on to events
+// until the subscriber is dropped or ctx is done, and then closes events.
+func (sub *taskSubscriber) forward(ctx context.Context, filter func(Domain.TaskEvent) bool, events chan<- Domain.TaskEvent) {
+	defer close(events)
+	for event := range sub.incoming {
+		if filter != nil && !filter(event) {
+			continue
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// replay returns the buffered events after afterID, or a reset event if
+// some of them have already been discarded. It must be called with b.mu
+// held.
+func (b *InMemoryTaskEventBroker) replay(afterID uint64) []Domain.TaskEvent {
+	if afterID == 0 || afterID == b.lastID {
+		return nil
+	}
+	if afterID > b.lastID || len(b.history) == 0 || afterID+1 < b.history[0].ID {
+		return []Domain.TaskEvent{{ID: b.lastID, Type: Domain.TaskEventsReset, Time: time.Now().UTC()}}
+	}
+
+	return append([]Domain.TaskEvent(nil), b.history[afterID+1-b.history[0].ID:]...)
+}
+
+func (b *InMemoryTaskEventBroker) unsubscribe(sub *taskSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.incoming)
+	}
+}
+
+// NewInMemoryTaskEventBroker creates a broker that keeps the last historySize
+// events for resuming subscribers and buffers up to bufferSize undelivered
+// events per subscriber.
+func NewInMemoryTaskEventBroker(historySize, bufferSize int) Domain.TaskEventBroker {
+	return &InMemoryTaskEventBroker{
+		historySize: historySize,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*taskSubscriber]struct{}),
+	}
+}
@@ -0,0 +1,95 @@
+package Infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task_manager/Domain"
+	"task_manager/Infrastructure"
+)
+
+func TestPublishDoesNotWaitForFilters(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broker := Infrastructure.NewInMemoryTaskEventBroker(10, 10)
+
+	// The first subscriber's filter is stuck, like one waiting on a slow
+	// database.
+	stuck := make(chan struct{})
+	defer close(stuck)
+	if _, err := broker.Subscribe(ctx, 0, func(Domain.TaskEvent) bool {
+		<-stuck
+		return true
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	events, err := broker.Subscribe(ctx, 0, func(event Domain.TaskEvent) bool {
+		return event.Type != Domain.TaskDeleted
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	published := make(chan error)
+	go func() {
+		for _, eventType := range []Domain.TaskEventType{Domain.TaskCreated, Domain.TaskDeleted, Domain.TaskUpdated} {
+			if err := broker.Publish(ctx, Domain.TaskEvent{Type: eventType}); err != nil {
+				published <- err
+				return
+			}
+		}
+		published <- nil
+	}()
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish waited for a filter")
+	}
+
+	// The other subscriber gets the events its filter accepts.
+	for _, want := range []Domain.TaskEventType{Domain.TaskCreated, Domain.TaskUpdated} {
+		select {
+		case event := <-events:
+			if event.Type != want {
+				t.Errorf("got %s event, want %s", event.Type, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s event", want)
+		}
+	}
+}
+
+func TestSubscribeReplaysFilteredEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	broker := Infrastructure.NewInMemoryTaskEventBroker(10, 10)
+	for _, eventType := range []Domain.TaskEventType{Domain.TaskCreated, Domain.TaskDeleted, Domain.TaskUpdated} {
+		if err := broker.Publish(ctx, Domain.TaskEvent{Type: eventType}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	events, err := broker.Subscribe(ctx, 1, func(event Domain.TaskEvent) bool {
+		return event.Type != Domain.TaskDeleted
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if event := <-events; event.ID != 3 || event.Type != Domain.TaskUpdated {
+		t.Errorf("replayed event %d %s, want 3 %s", event.ID, event.Type, Domain.TaskUpdated)
+	}
+
+	// The channel is closed once the context is done.
+	cancel()
+	select {
+	case event, ok := <-events:
+		if ok {
+			t.Errorf("got event %d after the context was done", event.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel was not closed")
+	}
+}
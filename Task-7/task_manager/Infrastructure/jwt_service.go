@@ -2,56 +2,149 @@
 package Infrastructure
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"task_manager/Domain"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+// Token types carried in the "typ" claim.
+const (
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
+)
+
+// Claims are the JWT claims issued by JWTService. The standard "jti" claim
+// identifies a single token, while Family is shared by every token issued
+// from the same login so that a whole session can be revoked at once.
+type Claims struct {
+	UserID   string `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Family   string `json:"fam"`
+	Type     string `json:"typ"`
+	jwt.StandardClaims
+}
+
+// TokenPair is a short-lived access token and the refresh token that renews it.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
 //JWTService defines methods fro JWT operations
 type JWTService interface {
-	GenerateToken(id, username, role string) (string, error)
-	ValidateToken(tokenString string) (jwt.MapClaims, error)
+	// GenerateTokenPair issues a new access/refresh token pair. An empty
+	// family starts a new token family.
+	GenerateTokenPair(id, username, role, family string) (TokenPair, error)
+	ValidateToken(tokenString string) (*Claims, error)
+	ValidateRefreshToken(tokenString string) (*Claims, error)
+	RefreshTokenTTL() time.Duration
 }
 
 //jwtService implements JWTService
 type jwtService struct {
-	secret string
+	secret     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
-// GenerateToken implements JWTService.
-func (j *jwtService) GenerateToken(id string, username string, role string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id": id,
-		"username": username,
-		"role": role,
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
+// GenerateTokenPair implements JWTService.
+func (j *jwtService) GenerateTokenPair(id string, username string, role string, family string) (TokenPair, error) {
+	if family == "" {
+		family = NewTokenID()
+	}
+
+	accessToken, err := j.sign(id, username, role, family, AccessTokenType, j.accessTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	refreshToken, err := j.sign(id, username, role, family, RefreshTokenType, j.refreshTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(j.accessTTL.Seconds()),
+	}, nil
+}
+
+// sign creates a signed token of the given type with a fresh jti.
+func (j *jwtService) sign(id, username, role, family, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		UserID:   id,
+		Username: username,
+		Role:     role,
+		Family:   family,
+		Type:     tokenType,
+		StandardClaims: jwt.StandardClaims{
+			Id:        NewTokenID(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		},
 	})
 
 	return token.SignedString([]byte(j.secret))
 }
 
-// ValidateToken implements JWTService.
-func (j *jwtService) ValidateToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+// ValidateToken implements JWTService. It only accepts access tokens.
+func (j *jwtService) ValidateToken(tokenString string) (*Claims, error) {
+	return j.validate(tokenString, AccessTokenType)
+}
+
+// ValidateRefreshToken implements JWTService. It only accepts refresh tokens.
+func (j *jwtService) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	return j.validate(tokenString, RefreshTokenType)
+}
+
+// validate parses tokenString and checks its signature, expiry and type.
+func (j *jwtService) validate(tokenString, tokenType string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing methos")
+			return nil, fmt.Errorf("unexpected signing method")
 		}
 		return []byte(j.secret), nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
+		return nil, fmt.Errorf("%w: invalid token: %v", Domain.ErrUnauthorized, err)
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || token.Valid {
-		return nil, fmt.Errorf("invalid token claims")
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, Domain.NewError(Domain.ErrUnauthorized, "invalid token claims")
+	}
+	if claims.Type != tokenType || claims.Id == "" || claims.Family == "" {
+		return nil, Domain.NewError(Domain.ErrUnauthorized, "invalid token: expected "+tokenType+" token")
 	}
 
 	return claims, nil
 }
 
-// NewJWTService creates a new JWTService
-func NewJWTService(secret string) JWTService {
-	return &jwtService{secret: secret}
+// RefreshTokenTTL implements JWTService.
+func (j *jwtService) RefreshTokenTTL() time.Duration {
+	return j.refreshTTL
+}
+
+// NewTokenID returns a random identifier suitable for the jti claim.
+func NewTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Errorf("failed to generate token ID: %w", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// NewJWTService creates a new JWTService issuing access and refresh tokens
+// with the given lifetimes.
+func NewJWTService(secret string, accessTTL, refreshTTL time.Duration) JWTService {
+	return &jwtService{secret: secret, accessTTL: accessTTL, refreshTTL: refreshTTL}
 }
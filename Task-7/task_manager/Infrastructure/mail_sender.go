@@ -0,0 +1,61 @@
+package Infrastructure
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"strings"
+	"task_manager/Domain"
+	"time"
+)
+
+// LogMailSender implements Domain.MailSender by writing emails to the
+// server log. It is meant for development only, as the log then contains
+// password reset tokens.
+type LogMailSender struct{}
+
+// Send implements Domain.MailSender.
+func (LogMailSender) Send(ctx context.Context, mail Domain.Mail) error {
+	log.Printf("Mail to %s: %s\n%s", mail.To, mail.Subject, mail.Body)
+	return nil
+}
+
+// NewLogMailSender creates a new LogMailSender
+func NewLogMailSender() Domain.MailSender {
+	return LogMailSender{}
+}
+
+// SMTPMailSender implements Domain.MailSender over SMTP. For local
+// development it can point at an SMTP stand-in such as MailHog.
+type SMTPMailSender struct {
+	config SMTPConfig
+}
+
+// Send implements Domain.MailSender.
+func (s *SMTPMailSender) Send(ctx context.Context, mail Domain.Mail) error {
+	if err := s.config.send(mail.To, mailMessage(s.config.From, mail)); err != nil {
+		return Domain.Internal("failed to send email", err)
+	}
+	return nil
+}
+
+// mailMessage builds the message for a plain-text email.
+func mailMessage(from string, mail Domain.Mail) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(mail.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// NewSMTPMailSender creates a new SMTPMailSender
+func NewSMTPMailSender(config SMTPConfig) Domain.MailSender {
+	return &SMTPMailSender{config: config}
+}
@@ -0,0 +1,143 @@
+package Infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"task_manager/Domain"
+	"time"
+)
+
+// LogNotifier implements Domain.Notifier by writing reminders to the
+// server log. It is meant for development.
+type LogNotifier struct{}
+
+// Notify implements Domain.Notifier.
+func (LogNotifier) Notify(ctx context.Context, reminder Domain.Reminder) error {
+	log.Printf("Reminder for user %s: task %s %q is due at %s",
+		reminder.UserID.Hex(), reminder.Task.ID.Hex(), reminder.Task.Title, reminder.Task.DueDate.UTC().Format(time.RFC3339))
+	return nil
+}
+
+// NewLogNotifier creates a new LogNotifier
+func NewLogNotifier() Domain.Notifier {
+	return LogNotifier{}
+}
+
+// SMTPConfig configures an SMTPNotifier or SMTPMailSender.
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password are used for PLAIN authentication if set.
+	// net/smtp only sends them over TLS or to localhost.
+	Username string
+	Password string
+	From     string
+}
+
+// send delivers message to a single recipient.
+func (c SMTPConfig) send(to string, message []byte) error {
+	var auth smtp.Auth
+	if c.Username != "" {
+		auth = smtp.PlainAuth("", c.Username, c.Password, c.Host)
+	}
+	addr := net.JoinHostPort(c.Host, fmt.Sprint(c.Port))
+	return smtp.SendMail(addr, auth, c.From, []string{to}, message)
+}
+
+// SMTPNotifier implements Domain.Notifier by sending an email to the
+// address in the user's reminder preferences. The connection is upgraded
+// with STARTTLS when the server offers it.
+type SMTPNotifier struct {
+	config SMTPConfig
+}
+
+// Notify implements Domain.Notifier.
+func (n *SMTPNotifier) Notify(ctx context.Context, reminder Domain.Reminder) error {
+	if reminder.Email == "" {
+		return Domain.ErrNoReminderRecipient
+	}
+
+	if err := n.config.send(reminder.Email, reminderMessage(n.config.From, reminder)); err != nil {
+		return Domain.Internal("failed to send reminder email", err)
+	}
+	return nil
+}
+
+// reminderMessage builds the email for a reminder.
+func reminderMessage(from string, reminder Domain.Reminder) []byte {
+	task := reminder.Task
+	subject := mime.QEncoding.Encode("utf-8", "Reminder: "+task.Title+" is due soon")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", reminder.Email)
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "Your task %q is due at %s.\r\n", task.Title, task.DueDate.UTC().Format(time.RFC1123))
+	if task.Description != "" {
+		// smtp.SendMail takes care of dot-stuffing.
+		b.WriteString("\r\n")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(task.Description, "\r\n", "\n"), "\n", "\r\n"))
+		b.WriteString("\r\n")
+	}
+	fmt.Fprintf(&b, "\r\nTask ID: %s\r\n", task.ID.Hex())
+	return []byte(b.String())
+}
+
+// NewSMTPNotifier creates a new SMTPNotifier
+func NewSMTPNotifier(config SMTPConfig) Domain.Notifier {
+	return &SMTPNotifier{config: config}
+}
+
+// WebhookNotifier implements Domain.Notifier by POSTing every reminder as
+// JSON to a fixed URL, e.g. a chat integration. Any status other than 2xx
+// counts as a failed delivery.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// reminderPayload is the JSON body sent by WebhookNotifier.
+type reminderPayload struct {
+	Type string `json:"type"`
+	Domain.Reminder
+}
+
+// Notify implements Domain.Notifier.
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder Domain.Reminder) error {
+	body, err := json.Marshal(reminderPayload{Type: "task.reminder", Reminder: reminder})
+	if err != nil {
+		return Domain.Internal("failed to encode reminder", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return Domain.Internal("failed to create reminder request", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return Domain.Internal("failed to send reminder webhook", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Domain.Internal("failed to send reminder webhook", fmt.Errorf("unexpected status %s", resp.Status))
+	}
+	return nil
+}
+
+// NewWebhookNotifier creates a new WebhookNotifier
+func NewWebhookNotifier(url string, timeout time.Duration) Domain.Notifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
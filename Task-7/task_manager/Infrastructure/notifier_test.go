@@ -0,0 +1,164 @@
+package Infrastructure_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"mime"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"task_manager/Domain"
+	"task_manager/Infrastructure"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// smtpMessage is a message received by the SMTP stand-in.
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPServer runs a minimal SMTP server on localhost that accepts a
+// single message and returns its port and a channel receiving the message.
+func startSMTPServer(t *testing.T) (int, <-chan smtpMessage) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP test")
+
+		var msg smtpMessage
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case verb == "EHLO" || verb == "HELO":
+				reply("250 localhost")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+				msg.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				reply("250 OK")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				reply("250 OK")
+			case verb == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(line, "."))
+				}
+				msg.data = data.String()
+				reply("250 OK")
+			case verb == "QUIT":
+				reply("221 Bye")
+				messages <- msg
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return p, messages
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	port, messages := startSMTPServer(t)
+	notifier := Infrastructure.NewSMTPNotifier(Infrastructure.SMTPConfig{
+		Host: "127.0.0.1",
+		Port: port,
+		From: "tasks@example.com",
+	})
+
+	task := Domain.Task{
+		ID:          primitive.NewObjectID(),
+		Title:       "Write report",
+		Description: "First line\n.starts with a dot",
+		DueDate:     time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+		Status:      Domain.Pending,
+		OwnerID:     primitive.NewObjectID(),
+	}
+	reminder := Domain.Reminder{Task: task, UserID: task.OwnerID, Email: "alice@example.com"}
+	if err := notifier.Notify(context.Background(), reminder); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	var msg smtpMessage
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP server received no message")
+	}
+	if msg.from != "tasks@example.com" {
+		t.Errorf("MAIL FROM = %q, want %q", msg.from, "tasks@example.com")
+	}
+	if len(msg.to) != 1 || msg.to[0] != "alice@example.com" {
+		t.Errorf("RCPT TO = %q, want [alice@example.com]", msg.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(msg.data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("failed to decode subject: %v", err)
+	}
+	if want := "Reminder: Write report is due soon"; subject != want {
+		t.Errorf("Subject = %q, want %q", subject, want)
+	}
+	if got := parsed.Header.Get("To"); got != "alice@example.com" {
+		t.Errorf("To = %q, want %q", got, "alice@example.com")
+	}
+	body := msg.data[strings.Index(msg.data, "\r\n\r\n")+4:]
+	for _, want := range []string{
+		`Your task "Write report" is due at Sun, 01 Mar 2026 09:30:00 UTC.`,
+		"First line\r\n.starts with a dot\r\n",
+		"Task ID: " + task.ID.Hex(),
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body %q does not contain %q", body, want)
+		}
+	}
+}
+
+func TestSMTPNotifierWithoutEmail(t *testing.T) {
+	notifier := Infrastructure.NewSMTPNotifier(Infrastructure.SMTPConfig{Host: "127.0.0.1", Port: 1})
+	err := notifier.Notify(context.Background(), Domain.Reminder{Task: Domain.Task{ID: primitive.NewObjectID()}})
+	if !errors.Is(err, Domain.ErrNoReminderRecipient) {
+		t.Errorf("Notify without email: got %v, want %v", err, Domain.ErrNoReminderRecipient)
+	}
+}
@@ -0,0 +1,73 @@
+package Infrastructure
+
+import (
+	"sync"
+	"task_manager/Domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimiter allows each key a fixed number of requests per time window.
+// It is safe for concurrent use. Its counters live in memory, so every
+// server instance limits on its own.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	swept   time.Time
+}
+
+// rateWindow counts the requests of one key in its current window.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// Allow records a request for key at now and reports whether it is within
+// the limit. If it is not, it also returns how long until the key's window
+// ends.
+func (l *RateLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) >= l.window {
+		for k, w := range l.windows {
+			if now.Sub(w.start) >= l.window {
+				delete(l.windows, k)
+			}
+		}
+		l.swept = now
+	}
+
+	w := l.windows[key]
+	if w == nil || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// NewRateLimiter creates a new RateLimiter
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, windows: make(map[string]*rateWindow)}
+}
+
+// RateLimitMiddleware rejects requests with a Domain.RetryAfterError once
+// the client IP has used up its limit.
+func RateLimitMiddleware(limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := limiter.Allow(c.ClientIP(), time.Now())
+		if !allowed {
+			abortWithError(c, Domain.NewRetryAfterError("too many requests; try again later", retryAfter))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,22 @@
+package Infrastructure
+
+import (
+	"context"
+	"time"
+)
+
+// RunPeriodically calls job once immediately and then every interval until
+// ctx is cancelled. It blocks, so callers usually start it in a goroutine.
+func RunPeriodically(ctx context.Context, interval time.Duration, job func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package Infrastructure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"task_manager/Domain"
+	"time"
+)
+
+// Headers sent with every webhook delivery.
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// SignWebhook returns the signature of a webhook body sent at timestamp
+// (in Unix seconds): "sha256=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription's secret.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the signature of a received webhook and that its
+// timestamp is no further than tolerance from now. Receivers use it to
+// reject forged and replayed deliveries.
+func VerifyWebhook(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) bool {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignWebhook(secret, timestamp, body)))
+}
+
+// HTTPWebhookSender implements Domain.WebhookSender by POSTing the signed
+// payload of a delivery to the subscription's URL.
+type HTTPWebhookSender struct {
+	client *http.Client
+}
+
+// Send implements Domain.WebhookSender.
+func (s *HTTPWebhookSender) Send(ctx context.Context, sub Domain.WebhookSubscription, delivery Domain.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, Domain.Internal("failed to create webhook request", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "task-manager-webhooks")
+	req.Header.Set(WebhookIDHeader, delivery.EventID.Hex())
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(sub.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, Domain.Internal("failed to send webhook", err)
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, Domain.Internal("failed to send webhook", fmt.Errorf("unexpected status %s", resp.Status))
+	}
+	return resp.StatusCode, nil
+}
+
+// NewHTTPWebhookSender creates a new HTTPWebhookSender
+func NewHTTPWebhookSender(timeout time.Duration) Domain.WebhookSender {
+	return &HTTPWebhookSender{client: &http.Client{Timeout: timeout}}
+}
@@ -0,0 +1,123 @@
+package Infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"task_manager/Domain"
+	"task_manager/Infrastructure"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSignWebhook(t *testing.T) {
+	// Expected value computed independently with
+	// printf '1700000000.{"a":1}' | openssl dgst -sha256 -hmac 'a-very-long-secret'
+	got := Infrastructure.SignWebhook("a-very-long-secret", "1700000000", []byte(`{"a":1}`))
+	want := "sha256=20fcb037d0601e1651c9a618b204d03d2af645da38e2f55cee4beafcc2d6fea7"
+	if got != want {
+		t.Errorf("SignWebhook = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	const secret = "a-very-long-secret"
+	body := []byte(`{"type":"task.created"}`)
+	now := time.Unix(1700000000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Infrastructure.SignWebhook(secret, timestamp, body)
+
+	for _, tt := range []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		now       time.Time
+		want      bool
+	}{
+		{"valid", secret, timestamp, signature, body, now, true},
+		{"within tolerance", secret, timestamp, signature, body, now.Add(4 * time.Minute), true},
+		{"replayed", secret, timestamp, signature, body, now.Add(6 * time.Minute), false},
+		{"from the future", secret, timestamp, signature, body, now.Add(-6 * time.Minute), false},
+		{"wrong secret", "another-long-secret", timestamp, signature, body, now, false},
+		{"tampered body", secret, timestamp, signature, []byte(`{"type":"task.deleted"}`), now, false},
+		{"tampered timestamp", secret, strconv.FormatInt(now.Unix()+1, 10), signature, body, now, false},
+		{"malformed timestamp", secret, "yesterday", signature, body, now, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Infrastructure.VerifyWebhook(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute, tt.now); got != tt.want {
+				t.Errorf("VerifyWebhook = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPWebhookSender(t *testing.T) {
+	const secret = "a-very-long-secret"
+	status := http.StatusNoContent
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sender := Infrastructure.NewHTTPWebhookSender(5 * time.Second)
+	sub := Domain.WebhookSubscription{ID: primitive.NewObjectID(), URL: server.URL, Secret: secret}
+	delivery := Domain.WebhookDelivery{
+		ID:        primitive.NewObjectID(),
+		EventID:   primitive.NewObjectID(),
+		EventType: Domain.TaskCreated,
+		Payload:   `{"type":"task.created"}`,
+	}
+
+	before := time.Now()
+	code, err := sender.Send(context.Background(), sub, delivery)
+	if err != nil || code != http.StatusNoContent {
+		t.Fatalf("Send = %d, %v, want %d, nil", code, err, http.StatusNoContent)
+	}
+	if received.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", received.Method)
+	}
+	if string(receivedBody) != delivery.Payload {
+		t.Errorf("body = %q, want %q", receivedBody, delivery.Payload)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":                        "application/json",
+		Infrastructure.WebhookIDHeader:        delivery.EventID.Hex(),
+		Infrastructure.WebhookEventHeader:     string(Domain.TaskCreated),
+		Infrastructure.WebhookSignatureHeader: Infrastructure.SignWebhook(secret, received.Header.Get(Infrastructure.WebhookTimestampHeader), receivedBody),
+	} {
+		if got := received.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	timestamp, err := strconv.ParseInt(received.Header.Get(Infrastructure.WebhookTimestampHeader), 10, 64)
+	if err != nil || timestamp < before.Unix() || timestamp > time.Now().Unix() {
+		t.Errorf("%s = %q, want the time of sending", Infrastructure.WebhookTimestampHeader, received.Header.Get(Infrastructure.WebhookTimestampHeader))
+	}
+	if !Infrastructure.VerifyWebhook(secret, received.Header.Get(Infrastructure.WebhookTimestampHeader), received.Header.Get(Infrastructure.WebhookSignatureHeader), receivedBody, time.Minute, time.Now()) {
+		t.Error("VerifyWebhook rejected the delivery")
+	}
+
+	status = http.StatusServiceUnavailable
+	code, err = sender.Send(context.Background(), sub, delivery)
+	if code != http.StatusServiceUnavailable || !errors.Is(err, Domain.ErrInternal) {
+		t.Errorf("Send to a failing receiver = %d, %v, want %d and an internal error", code, err, http.StatusServiceUnavailable)
+	}
+
+	server.Close()
+	code, err = sender.Send(context.Background(), sub, delivery)
+	if code != 0 || err == nil {
+		t.Errorf("Send to an unreachable receiver = %d, %v, want 0 and an error", code, err)
+	}
+}
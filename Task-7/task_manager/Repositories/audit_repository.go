@@ -0,0 +1,107 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAuditRepository implements Domain.AuditRepository using MongoDB.
+type MongoAuditRepository struct {
+	collection *mongo.Collection
+}
+
+// RecordEntry implements Domain.AuditRepository.
+func (m *MongoAuditRepository) RecordEntry(ctx context.Context, entry Domain.AuditEntry) (Domain.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	entry.ID = primitive.NewObjectID()
+	if _, err := m.collection.InsertOne(ctx, entry); err != nil {
+		return Domain.AuditEntry{}, Domain.Internal("failed to record audit entry", err)
+	}
+	return entry, nil
+}
+
+// GetEntries implements Domain.AuditRepository.
+func (m *MongoAuditRepository) GetEntries(ctx context.Context, query Domain.AuditQuery) (Domain.AuditPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter, err := auditQueryFilter(query)
+	if err != nil {
+		return Domain.AuditPage{}, err
+	}
+	total, err := m.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return Domain.AuditPage{}, Domain.Internal("failed to count audit entries", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit))
+	cursor, err := m.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return Domain.AuditPage{}, Domain.Internal("failed to fetch audit entries", err)
+	}
+
+	defer cursor.Close(ctx)
+	entries := []Domain.AuditEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return Domain.AuditPage{}, Domain.Internal("failed to decode audit entries", err)
+	}
+	return Domain.AuditPage{Entries: entries, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// auditQueryFilter builds the Mongo filter for an audit query.
+func auditQueryFilter(query Domain.AuditQuery) (bson.M, error) {
+	filter := bson.M{}
+	if query.TaskID != "" {
+		taskID, err := parseID("task_id", query.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		filter["task_id"] = taskID
+	}
+	if query.UserID != "" {
+		userID, err := parseID("user_id", query.UserID)
+		if err != nil {
+			return nil, err
+		}
+		filter["actor_id"] = userID
+	}
+	timestamp := bson.M{}
+	if query.From != nil {
+		timestamp["$gte"] = *query.From
+	}
+	if query.To != nil {
+		timestamp["$lt"] = *query.To
+	}
+	if len(timestamp) > 0 {
+		filter["timestamp"] = timestamp
+	}
+	return filter, nil
+}
+
+// NewMongoAuditRepository creates a new MongoAuditRepository
+func NewMongoAuditRepository(client *mongo.Client, dbName, collName string) Domain.AuditRepository {
+	collection := client.Database(dbName).Collection(collName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.M{"timestamp": -1}},
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to create audit indexes: %w", err))
+	}
+	return &MongoAuditRepository{collection: collection}
+}
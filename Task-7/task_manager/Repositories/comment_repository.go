@@ -0,0 +1,152 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoCommentRepository implements Domain.CommentRepository using MongoDB.
+type MongoCommentRepository struct {
+	collection *mongo.Collection
+}
+
+// CreateComment implements Domain.CommentRepository.
+func (m *MongoCommentRepository) CreateComment(ctx context.Context, comment Domain.Comment) (Domain.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	comment.ID = primitive.NewObjectID()
+	if _, err := m.collection.InsertOne(ctx, comment); err != nil {
+		return Domain.Comment{}, Domain.Internal("failed to create comment", err)
+	}
+	return comment, nil
+}
+
+// GetComment implements Domain.CommentRepository.
+func (m *MongoCommentRepository) GetComment(ctx context.Context, id string) (Domain.Comment, error) {
+	objID, err := parseID("comment_id", id)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var comment Domain.Comment
+	err = m.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&comment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.Comment{}, fmt.Errorf("%w: %s", Domain.ErrCommentNotFound, id)
+		}
+		return Domain.Comment{}, Domain.Internal("failed to retrieve comment", err)
+	}
+	return comment, nil
+}
+
+// ListComments implements Domain.CommentRepository.
+func (m *MongoCommentRepository) ListComments(ctx context.Context, query Domain.CommentQuery) (Domain.CommentPage, error) {
+	taskID, err := parseID("task_id", query.TaskID)
+	if err != nil {
+		return Domain.CommentPage{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"task_id": taskID}
+	total, err := m.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return Domain.CommentPage{}, Domain.Internal("failed to count comments", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit))
+	cursor, err := m.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return Domain.CommentPage{}, Domain.Internal("failed to fetch comments", err)
+	}
+
+	defer cursor.Close(ctx)
+	comments := []Domain.Comment{}
+	if err = cursor.All(ctx, &comments); err != nil {
+		return Domain.CommentPage{}, Domain.Internal("failed to decode comments", err)
+	}
+	return Domain.CommentPage{Comments: comments, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// UpdateComment implements Domain.CommentRepository.
+func (m *MongoCommentRepository) UpdateComment(ctx context.Context, comment Domain.Comment) (Domain.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"body": comment.Body, "updated_at": comment.UpdatedAt}}
+	var updated Domain.Comment
+	err := m.collection.FindOneAndUpdate(ctx, bson.M{"_id": comment.ID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.Comment{}, fmt.Errorf("%w: %s", Domain.ErrCommentNotFound, comment.ID.Hex())
+		}
+		return Domain.Comment{}, Domain.Internal("failed to update comment", err)
+	}
+	return updated, nil
+}
+
+// DeleteComment implements Domain.CommentRepository.
+func (m *MongoCommentRepository) DeleteComment(ctx context.Context, id string) error {
+	objID, err := parseID("comment_id", id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := m.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return Domain.Internal("failed to delete comment", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s", Domain.ErrCommentNotFound, id)
+	}
+	return nil
+}
+
+// DeleteTaskComments implements Domain.CommentRepository.
+func (m *MongoCommentRepository) DeleteTaskComments(ctx context.Context, taskIDs ...primitive.ObjectID) (int64, error) {
+	if len(taskIDs) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := m.collection.DeleteMany(ctx, bson.M{"task_id": bson.M{"$in": taskIDs}})
+	if err != nil {
+		return 0, Domain.Internal("failed to delete comments", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// NewMongoCommentRepository creates a new MongoCommentRepository
+func NewMongoCommentRepository(client *mongo.Client, dbName, collName string) Domain.CommentRepository {
+	collection := client.Database(dbName).Collection(collName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to create comment index: %w", err))
+	}
+	return &MongoCommentRepository{collection: collection}
+}
@@ -0,0 +1,103 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLoginAttemptStore implements Domain.LoginAttemptStore using MongoDB.
+// Expired records are removed by a TTL index on expires_at.
+type MongoLoginAttemptStore struct {
+	collection *mongo.Collection
+}
+
+// GetAttempts implements Domain.LoginAttemptStore.
+func (m *MongoLoginAttemptStore) GetAttempts(ctx context.Context, key string, now time.Time) (Domain.LoginAttempts, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// The TTL monitor only runs once a minute, so expired records are filtered out explicitly.
+	var attempts Domain.LoginAttempts
+	err := m.collection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": now}}).Decode(&attempts)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Domain.LoginAttempts{Key: key}, nil
+	}
+	if err != nil {
+		return Domain.LoginAttempts{}, Domain.Internal("failed to get login attempts", err)
+	}
+	return attempts, nil
+}
+
+// ReserveAttempt implements Domain.LoginAttemptStore.
+func (m *MongoLoginAttemptStore) ReserveAttempt(ctx context.Context, key string, seen Domain.LoginAttempts, now, expiresAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if seen.ExpiresAt.IsZero() {
+		// There was no live record: replace an expired one or insert one,
+		// which fails if another attempt has inserted it first.
+		update := bson.M{"$set": bson.M{"failures": 1, "last_failure": now, "expires_at": expiresAt}}
+		_, err := m.collection.UpdateOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$lte": now}}, update, options.Update().SetUpsert(true))
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, Domain.Internal("failed to reserve login attempt", err)
+		}
+		return true, nil
+	}
+
+	filter := bson.M{"_id": key, "failures": seen.Failures, "last_failure": seen.LastFailure, "expires_at": bson.M{"$gt": now}}
+	update := bson.M{"$inc": bson.M{"failures": 1}, "$set": bson.M{"last_failure": now, "expires_at": expiresAt}}
+	result, err := m.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, Domain.Internal("failed to reserve login attempt", err)
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// ReleaseAttempt implements Domain.LoginAttemptStore.
+func (m *MongoLoginAttemptStore) ReleaseAttempt(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := m.collection.UpdateOne(ctx, bson.M{"_id": key, "failures": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"failures": -1}}); err != nil {
+		return Domain.Internal("failed to release login attempt", err)
+	}
+	return nil
+}
+
+// ResetAttempts implements Domain.LoginAttemptStore.
+func (m *MongoLoginAttemptStore) ResetAttempts(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := m.collection.DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return Domain.Internal("failed to reset login attempts", err)
+	}
+	return nil
+}
+
+// NewMongoLoginAttemptStore creates a new MongoLoginAttemptStore
+func NewMongoLoginAttemptStore(client *mongo.Client, dbName, collName string) Domain.LoginAttemptStore {
+	collection := client.Database(dbName).Collection(collName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to create login attempt index: %w", err))
+	}
+
+	return &MongoLoginAttemptStore{collection: collection}
+}
@@ -0,0 +1,81 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"task_manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryAuditRepository implements Domain.AuditRepository in memory.
+// It is safe for concurrent use and intended for tests and demos.
+type InMemoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []Domain.AuditEntry
+}
+
+// RecordEntry implements Domain.AuditRepository.
+func (m *InMemoryAuditRepository) RecordEntry(ctx context.Context, entry Domain.AuditEntry) (Domain.AuditEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.ID = primitive.NewObjectID()
+	m.entries = append(m.entries, entry)
+	return entry, nil
+}
+
+// GetEntries implements Domain.AuditRepository.
+func (m *InMemoryAuditRepository) GetEntries(ctx context.Context, query Domain.AuditQuery) (Domain.AuditPage, error) {
+	var taskID, userID primitive.ObjectID
+	var err error
+	if query.TaskID != "" {
+		if taskID, err = parseID("task_id", query.TaskID); err != nil {
+			return Domain.AuditPage{}, err
+		}
+	}
+	if query.UserID != "" {
+		if userID, err = parseID("user_id", query.UserID); err != nil {
+			return Domain.AuditPage{}, err
+		}
+	}
+
+	m.mu.RLock()
+	matched := []Domain.AuditEntry{}
+	for _, entry := range m.entries {
+		switch {
+		case query.TaskID != "" && entry.TaskID != taskID,
+			query.UserID != "" && entry.ActorID != userID,
+			query.From != nil && entry.Timestamp.Before(*query.From),
+			query.To != nil && !entry.Timestamp.Before(*query.To):
+			continue
+		}
+		matched = append(matched, entry)
+	}
+	m.mu.RUnlock()
+
+	// Newest first, like the MongoDB repository.
+	sort.SliceStable(matched, func(i, j int) bool {
+		if c := matched[i].Timestamp.Compare(matched[j].Timestamp); c != 0 {
+			return c > 0
+		}
+		return strings.Compare(matched[i].ID.Hex(), matched[j].ID.Hex()) > 0
+	})
+
+	page := Domain.AuditPage{Entries: []Domain.AuditEntry{}, Total: int64(len(matched)), Limit: query.Limit, Offset: query.Offset}
+	if query.Offset < len(matched) {
+		end := len(matched)
+		if query.Limit > 0 {
+			end = min(query.Offset+query.Limit, end)
+		}
+		page.Entries = matched[query.Offset:end]
+	}
+	return page, nil
+}
+
+// NewInMemoryAuditRepository creates a new InMemoryAuditRepository
+func NewInMemoryAuditRepository() Domain.AuditRepository {
+	return &InMemoryAuditRepository{}
+}
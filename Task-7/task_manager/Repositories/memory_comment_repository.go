@@ -0,0 +1,133 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"task_manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryCommentRepository implements Domain.CommentRepository in memory.
+// It is safe for concurrent use and intended for tests and demos.
+type InMemoryCommentRepository struct {
+	mu       sync.Mutex
+	comments map[primitive.ObjectID]Domain.Comment
+}
+
+// CreateComment implements Domain.CommentRepository.
+func (m *InMemoryCommentRepository) CreateComment(ctx context.Context, comment Domain.Comment) (Domain.Comment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	comment.ID = primitive.NewObjectID()
+	m.comments[comment.ID] = comment
+	return comment, nil
+}
+
+// GetComment implements Domain.CommentRepository.
+func (m *InMemoryCommentRepository) GetComment(ctx context.Context, id string) (Domain.Comment, error) {
+	objID, err := parseID("comment_id", id)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	comment, exists := m.comments[objID]
+	if !exists {
+		return Domain.Comment{}, fmt.Errorf("%w: %s", Domain.ErrCommentNotFound, id)
+	}
+	return comment, nil
+}
+
+// ListComments implements Domain.CommentRepository.
+func (m *InMemoryCommentRepository) ListComments(ctx context.Context, query Domain.CommentQuery) (Domain.CommentPage, error) {
+	taskID, err := parseID("task_id", query.TaskID)
+	if err != nil {
+		return Domain.CommentPage{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var matched []Domain.Comment
+	for _, comment := range m.comments {
+		if comment.TaskID == taskID {
+			matched = append(matched, comment)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if c := matched[i].CreatedAt.Compare(matched[j].CreatedAt); c != 0 {
+			return c < 0
+		}
+		return strings.Compare(matched[i].ID.Hex(), matched[j].ID.Hex()) < 0
+	})
+
+	page := Domain.CommentPage{Comments: []Domain.Comment{}, Total: int64(len(matched)), Limit: query.Limit, Offset: query.Offset}
+	if query.Offset < len(matched) {
+		end := len(matched)
+		if query.Limit > 0 {
+			end = min(query.Offset+query.Limit, end)
+		}
+		page.Comments = matched[query.Offset:end]
+	}
+	return page, nil
+}
+
+// UpdateComment implements Domain.CommentRepository.
+func (m *InMemoryCommentRepository) UpdateComment(ctx context.Context, comment Domain.Comment) (Domain.Comment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, exists := m.comments[comment.ID]
+	if !exists {
+		return Domain.Comment{}, fmt.Errorf("%w: %s", Domain.ErrCommentNotFound, comment.ID.Hex())
+	}
+	existing.Body = comment.Body
+	existing.UpdatedAt = comment.UpdatedAt
+	m.comments[comment.ID] = existing
+	return existing, nil
+}
+
+// DeleteComment implements Domain.CommentRepository.
+func (m *InMemoryCommentRepository) DeleteComment(ctx context.Context, id string) error {
+	objID, err := parseID("comment_id", id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.comments[objID]; !exists {
+		return fmt.Errorf("%w: %s", Domain.ErrCommentNotFound, id)
+	}
+	delete(m.comments, objID)
+	return nil
+}
+
+// DeleteTaskComments implements Domain.CommentRepository.
+func (m *InMemoryCommentRepository) DeleteTaskComments(ctx context.Context, taskIDs ...primitive.ObjectID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for id, comment := range m.comments {
+		if slices.Contains(taskIDs, comment.TaskID) {
+			delete(m.comments, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// NewInMemoryCommentRepository creates a new InMemoryCommentRepository
+func NewInMemoryCommentRepository() Domain.CommentRepository {
+	return &InMemoryCommentRepository{comments: make(map[primitive.ObjectID]Domain.Comment)}
+}
@@ -0,0 +1,76 @@
+package Repositories
+
+import (
+	"context"
+	"sync"
+	"task_manager/Domain"
+	"time"
+)
+
+// InMemoryLoginAttemptStore implements Domain.LoginAttemptStore in memory.
+// It is safe for concurrent use and intended for tests and demos.
+type InMemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]Domain.LoginAttempts
+}
+
+// GetAttempts implements Domain.LoginAttemptStore.
+func (m *InMemoryLoginAttemptStore) GetAttempts(ctx context.Context, key string, now time.Time) (Domain.LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempts, exists := m.attempts[key]
+	if !exists || !attempts.ExpiresAt.After(now) {
+		return Domain.LoginAttempts{Key: key}, nil
+	}
+	return attempts, nil
+}
+
+// ReserveAttempt implements Domain.LoginAttemptStore.
+func (m *InMemoryLoginAttemptStore) ReserveAttempt(ctx context.Context, key string, seen Domain.LoginAttempts, now, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, a := range m.attempts {
+		if !a.ExpiresAt.After(now) {
+			delete(m.attempts, k)
+		}
+	}
+
+	attempts, exists := m.attempts[key]
+	if exists != !seen.ExpiresAt.IsZero() || attempts.Failures != seen.Failures || !attempts.LastFailure.Equal(seen.LastFailure) {
+		return false, nil
+	}
+	attempts.Key = key
+	attempts.Failures++
+	attempts.LastFailure = now
+	attempts.ExpiresAt = expiresAt
+	m.attempts[key] = attempts
+	return true, nil
+}
+
+// ReleaseAttempt implements Domain.LoginAttemptStore.
+func (m *InMemoryLoginAttemptStore) ReleaseAttempt(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if attempts, exists := m.attempts[key]; exists && attempts.Failures > 0 {
+		attempts.Failures--
+		m.attempts[key] = attempts
+	}
+	return nil
+}
+
+// ResetAttempts implements Domain.LoginAttemptStore.
+func (m *InMemoryLoginAttemptStore) ResetAttempts(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
+
+// NewInMemoryLoginAttemptStore creates a new InMemoryLoginAttemptStore
+func NewInMemoryLoginAttemptStore() Domain.LoginAttemptStore {
+	return &InMemoryLoginAttemptStore{attempts: make(map[string]Domain.LoginAttempts)}
+}
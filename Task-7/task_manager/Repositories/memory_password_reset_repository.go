@@ -0,0 +1,52 @@
+package Repositories
+
+import (
+	"context"
+	"sync"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryPasswordResetRepository implements Domain.PasswordResetRepository
+// in memory. It is safe for concurrent use and intended for tests and demos.
+type InMemoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	resets map[primitive.ObjectID]Domain.PasswordReset
+}
+
+// CreateReset implements Domain.PasswordResetRepository.
+func (m *InMemoryPasswordResetRepository) CreateReset(ctx context.Context, reset Domain.PasswordReset, notBefore time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, exists := m.resets[reset.UserID]; exists && existing.CreatedAt.After(notBefore) {
+		return false, nil
+	}
+	m.resets[reset.UserID] = reset
+	return true, nil
+}
+
+// ConsumeReset implements Domain.PasswordResetRepository.
+func (m *InMemoryPasswordResetRepository) ConsumeReset(ctx context.Context, tokenHash string, now time.Time) (Domain.PasswordReset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for userID, reset := range m.resets {
+		if reset.TokenHash != tokenHash {
+			continue
+		}
+		if !reset.ExpiresAt.After(now) {
+			break
+		}
+		delete(m.resets, userID)
+		return reset, nil
+	}
+	return Domain.PasswordReset{}, Domain.ErrResetTokenNotFound
+}
+
+// NewInMemoryPasswordResetRepository creates a new InMemoryPasswordResetRepository
+func NewInMemoryPasswordResetRepository() Domain.PasswordResetRepository {
+	return &InMemoryPasswordResetRepository{resets: make(map[primitive.ObjectID]Domain.PasswordReset)}
+}
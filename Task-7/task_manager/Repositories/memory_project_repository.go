@@ -0,0 +1,152 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"task_manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryProjectRepository implements Domain.ProjectRepository in memory.
+// It is safe for concurrent use and intended for tests and demos.
+type InMemoryProjectRepository struct {
+	mu       sync.Mutex
+	projects map[primitive.ObjectID]Domain.Project
+}
+
+// CreateProject implements Domain.ProjectRepository.
+func (m *InMemoryProjectRepository) CreateProject(ctx context.Context, project Domain.Project) (Domain.Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project.ID = primitive.NewObjectID()
+	project.Members = slices.Clone(project.Members)
+	if project.Members == nil {
+		project.Members = []primitive.ObjectID{}
+	}
+	m.projects[project.ID] = project
+	return project, nil
+}
+
+// GetProject implements Domain.ProjectRepository.
+func (m *InMemoryProjectRepository) GetProject(ctx context.Context, id string) (Domain.Project, error) {
+	objID, err := parseID("project_id", id)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project, exists := m.projects[objID]
+	if !exists {
+		return Domain.Project{}, fmt.Errorf("%w: %s", Domain.ErrProjectNotFound, id)
+	}
+	return project, nil
+}
+
+// ListProjects implements Domain.ProjectRepository.
+func (m *InMemoryProjectRepository) ListProjects(ctx context.Context, query Domain.ProjectQuery) (Domain.ProjectPage, error) {
+	if query.MemberID != "" {
+		if _, err := parseID("member_id", query.MemberID); err != nil {
+			return Domain.ProjectPage{}, err
+		}
+	}
+
+	m.mu.Lock()
+	var matched []Domain.Project
+	for _, project := range m.projects {
+		if query.MemberID != "" && !project.HasMember(query.MemberID) {
+			continue
+		}
+		if query.Archived != nil && project.Archived != *query.Archived {
+			continue
+		}
+		matched = append(matched, project)
+	}
+	m.mu.Unlock()
+
+	// By name, like the MongoDB sort, which compares bytes.
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Name != matched[j].Name {
+			return matched[i].Name < matched[j].Name
+		}
+		return strings.Compare(matched[i].ID.Hex(), matched[j].ID.Hex()) < 0
+	})
+
+	page := Domain.ProjectPage{Projects: []Domain.Project{}, Total: int64(len(matched)), Limit: query.Limit, Offset: query.Offset}
+	if query.Offset < len(matched) {
+		end := len(matched)
+		if query.Limit > 0 {
+			end = min(query.Offset+query.Limit, end)
+		}
+		page.Projects = matched[query.Offset:end]
+	}
+	return page, nil
+}
+
+// UpdateProject implements Domain.ProjectRepository.
+func (m *InMemoryProjectRepository) UpdateProject(ctx context.Context, project Domain.Project) (Domain.Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, exists := m.projects[project.ID]
+	if !exists {
+		return Domain.Project{}, fmt.Errorf("%w: %s", Domain.ErrProjectNotFound, project.ID.Hex())
+	}
+	existing.Name = project.Name
+	existing.Description = project.Description
+	existing.Members = slices.Clone(project.Members)
+	if existing.Members == nil {
+		existing.Members = []primitive.ObjectID{}
+	}
+	existing.Archived = project.Archived
+	existing.UpdatedAt = project.UpdatedAt
+	m.projects[project.ID] = existing
+	return existing, nil
+}
+
+// DeleteProject implements Domain.ProjectRepository.
+func (m *InMemoryProjectRepository) DeleteProject(ctx context.Context, id string) error {
+	objID, err := parseID("project_id", id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.projects[objID]; !exists {
+		return fmt.Errorf("%w: %s", Domain.ErrProjectNotFound, id)
+	}
+	delete(m.projects, objID)
+	return nil
+}
+
+// MemberProjectIDs implements Domain.ProjectRepository.
+func (m *InMemoryProjectRepository) MemberProjectIDs(ctx context.Context, userID string) ([]primitive.ObjectID, error) {
+	if _, err := parseID("member_id", userID); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := []primitive.ObjectID{}
+	for id, project := range m.projects {
+		if project.HasMember(userID) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// NewInMemoryProjectRepository creates a new InMemoryProjectRepository
+func NewInMemoryProjectRepository() Domain.ProjectRepository {
+	return &InMemoryProjectRepository{projects: make(map[primitive.ObjectID]Domain.Project)}
+}
@@ -0,0 +1,82 @@
+package Repositories
+
+import (
+	"context"
+	"sync"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryReminderRepository implements Domain.ReminderRepository in
+// memory. It is safe for concurrent use and intended for tests and demos.
+type InMemoryReminderRepository struct {
+	mu          sync.Mutex
+	preferences map[primitive.ObjectID]Domain.ReminderPreferences
+	// sent maps reminder keys to the time their record expires.
+	sent map[string]time.Time
+}
+
+// GetPreferences implements Domain.ReminderRepository.
+func (m *InMemoryReminderRepository) GetPreferences(ctx context.Context, userID string) (Domain.ReminderPreferences, error) {
+	objID, err := parseID("user_id", userID)
+	if err != nil {
+		return Domain.ReminderPreferences{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prefs, exists := m.preferences[objID]
+	if !exists {
+		return Domain.ReminderPreferences{}, Domain.ErrReminderPreferencesNotFound
+	}
+	return prefs, nil
+}
+
+// SavePreferences implements Domain.ReminderRepository.
+func (m *InMemoryReminderRepository) SavePreferences(ctx context.Context, prefs Domain.ReminderPreferences) (Domain.ReminderPreferences, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.preferences[prefs.UserID] = prefs
+	return prefs, nil
+}
+
+// MarkReminderSent implements Domain.ReminderRepository.
+func (m *InMemoryReminderRepository) MarkReminderSent(ctx context.Context, taskID primitive.ObjectID, dueDate time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for key, exp := range m.sent {
+		if !exp.After(now) {
+			delete(m.sent, key)
+		}
+	}
+
+	key := reminderKey(taskID, dueDate)
+	if _, exists := m.sent[key]; exists {
+		return false, nil
+	}
+	m.sent[key] = dueDate
+	return true, nil
+}
+
+// UnmarkReminderSent implements Domain.ReminderRepository.
+func (m *InMemoryReminderRepository) UnmarkReminderSent(ctx context.Context, taskID primitive.ObjectID, dueDate time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sent, reminderKey(taskID, dueDate))
+	return nil
+}
+
+// NewInMemoryReminderRepository creates a new InMemoryReminderRepository
+func NewInMemoryReminderRepository() Domain.ReminderRepository {
+	return &InMemoryReminderRepository{
+		preferences: make(map[primitive.ObjectID]Domain.ReminderPreferences),
+		sent:        make(map[string]time.Time),
+	}
+}
@@ -0,0 +1,69 @@
+package Repositories_test
+
+import (
+	"testing"
+
+	"task_manager/Domain"
+	"task_manager/Repositories"
+	"task_manager/Repositories/repotest"
+)
+
+func TestInMemoryTaskRepository(t *testing.T) {
+	repotest.TaskRepository(t, func(t *testing.T) Domain.TaskRepository {
+		return Repositories.NewInMemoryTaskRepository()
+	})
+}
+
+func TestInMemoryUserRepository(t *testing.T) {
+	repotest.UserRepository(t, func(t *testing.T) Domain.UserRepository {
+		return Repositories.NewInMemoryUserRepository()
+	})
+}
+
+func TestInMemoryAuditRepository(t *testing.T) {
+	repotest.AuditRepository(t, func(t *testing.T) Domain.AuditRepository {
+		return Repositories.NewInMemoryAuditRepository()
+	})
+}
+
+func TestInMemoryCommentRepository(t *testing.T) {
+	repotest.CommentRepository(t, func(t *testing.T) Domain.CommentRepository {
+		return Repositories.NewInMemoryCommentRepository()
+	})
+}
+
+func TestInMemoryProjectRepository(t *testing.T) {
+	repotest.ProjectRepository(t, func(t *testing.T) Domain.ProjectRepository {
+		return Repositories.NewInMemoryProjectRepository()
+	})
+}
+
+func TestInMemoryPasswordResetRepository(t *testing.T) {
+	repotest.PasswordResetRepository(t, func(t *testing.T) Domain.PasswordResetRepository {
+		return Repositories.NewInMemoryPasswordResetRepository()
+	})
+}
+
+func TestInMemoryLoginAttemptStore(t *testing.T) {
+	repotest.LoginAttemptStore(t, func(t *testing.T) Domain.LoginAttemptStore {
+		return Repositories.NewInMemoryLoginAttemptStore()
+	})
+}
+
+func TestInMemoryReminderRepository(t *testing.T) {
+	repotest.ReminderRepository(t, func(t *testing.T) Domain.ReminderRepository {
+		return Repositories.NewInMemoryReminderRepository()
+	})
+}
+
+func TestInMemoryWebhookRepository(t *testing.T) {
+	repotest.WebhookRepository(t, func(t *testing.T) Domain.WebhookRepository {
+		return Repositories.NewInMemoryWebhookRepository()
+	})
+}
+
+func TestInMemoryRevocationStore(t *testing.T) {
+	repotest.RevocationStore(t, func(t *testing.T) Domain.RevocationStore {
+		return Repositories.NewInMemoryRevocationStore()
+	})
+}
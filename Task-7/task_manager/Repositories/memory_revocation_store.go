@@ -0,0 +1,53 @@
+package Repositories
+
+import (
+	"context"
+	"sync"
+	"task_manager/Domain"
+	"time"
+)
+
+// InMemoryRevocationStore implements Domain.RevocationStore in memory.
+// It is safe for concurrent use and intended for tests and demos.
+type InMemoryRevocationStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+// Revoke implements Domain.RevocationStore.
+func (m *InMemoryRevocationStore) Revoke(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for key, exp := range m.revoked {
+		if !exp.After(now) {
+			delete(m.revoked, key)
+		}
+	}
+
+	if _, exists := m.revoked[id]; exists {
+		return false, nil
+	}
+	m.revoked[id] = expiresAt
+	return true, nil
+}
+
+// IsRevoked implements Domain.RevocationStore.
+func (m *InMemoryRevocationStore) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		if exp, exists := m.revoked[id]; exists && exp.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// NewInMemoryRevocationStore creates a new InMemoryRevocationStore
+func NewInMemoryRevocationStore() Domain.RevocationStore {
+	return &InMemoryRevocationStore{revoked: make(map[string]time.Time)}
+}
@@ -0,0 +1,529 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryTaskRepository implements Domain.TaskRepository in memory.
+// It is safe for concurrent use and behaves like MongoTaskRepository,
+// which makes it suitable for tests and demos without a database.
+type InMemoryTaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[primitive.ObjectID]Domain.Task
+	// terms is an inverted index from search terms to the tasks containing
+	// them, the in-memory counterpart of the MongoDB text index.
+	terms map[string]map[primitive.ObjectID]struct{}
+}
+
+// CreateTask implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) CreateTask(ctx context.Context, task Domain.Task) (Domain.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if task.SeriesID != nil {
+		for _, stored := range m.tasks {
+			if stored.SeriesID != nil && *stored.SeriesID == *task.SeriesID && stored.Occurrence == task.Occurrence {
+				return Domain.Task{}, Domain.ErrOccurrenceExists
+			}
+		}
+	}
+
+	task.ID = primitive.NewObjectID()
+	task.Version = 1
+	m.store(task)
+	return task, nil
+}
+
+// DeleteTask implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) DeleteTask(ctx context.Context, id string, version int64) error {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.lookup(objID, id, version)
+	if err != nil {
+		return err
+	}
+	deletedAt := time.Now().UTC()
+	stored.DeletedAt = &deletedAt
+	stored.Version++
+	m.store(stored)
+	return nil
+}
+
+// WriteTasks implements Domain.TaskRepository. The whole batch is applied
+// under a single lock, so atomic batches never leave partial results.
+func (m *InMemoryTaskRepository) WriteTasks(ctx context.Context, batch Domain.TaskBatch) ([]Domain.BulkResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	live := make(map[primitive.ObjectID]Domain.Task)
+	for _, objID := range batchTaskIDs(batch) {
+		if stored, exists := m.tasks[objID]; exists && stored.DeletedAt == nil {
+			live[objID] = stored
+		}
+	}
+	plan := planTaskBatch(batch, live, time.Now().UTC())
+	if batch.Atomic && planFailed(plan) {
+		return batchResults(plan, true), nil
+	}
+
+	for _, p := range plan {
+		if p.err == nil {
+			m.store(p.after)
+		}
+	}
+	return batchResults(plan, false), nil
+}
+
+// GetTrashedTaskByID implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) GetTrashedTaskByID(ctx context.Context, id string) (Domain.Task, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.lookupTrashed(objID, id)
+}
+
+// RestoreTask implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) RestoreTask(ctx context.Context, id string) (Domain.Task, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.lookupTrashed(objID, id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	stored.DeletedAt = nil
+	stored.Version++
+	m.store(stored)
+	return stored, nil
+}
+
+// PurgeTask implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) PurgeTask(ctx context.Context, id string) error {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.lookupTrashed(objID, id); err != nil {
+		return err
+	}
+	m.remove(objID)
+	return nil
+}
+
+// PurgeDeletedBefore implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]primitive.ObjectID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged []primitive.ObjectID
+	for objID, task := range m.tasks {
+		if task.DeletedAt != nil && task.DeletedAt.Before(cutoff) {
+			m.remove(objID)
+			purged = append(purged, objID)
+		}
+	}
+	return purged, nil
+}
+
+// store saves task and updates the search index. The caller must hold m.mu.
+func (m *InMemoryTaskRepository) store(task Domain.Task) {
+	m.unindex(task.ID)
+	m.tasks[task.ID] = task
+	for _, term := range taskSearchTerms(task) {
+		if m.terms[term] == nil {
+			m.terms[term] = make(map[primitive.ObjectID]struct{})
+		}
+		m.terms[term][task.ID] = struct{}{}
+	}
+}
+
+// remove deletes a task and its search index entries. The caller must hold m.mu.
+func (m *InMemoryTaskRepository) remove(objID primitive.ObjectID) {
+	m.unindex(objID)
+	delete(m.tasks, objID)
+}
+
+// unindex removes the search index entries of the stored task with the given ID.
+func (m *InMemoryTaskRepository) unindex(objID primitive.ObjectID) {
+	stored, exists := m.tasks[objID]
+	if !exists {
+		return
+	}
+	for _, term := range taskSearchTerms(stored) {
+		delete(m.terms[term], objID)
+		if len(m.terms[term]) == 0 {
+			delete(m.terms, term)
+		}
+	}
+}
+
+// taskSearchTerms returns the search terms of the indexed fields of task.
+func taskSearchTerms(task Domain.Task) []string {
+	return append(Domain.SearchTerms(task.Title), Domain.SearchTerms(task.Description)...)
+}
+
+// ListTags implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) ListTags(ctx context.Context, query Domain.TagQuery) ([]Domain.TagCount, error) {
+	var ownerID primitive.ObjectID
+	if query.VisibleTo != "" {
+		var err error
+		if ownerID, err = parseID("visible_to", query.VisibleTo); err != nil {
+			return nil, err
+		}
+	}
+	if query.ProjectID != "" {
+		if _, err := parseID("project_id", query.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+	filter := Domain.TaskQuery{ProjectID: query.ProjectID}
+
+	m.mu.RLock()
+	counts := make(map[string]int64)
+	for _, task := range m.tasks {
+		if query.VisibleTo != "" && !task.VisibleTo(ownerID, query.VisibleProjects) {
+			continue
+		}
+		if !matchesTaskQuery(task, filter) {
+			continue
+		}
+		for _, tag := range task.Tags {
+			if strings.HasPrefix(tag, query.Prefix) {
+				counts[tag]++
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	// Most used first, then alphabetically, like the MongoDB aggregation.
+	tags := []Domain.TagCount{}
+	for tag, count := range counts {
+		tags = append(tags, Domain.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	if query.Limit > 0 && len(tags) > query.Limit {
+		tags = tags[:query.Limit]
+	}
+	return tags, nil
+}
+
+// SearchTasks implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) SearchTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskSearchPage, error) {
+	var ownerID primitive.ObjectID
+	if query.VisibleTo != "" {
+		var err error
+		if ownerID, err = parseID("visible_to", query.VisibleTo); err != nil {
+			return Domain.TaskSearchPage{}, err
+		}
+	}
+	if query.SeriesID != "" {
+		if _, err := parseID("series_id", query.SeriesID); err != nil {
+			return Domain.TaskSearchPage{}, err
+		}
+	}
+	if query.ParentID != "" {
+		if _, err := parseID("parent_id", query.ParentID); err != nil {
+			return Domain.TaskSearchPage{}, err
+		}
+	}
+	if query.ProjectID != "" {
+		if _, err := parseID("project_id", query.ProjectID); err != nil {
+			return Domain.TaskSearchPage{}, err
+		}
+	}
+	if query.AssigneeID != "" {
+		if _, err := parseID("assignee_id", query.AssigneeID); err != nil {
+			return Domain.TaskSearchPage{}, err
+		}
+	}
+	search := Domain.ParseTextSearch(query.Text)
+
+	m.mu.RLock()
+	candidates := make(map[primitive.ObjectID]struct{})
+	for _, term := range search.Terms {
+		for objID := range m.terms[term] {
+			candidates[objID] = struct{}{}
+		}
+	}
+	matched := []Domain.TaskSearchResult{}
+	for objID := range candidates {
+		task := m.tasks[objID]
+		if query.VisibleTo != "" && !task.VisibleTo(ownerID, query.VisibleProjects) {
+			continue
+		}
+		if matchesTaskQuery(task, query) && search.Matches(task) {
+			matched = append(matched, Domain.TaskSearchResult{Task: task, Score: search.Score(task)})
+		}
+	}
+	m.mu.RUnlock()
+
+	// Most relevant first, like the MongoDB text score sort.
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Score != matched[j].Score {
+			return matched[i].Score > matched[j].Score
+		}
+		return strings.Compare(matched[i].Task.ID.Hex(), matched[j].Task.ID.Hex()) < 0
+	})
+
+	page := Domain.TaskSearchPage{Results: []Domain.TaskSearchResult{}, Total: int64(len(matched)), Limit: query.Limit, Offset: query.Offset}
+	if query.Offset < len(matched) {
+		end := len(matched)
+		if query.Limit > 0 {
+			end = min(query.Offset+query.Limit, end)
+		}
+		page.Results = matched[query.Offset:end]
+	}
+	return page, nil
+}
+
+// lookupTrashed returns a task that is in the trash. The caller must hold m.mu.
+func (m *InMemoryTaskRepository) lookupTrashed(objID primitive.ObjectID, id string) (Domain.Task, error) {
+	stored, exists := m.tasks[objID]
+	if !exists || stored.DeletedAt == nil {
+		return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id)
+	}
+	return stored, nil
+}
+
+// lookup returns the stored live task, checking its version unless version
+// is zero. The caller must hold m.mu.
+func (m *InMemoryTaskRepository) lookup(objID primitive.ObjectID, id string, version int64) (Domain.Task, error) {
+	stored, exists := m.tasks[objID]
+	if !exists || stored.DeletedAt != nil {
+		return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id)
+	}
+	if version != 0 && stored.Version != version {
+		return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, id)
+	}
+	return stored, nil
+}
+
+// GetAllTasks implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) GetAllTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskPage, error) {
+	var ownerID primitive.ObjectID
+	if query.VisibleTo != "" {
+		var err error
+		if ownerID, err = parseID("visible_to", query.VisibleTo); err != nil {
+			return Domain.TaskPage{}, err
+		}
+	}
+	if query.SeriesID != "" {
+		if _, err := parseID("series_id", query.SeriesID); err != nil {
+			return Domain.TaskPage{}, err
+		}
+	}
+	if query.ParentID != "" {
+		if _, err := parseID("parent_id", query.ParentID); err != nil {
+			return Domain.TaskPage{}, err
+		}
+	}
+	if query.ProjectID != "" {
+		if _, err := parseID("project_id", query.ProjectID); err != nil {
+			return Domain.TaskPage{}, err
+		}
+	}
+	if query.AssigneeID != "" {
+		if _, err := parseID("assignee_id", query.AssigneeID); err != nil {
+			return Domain.TaskPage{}, err
+		}
+	}
+
+	m.mu.RLock()
+	matched := []Domain.Task{}
+	for _, task := range m.tasks {
+		if query.VisibleTo != "" && !task.VisibleTo(ownerID, query.VisibleProjects) {
+			continue
+		}
+		if matchesTaskQuery(task, query) {
+			matched = append(matched, task)
+		}
+	}
+	m.mu.RUnlock()
+
+	sortTasks(matched, query)
+
+	page := Domain.TaskPage{Tasks: []Domain.Task{}, Total: int64(len(matched)), Limit: query.Limit, Offset: query.Offset}
+	if query.Offset < len(matched) {
+		end := len(matched)
+		if query.Limit > 0 {
+			end = min(query.Offset+query.Limit, end)
+		}
+		page.Tasks = matched[query.Offset:end]
+	}
+	return page, nil
+}
+
+// matchesTaskQuery reports whether task passes the trash, series, parent, project, tag, assignee, status and due date filters of query.
+func matchesTaskQuery(task Domain.Task, query Domain.TaskQuery) bool {
+	if (task.DeletedAt != nil) != query.Deleted {
+		return false
+	}
+	if query.SeriesID != "" && (task.SeriesID == nil || task.SeriesID.Hex() != query.SeriesID) {
+		return false
+	}
+	if query.ParentID != "" && (task.ParentID == nil || task.ParentID.Hex() != query.ParentID) {
+		return false
+	}
+	if query.ProjectID != "" && (task.ProjectID == nil || task.ProjectID.Hex() != query.ProjectID) {
+		return false
+	}
+	if query.Tag != "" && !slices.Contains(task.Tags, query.Tag) {
+		return false
+	}
+	if query.AssigneeID != "" && !slices.ContainsFunc(task.Assignees, func(id primitive.ObjectID) bool { return id.Hex() == query.AssigneeID }) {
+		return false
+	}
+	if query.Status != "" && task.Status != query.Status {
+		return false
+	}
+	if slices.Contains(query.ExcludeStatuses, task.Status) {
+		return false
+	}
+	if query.DueBefore != nil && !task.DueDate.Before(*query.DueBefore) {
+		return false
+	}
+	if query.DueAfter != nil && !task.DueDate.After(*query.DueAfter) {
+		return false
+	}
+	return true
+}
+
+// sortTasks orders tasks the same way taskQuerySort orders them in MongoDB.
+func sortTasks(tasks []Domain.Task, query Domain.TaskQuery) {
+	compare := func(a, b Domain.Task) int {
+		switch query.SortBy {
+		case Domain.SortByDueDate:
+			if c := a.DueDate.Compare(b.DueDate); c != 0 {
+				return c
+			}
+		case Domain.SortByTitle:
+			if c := strings.Compare(a.Title, b.Title); c != 0 {
+				return c
+			}
+		case Domain.SortByStatus:
+			if c := strings.Compare(string(a.Status), string(b.Status)); c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(a.ID.Hex(), b.ID.Hex())
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if query.SortDesc {
+			return compare(tasks[i], tasks[j]) > 0
+		}
+		return compare(tasks[i], tasks[j]) < 0
+	})
+}
+
+// GetTaskByID implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) GetTaskByID(ctx context.Context, id string) (Domain.Task, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.lookup(objID, id, 0)
+}
+
+// UpdateTask implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) UpdateTask(ctx context.Context, id string, task Domain.Task, version int64) (Domain.Task, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.lookup(objID, id, version)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	stored.Title = task.Title
+	stored.Description = task.Description
+	stored.Status = task.Status
+	stored.DueDate = task.DueDate
+	stored.Recurrence = task.Recurrence
+	stored.SeriesID = task.SeriesID
+	stored.Occurrence = task.Occurrence
+	stored.SeriesTemplate = task.SeriesTemplate
+	stored.ParentID = task.ParentID
+	stored.Checklist = task.Checklist
+	stored.BlockedBy = task.BlockedBy
+	stored.ProjectID = task.ProjectID
+	stored.Tags = task.Tags
+	stored.Assignees = task.Assignees
+	if task.StatusEnteredAt != nil {
+		stored.StatusEnteredAt = task.StatusEnteredAt
+	}
+	stored.Version++
+	m.store(stored)
+	return stored, nil
+}
+
+// PatchTask implements Domain.TaskRepository.
+func (m *InMemoryTaskRepository) PatchTask(ctx context.Context, id string, patch Domain.TaskPatch, version int64) (Domain.Task, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.lookup(objID, id, version)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	stored = patch.Apply(stored)
+	stored.Version++
+	m.store(stored)
+	return stored, nil
+}
+
+// NewInMemoryTaskRepository creates a new InMemoryTaskRepository
+func NewInMemoryTaskRepository() Domain.TaskRepository {
+	return &InMemoryTaskRepository{
+		tasks: make(map[primitive.ObjectID]Domain.Task),
+		terms: make(map[string]map[primitive.ObjectID]struct{}),
+	}
+}
@@ -0,0 +1,169 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryUserRepository implements Domain.UserRepository in memory.
+// It is safe for concurrent use and enforces unique usernames like
+// MongoUserRepository does.
+type InMemoryUserRepository struct {
+	mu         sync.RWMutex
+	users      map[primitive.ObjectID]Domain.User
+	byUsername map[string]primitive.ObjectID
+}
+
+// CreateUser implements Domain.UserRepository.
+func (m *InMemoryUserRepository) CreateUser(ctx context.Context, user Domain.User) (Domain.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, taken := m.byUsername[user.Username]; taken {
+		return Domain.User{}, Domain.ErrUsernameTaken
+	}
+
+	user.ID = primitive.NewObjectID()
+	m.users[user.ID] = user
+	m.byUsername[user.Username] = user.ID
+
+	user.Password = ""
+	return user, nil
+}
+
+// GetUserByUsername implements Domain.UserRepository.
+func (m *InMemoryUserRepository) GetUserByUsername(ctx context.Context, username string) (Domain.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, exists := m.byUsername[username]
+	if !exists {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	return m.users[id], nil
+}
+
+// GetUserByID implements Domain.UserRepository.
+func (m *InMemoryUserRepository) GetUserByID(ctx context.Context, id string) (Domain.User, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, exists := m.users[objID]
+	if !exists {
+		return Domain.User{}, Domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// ListUsers implements Domain.UserRepository.
+func (m *InMemoryUserRepository) ListUsers(ctx context.Context, query Domain.UserQuery) (Domain.UserPage, error) {
+	search := strings.ToLower(query.Search)
+
+	m.mu.RLock()
+	var matched []Domain.User
+	for _, user := range m.users {
+		if search != "" && !strings.Contains(strings.ToLower(user.Username), search) {
+			continue
+		}
+		if query.Role != "" && user.Role != query.Role {
+			continue
+		}
+		if query.Disabled != nil && user.Disabled != *query.Disabled {
+			continue
+		}
+		user.Password = ""
+		matched = append(matched, user)
+	}
+	m.mu.RUnlock()
+
+	// Usernames are unique, so they order the users completely.
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Username < matched[j].Username
+	})
+
+	page := Domain.UserPage{Users: []Domain.User{}, Total: int64(len(matched)), Limit: query.Limit, Offset: query.Offset}
+	if query.Offset < len(matched) {
+		end := len(matched)
+		if query.Limit > 0 {
+			end = min(query.Offset+query.Limit, end)
+		}
+		page.Users = matched[query.Offset:end]
+	}
+	return page, nil
+}
+
+// UpdateUser implements Domain.UserRepository.
+func (m *InMemoryUserRepository) UpdateUser(ctx context.Context, user Domain.User) (Domain.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, exists := m.users[user.ID]
+	if !exists {
+		return Domain.User{}, fmt.Errorf("%w: %s", Domain.ErrUserNotFound, user.ID.Hex())
+	}
+	existing.Role = user.Role
+	existing.Disabled = user.Disabled
+	m.users[user.ID] = existing
+
+	existing.Password = ""
+	return existing, nil
+}
+
+// SetPassword implements Domain.UserRepository.
+func (m *InMemoryUserRepository) SetPassword(ctx context.Context, id string, hash string, changedAt time.Time) error {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, exists := m.users[objID]
+	if !exists {
+		return fmt.Errorf("%w: %s", Domain.ErrUserNotFound, id)
+	}
+	user.Password = hash
+	user.PasswordChangedAt = &changedAt
+	m.users[objID] = user
+	return nil
+}
+
+// DeleteUser implements Domain.UserRepository.
+func (m *InMemoryUserRepository) DeleteUser(ctx context.Context, id string) error {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, exists := m.users[objID]
+	if !exists {
+		return fmt.Errorf("%w: %s", Domain.ErrUserNotFound, id)
+	}
+	delete(m.users, objID)
+	delete(m.byUsername, user.Username)
+	return nil
+}
+
+// NewInMemoryUserRepository creates a new InMemoryUserRepository
+func NewInMemoryUserRepository() Domain.UserRepository {
+	return &InMemoryUserRepository{
+		users:      make(map[primitive.ObjectID]Domain.User),
+		byUsername: make(map[string]primitive.ObjectID),
+	}
+}
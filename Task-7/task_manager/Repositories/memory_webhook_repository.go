@@ -0,0 +1,198 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InMemoryWebhookRepository implements Domain.WebhookRepository in memory.
+// It is safe for concurrent use and intended for tests and demos.
+type InMemoryWebhookRepository struct {
+	mu            sync.Mutex
+	subscriptions []Domain.WebhookSubscription
+	deliveries    map[primitive.ObjectID]Domain.WebhookDelivery
+}
+
+// CreateSubscription implements Domain.WebhookRepository.
+func (m *InMemoryWebhookRepository) CreateSubscription(ctx context.Context, sub Domain.WebhookSubscription) (Domain.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub.ID = primitive.NewObjectID()
+	sub.Events = append([]Domain.TaskEventType(nil), sub.Events...)
+	m.subscriptions = append(m.subscriptions, sub)
+	return sub, nil
+}
+
+// GetSubscription implements Domain.WebhookRepository.
+func (m *InMemoryWebhookRepository) GetSubscription(ctx context.Context, id string) (Domain.WebhookSubscription, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.WebhookSubscription{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, sub := range m.subscriptions {
+		if sub.ID == objID {
+			return sub, nil
+		}
+	}
+	return Domain.WebhookSubscription{}, fmt.Errorf("%w: %s", Domain.ErrWebhookNotFound, id)
+}
+
+// ListSubscriptions implements Domain.WebhookRepository.
+func (m *InMemoryWebhookRepository) ListSubscriptions(ctx context.Context) ([]Domain.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Domain.WebhookSubscription{}, m.subscriptions...), nil
+}
+
+// DeleteSubscription implements Domain.WebhookRepository.
+func (m *InMemoryWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, sub := range m.subscriptions {
+		if sub.ID != objID {
+			continue
+		}
+		m.subscriptions = append(m.subscriptions[:i:i], m.subscriptions[i+1:]...)
+		for deliveryID, delivery := range m.deliveries {
+			if delivery.SubscriptionID == objID && delivery.Status == Domain.DeliveryPending {
+				delete(m.deliveries, deliveryID)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", Domain.ErrWebhookNotFound, id)
+}
+
+// CreateDeliveries implements Domain.WebhookRepository.
+func (m *InMemoryWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []Domain.WebhookDelivery) ([]Domain.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range deliveries {
+		deliveries[i].ID = primitive.NewObjectID()
+		m.deliveries[deliveries[i].ID] = deliveries[i]
+	}
+	return deliveries, nil
+}
+
+// GetDelivery implements Domain.WebhookRepository.
+func (m *InMemoryWebhookRepository) GetDelivery(ctx context.Context, id string) (Domain.WebhookDelivery, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.WebhookDelivery{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delivery, exists := m.deliveries[objID]
+	if !exists {
+		return Domain.WebhookDelivery{}, fmt.Errorf("%w: %s", Domain.ErrDeliveryNotFound, id)
+	}
+	return delivery, nil
+}
+
+// ListDeliveries implements Domain.WebhookRepository.
+func (m *InMemoryWebhookRepository) ListDeliveries(ctx context.Context, query Domain.WebhookDeliveryQuery) (Domain.WebhookDeliveryPage, error) {
+	var subID primitive.ObjectID
+	if query.SubscriptionID != "" {
+		var err error
+		if subID, err = parseID("subscription_id", query.SubscriptionID); err != nil {
+			return Domain.WebhookDeliveryPage{}, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var matched []Domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if query.SubscriptionID != "" && delivery.SubscriptionID != subID {
+			continue
+		}
+		if query.Status != "" && delivery.Status != query.Status {
+			continue
+		}
+		matched = append(matched, delivery)
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if c := matched[i].CreatedAt.Compare(matched[j].CreatedAt); c != 0 {
+			return c > 0
+		}
+		return strings.Compare(matched[i].ID.Hex(), matched[j].ID.Hex()) > 0
+	})
+
+	page := Domain.WebhookDeliveryPage{Deliveries: []Domain.WebhookDelivery{}, Total: int64(len(matched)), Limit: query.Limit, Offset: query.Offset}
+	if query.Offset < len(matched) {
+		end := len(matched)
+		if query.Limit > 0 {
+			end = min(query.Offset+query.Limit, end)
+		}
+		page.Deliveries = matched[query.Offset:end]
+	}
+	return page, nil
+}
+
+// ClaimDueDeliveries implements Domain.WebhookRepository.
+func (m *InMemoryWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Domain.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []Domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.Status == Domain.DeliveryPending && delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		if c := due[i].NextAttemptAt.Compare(*due[j].NextAttemptAt); c != 0 {
+			return c < 0
+		}
+		return strings.Compare(due[i].ID.Hex(), due[j].ID.Hex()) < 0
+	})
+
+	claimed := []Domain.WebhookDelivery{}
+	for _, delivery := range due[:min(limit, len(due))] {
+		next := now.Add(lease)
+		delivery.NextAttemptAt = &next
+		m.deliveries[delivery.ID] = delivery
+		claimed = append(claimed, delivery)
+	}
+	return claimed, nil
+}
+
+// UpdateDelivery implements Domain.WebhookRepository.
+func (m *InMemoryWebhookRepository) UpdateDelivery(ctx context.Context, delivery Domain.WebhookDelivery) (Domain.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.deliveries[delivery.ID]; !exists {
+		return Domain.WebhookDelivery{}, fmt.Errorf("%w: %s", Domain.ErrDeliveryNotFound, delivery.ID.Hex())
+	}
+	m.deliveries[delivery.ID] = delivery
+	return delivery, nil
+}
+
+// NewInMemoryWebhookRepository creates a new InMemoryWebhookRepository
+func NewInMemoryWebhookRepository() Domain.WebhookRepository {
+	return &InMemoryWebhookRepository{deliveries: make(map[primitive.ObjectID]Domain.WebhookDelivery)}
+}
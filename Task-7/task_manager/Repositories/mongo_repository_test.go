@@ -0,0 +1,84 @@
+package Repositories_test
+
+import (
+	"testing"
+
+	"task_manager/Domain"
+	"task_manager/Repositories"
+	"task_manager/Repositories/repotest"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The Mongo tests run against the server at MONGODB_TEST_URI and are
+// skipped when none is reachable. Every repository gets fresh collections.
+
+func TestMongoTaskRepository(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.TaskRepository(t, func(t *testing.T) Domain.TaskRepository {
+		return Repositories.NewMongoTaskRepository(client, dbName, primitive.NewObjectID().Hex())
+	})
+}
+
+func TestMongoUserRepository(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.UserRepository(t, func(t *testing.T) Domain.UserRepository {
+		return Repositories.NewMongoUserRepository(client, dbName, primitive.NewObjectID().Hex())
+	})
+}
+
+func TestMongoAuditRepository(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.AuditRepository(t, func(t *testing.T) Domain.AuditRepository {
+		return Repositories.NewMongoAuditRepository(client, dbName, primitive.NewObjectID().Hex())
+	})
+}
+
+func TestMongoCommentRepository(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.CommentRepository(t, func(t *testing.T) Domain.CommentRepository {
+		return Repositories.NewMongoCommentRepository(client, dbName, primitive.NewObjectID().Hex())
+	})
+}
+
+func TestMongoProjectRepository(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.ProjectRepository(t, func(t *testing.T) Domain.ProjectRepository {
+		return Repositories.NewMongoProjectRepository(client, dbName, primitive.NewObjectID().Hex())
+	})
+}
+
+func TestMongoPasswordResetRepository(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.PasswordResetRepository(t, func(t *testing.T) Domain.PasswordResetRepository {
+		return Repositories.NewMongoPasswordResetRepository(client, dbName, primitive.NewObjectID().Hex())
+	})
+}
+
+func TestMongoLoginAttemptStore(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.LoginAttemptStore(t, func(t *testing.T) Domain.LoginAttemptStore {
+		return Repositories.NewMongoLoginAttemptStore(client, dbName, primitive.NewObjectID().Hex())
+	})
+}
+
+func TestMongoReminderRepository(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.ReminderRepository(t, func(t *testing.T) Domain.ReminderRepository {
+		return Repositories.NewMongoReminderRepository(client, dbName, primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+	})
+}
+
+func TestMongoWebhookRepository(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.WebhookRepository(t, func(t *testing.T) Domain.WebhookRepository {
+		return Repositories.NewMongoWebhookRepository(client, dbName, primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+	})
+}
+
+func TestMongoRevocationStore(t *testing.T) {
+	client, dbName := repotest.MongoDatabase(t)
+	repotest.RevocationStore(t, func(t *testing.T) Domain.RevocationStore {
+		return Repositories.NewMongoRevocationStore(client, dbName, primitive.NewObjectID().Hex())
+	})
+}
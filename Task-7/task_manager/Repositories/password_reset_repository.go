@@ -0,0 +1,73 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoPasswordResetRepository implements Domain.PasswordResetRepository
+// using MongoDB. A unique index on user_id keeps one reset per user, and
+// expired resets are removed by a TTL index on expires_at.
+type MongoPasswordResetRepository struct {
+	collection *mongo.Collection
+}
+
+// CreateReset implements Domain.PasswordResetRepository.
+func (m *MongoPasswordResetRepository) CreateReset(ctx context.Context, reset Domain.PasswordReset, notBefore time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// If the user has a newer reset the filter does not match it, and the
+	// upsert then collides with it on the user_id index.
+	filter := bson.M{"user_id": reset.UserID, "created_at": bson.M{"$lte": notBefore}}
+	_, err := m.collection.ReplaceOne(ctx, filter, reset, options.Replace().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, Domain.Internal("failed to create password reset", err)
+	}
+	return true, nil
+}
+
+// ConsumeReset implements Domain.PasswordResetRepository.
+func (m *MongoPasswordResetRepository) ConsumeReset(ctx context.Context, tokenHash string, now time.Time) (Domain.PasswordReset, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// The TTL monitor only runs once a minute, so expired resets are filtered out explicitly.
+	filter := bson.M{"token_hash": tokenHash, "expires_at": bson.M{"$gt": now}}
+	var reset Domain.PasswordReset
+	if err := m.collection.FindOneAndDelete(ctx, filter).Decode(&reset); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.PasswordReset{}, Domain.ErrResetTokenNotFound
+		}
+		return Domain.PasswordReset{}, Domain.Internal("failed to consume password reset", err)
+	}
+	return reset, nil
+}
+
+// NewMongoPasswordResetRepository creates a new MongoPasswordResetRepository
+func NewMongoPasswordResetRepository(client *mongo.Client, dbName, collName string) Domain.PasswordResetRepository {
+	collection := client.Database(dbName).Collection(collName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"user_id": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"token_hash": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"expires_at": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to create password reset index: %w", err))
+	}
+
+	return &MongoPasswordResetRepository{collection: collection}
+}
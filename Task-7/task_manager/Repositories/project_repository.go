@@ -0,0 +1,186 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoProjectRepository implements Domain.ProjectRepository using MongoDB.
+type MongoProjectRepository struct {
+	collection *mongo.Collection
+}
+
+// CreateProject implements Domain.ProjectRepository.
+func (m *MongoProjectRepository) CreateProject(ctx context.Context, project Domain.Project) (Domain.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	project.ID = primitive.NewObjectID()
+	if project.Members == nil {
+		project.Members = []primitive.ObjectID{}
+	}
+	if _, err := m.collection.InsertOne(ctx, project); err != nil {
+		return Domain.Project{}, Domain.Internal("failed to create project", err)
+	}
+	return project, nil
+}
+
+// GetProject implements Domain.ProjectRepository.
+func (m *MongoProjectRepository) GetProject(ctx context.Context, id string) (Domain.Project, error) {
+	objID, err := parseID("project_id", id)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var project Domain.Project
+	err = m.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&project)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.Project{}, fmt.Errorf("%w: %s", Domain.ErrProjectNotFound, id)
+		}
+		return Domain.Project{}, Domain.Internal("failed to retrieve project", err)
+	}
+	return project, nil
+}
+
+// ListProjects implements Domain.ProjectRepository.
+func (m *MongoProjectRepository) ListProjects(ctx context.Context, query Domain.ProjectQuery) (Domain.ProjectPage, error) {
+	filter := bson.M{}
+	if query.MemberID != "" {
+		memberID, err := parseID("member_id", query.MemberID)
+		if err != nil {
+			return Domain.ProjectPage{}, err
+		}
+		filter["$or"] = bson.A{bson.M{"owner_id": memberID}, bson.M{"members": memberID}}
+	}
+	if query.Archived != nil {
+		filter["archived"] = *query.Archived
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	total, err := m.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return Domain.ProjectPage{}, Domain.Internal("failed to count projects", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit))
+	cursor, err := m.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return Domain.ProjectPage{}, Domain.Internal("failed to fetch projects", err)
+	}
+
+	defer cursor.Close(ctx)
+	projects := []Domain.Project{}
+	if err = cursor.All(ctx, &projects); err != nil {
+		return Domain.ProjectPage{}, Domain.Internal("failed to decode projects", err)
+	}
+	return Domain.ProjectPage{Projects: projects, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// UpdateProject implements Domain.ProjectRepository.
+func (m *MongoProjectRepository) UpdateProject(ctx context.Context, project Domain.Project) (Domain.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if project.Members == nil {
+		project.Members = []primitive.ObjectID{}
+	}
+	update := bson.M{"$set": bson.M{
+		"name":        project.Name,
+		"description": project.Description,
+		"members":     project.Members,
+		"archived":    project.Archived,
+		"updated_at":  project.UpdatedAt,
+	}}
+	var updated Domain.Project
+	err := m.collection.FindOneAndUpdate(ctx, bson.M{"_id": project.ID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.Project{}, fmt.Errorf("%w: %s", Domain.ErrProjectNotFound, project.ID.Hex())
+		}
+		return Domain.Project{}, Domain.Internal("failed to update project", err)
+	}
+	return updated, nil
+}
+
+// DeleteProject implements Domain.ProjectRepository.
+func (m *MongoProjectRepository) DeleteProject(ctx context.Context, id string) error {
+	objID, err := parseID("project_id", id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := m.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return Domain.Internal("failed to delete project", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s", Domain.ErrProjectNotFound, id)
+	}
+	return nil
+}
+
+// MemberProjectIDs implements Domain.ProjectRepository.
+func (m *MongoProjectRepository) MemberProjectIDs(ctx context.Context, userID string) ([]primitive.ObjectID, error) {
+	memberID, err := parseID("member_id", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"$or": bson.A{bson.M{"owner_id": memberID}, bson.M{"members": memberID}}}
+	cursor, err := m.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, Domain.Internal("failed to fetch projects", err)
+	}
+
+	defer cursor.Close(ctx)
+	var projects []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, Domain.Internal("failed to decode projects", err)
+	}
+	ids := make([]primitive.ObjectID, len(projects))
+	for i, project := range projects {
+		ids[i] = project.ID
+	}
+	return ids, nil
+}
+
+// NewMongoProjectRepository creates a new MongoProjectRepository
+func NewMongoProjectRepository(client *mongo.Client, dbName, collName string) Domain.ProjectRepository {
+	collection := client.Database(dbName).Collection(collName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"owner_id": 1}},
+		{Keys: bson.M{"members": 1}},
+		{Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to create project indexes: %w", err))
+	}
+	return &MongoProjectRepository{collection: collection}
+}
@@ -0,0 +1,119 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sentReminder is the stored record of a reminder that has been sent.
+type sentReminder struct {
+	ID        string             `bson:"_id"`
+	TaskID    primitive.ObjectID `bson:"task_id"`
+	DueDate   time.Time          `bson:"due_date"`
+	SentAt    time.Time          `bson:"sent_at"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+}
+
+// reminderKey identifies the reminder for a task due at dueDate.
+func reminderKey(taskID primitive.ObjectID, dueDate time.Time) string {
+	return taskID.Hex() + "@" + dueDate.UTC().Format(time.RFC3339Nano)
+}
+
+// MongoReminderRepository implements Domain.ReminderRepository using
+// MongoDB. Sent reminders are removed by a TTL index once their task is due.
+type MongoReminderRepository struct {
+	preferences *mongo.Collection
+	sent        *mongo.Collection
+}
+
+// GetPreferences implements Domain.ReminderRepository.
+func (m *MongoReminderRepository) GetPreferences(ctx context.Context, userID string) (Domain.ReminderPreferences, error) {
+	objID, err := parseID("user_id", userID)
+	if err != nil {
+		return Domain.ReminderPreferences{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var prefs Domain.ReminderPreferences
+	err = m.preferences.FindOne(ctx, bson.M{"_id": objID}).Decode(&prefs)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.ReminderPreferences{}, Domain.ErrReminderPreferencesNotFound
+		}
+		return Domain.ReminderPreferences{}, Domain.Internal("failed to retrieve reminder preferences", err)
+	}
+	return prefs, nil
+}
+
+// SavePreferences implements Domain.ReminderRepository.
+func (m *MongoReminderRepository) SavePreferences(ctx context.Context, prefs Domain.ReminderPreferences) (Domain.ReminderPreferences, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := m.preferences.ReplaceOne(ctx, bson.M{"_id": prefs.UserID}, prefs, options.Replace().SetUpsert(true))
+	if err != nil {
+		return Domain.ReminderPreferences{}, Domain.Internal("failed to save reminder preferences", err)
+	}
+	return prefs, nil
+}
+
+// MarkReminderSent implements Domain.ReminderRepository.
+func (m *MongoReminderRepository) MarkReminderSent(ctx context.Context, taskID primitive.ObjectID, dueDate time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := m.sent.InsertOne(ctx, sentReminder{
+		ID:        reminderKey(taskID, dueDate),
+		TaskID:    taskID,
+		DueDate:   dueDate,
+		SentAt:    time.Now().UTC(),
+		ExpiresAt: dueDate,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, Domain.Internal("failed to record reminder", err)
+	}
+	return true, nil
+}
+
+// UnmarkReminderSent implements Domain.ReminderRepository.
+func (m *MongoReminderRepository) UnmarkReminderSent(ctx context.Context, taskID primitive.ObjectID, dueDate time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := m.sent.DeleteOne(ctx, bson.M{"_id": reminderKey(taskID, dueDate)}); err != nil {
+		return Domain.Internal("failed to remove reminder record", err)
+	}
+	return nil
+}
+
+// NewMongoReminderRepository creates a new MongoReminderRepository that
+// keeps preferences in prefsCollName and sent reminders in sentCollName.
+func NewMongoReminderRepository(client *mongo.Client, dbName, prefsCollName, sentCollName string) Domain.ReminderRepository {
+	db := client.Database(dbName)
+	sent := db.Collection(sentCollName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := sent.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to create reminder index: %w", err))
+	}
+
+	return &MongoReminderRepository{preferences: db.Collection(prefsCollName), sent: sent}
+}
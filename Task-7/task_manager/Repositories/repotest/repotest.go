@@ -0,0 +1,1796 @@
+// Package repotest provides a conformance suite that every implementation of
+// the Domain repository interfaces must pass. Implementations call the suite
+// from their own tests:
+//
+//	func TestInMemoryTaskRepository(t *testing.T) {
+//		repotest.TaskRepository(t, func(t *testing.T) Domain.TaskRepository {
+//			return Repositories.NewInMemoryTaskRepository()
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"task_manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TaskRepository runs the task repository conformance tests. newRepo must
+// return a new, empty repository on every call.
+func TaskRepository(t *testing.T, newRepo func(t *testing.T) Domain.TaskRepository) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		ownerID := primitive.NewObjectID()
+
+		created, err := repo.CreateTask(ctx, newTask("write report", 24*time.Hour, ownerID))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if created.ID.IsZero() {
+			t.Fatal("CreateTask did not assign an ID")
+		}
+
+		got, err := repo.GetTaskByID(ctx, created.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetTaskByID: %v", err)
+		}
+		if got.ID != created.ID || got.Title != created.Title || got.OwnerID != ownerID || !got.DueDate.Equal(created.DueDate) {
+			t.Errorf("GetTaskByID = %+v, want %+v", got, created)
+		}
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		_, err := newRepo(t).GetTaskByID(context.Background(), primitive.NewObjectID().Hex())
+		if !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("GetTaskByID of missing task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		if _, err := repo.GetTaskByID(ctx, "not-an-id"); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetTaskByID with invalid ID: got %v, want %v", err, Domain.ErrValidation)
+		}
+		if _, err := repo.UpdateTask(ctx, "not-an-id", newTask("x", time.Hour, primitive.NewObjectID()), 0); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("UpdateTask with invalid ID: got %v, want %v", err, Domain.ErrValidation)
+		}
+		if err := repo.DeleteTask(ctx, "not-an-id", 0); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("DeleteTask with invalid ID: got %v, want %v", err, Domain.ErrValidation)
+		}
+	})
+
+	t.Run("UpdateMissing", func(t *testing.T) {
+		_, err := newRepo(t).UpdateTask(context.Background(), primitive.NewObjectID().Hex(), newTask("x", time.Hour, primitive.NewObjectID()), 0)
+		if !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("UpdateTask of missing task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		created, err := repo.CreateTask(ctx, newTask("draft", time.Hour, primitive.NewObjectID()))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+
+		change := created
+		change.Title = "final"
+		change.Status = Domain.Completed
+		if _, err := repo.UpdateTask(ctx, created.ID.Hex(), change, 0); err != nil {
+			t.Fatalf("UpdateTask: %v", err)
+		}
+
+		got, err := repo.GetTaskByID(ctx, created.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetTaskByID: %v", err)
+		}
+		if got.Title != "final" || got.Status != Domain.Completed || got.OwnerID != created.OwnerID {
+			t.Errorf("task after update = %+v", got)
+		}
+	})
+
+	t.Run("PatchMissing", func(t *testing.T) {
+		title := "x"
+		_, err := newRepo(t).PatchTask(context.Background(), primitive.NewObjectID().Hex(), Domain.TaskPatch{Title: &title}, 0)
+		if !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("PatchTask of missing task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		created, err := repo.CreateTask(ctx, newTask("draft", time.Hour, primitive.NewObjectID()))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+
+		status := Domain.Completed
+		patched, err := repo.PatchTask(ctx, created.ID.Hex(), Domain.TaskPatch{Status: &status}, 0)
+		if err != nil {
+			t.Fatalf("PatchTask: %v", err)
+		}
+		if patched.Status != Domain.Completed || patched.Title != "draft" || patched.Description != created.Description || !patched.DueDate.Equal(created.DueDate) {
+			t.Errorf("PatchTask = %+v, want only the status changed", patched)
+		}
+	})
+
+	t.Run("StatusEnteredAt", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		start := time.Now().UTC().Truncate(time.Millisecond)
+		task := newTask("review", time.Hour, primitive.NewObjectID())
+		task.EnterStatus(start)
+		created, err := repo.CreateTask(ctx, task)
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+
+		status, later := Domain.Completed, start.Add(time.Minute)
+		patched, err := repo.PatchTask(ctx, created.ID.Hex(), Domain.TaskPatch{Status: &status, EnteredStatusAt: &later}, 0)
+		if err != nil {
+			t.Fatalf("PatchTask: %v", err)
+		}
+		if !patched.StatusEnteredAt[Domain.Pending].Equal(start) || !patched.StatusEnteredAt[Domain.Completed].Equal(later) {
+			t.Errorf("StatusEnteredAt after patch = %v, want pending at %v and completed at %v", patched.StatusEnteredAt, start, later)
+		}
+
+		// A task without status times keeps the stored ones.
+		change := patched
+		change.Title = "reviewed"
+		change.StatusEnteredAt = nil
+		updated, err := repo.UpdateTask(ctx, created.ID.Hex(), change, 0)
+		if err != nil {
+			t.Fatalf("UpdateTask: %v", err)
+		}
+		if len(updated.StatusEnteredAt) != 2 {
+			t.Errorf("StatusEnteredAt after update = %v, want both times kept", updated.StatusEnteredAt)
+		}
+	})
+
+	t.Run("DeleteMissing", func(t *testing.T) {
+		err := newRepo(t).DeleteTask(context.Background(), primitive.NewObjectID().Hex(), 0)
+		if !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("DeleteTask of missing task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		created, err := repo.CreateTask(ctx, newTask("temp", time.Hour, primitive.NewObjectID()))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if err := repo.DeleteTask(ctx, created.ID.Hex(), 0); err != nil {
+			t.Fatalf("DeleteTask: %v", err)
+		}
+		if _, err := repo.GetTaskByID(ctx, created.ID.Hex()); !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("GetTaskByID after delete: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+	})
+
+	t.Run("Trash", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		created, err := repo.CreateTask(ctx, newTask("trash me", time.Hour, primitive.NewObjectID()))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		id := created.ID.Hex()
+
+		if _, err := repo.GetTrashedTaskByID(ctx, id); !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("GetTrashedTaskByID of live task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+		if err := repo.DeleteTask(ctx, id, 0); err != nil {
+			t.Fatalf("DeleteTask: %v", err)
+		}
+		if err := repo.DeleteTask(ctx, id, 0); !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("DeleteTask of trashed task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+		if _, err := repo.UpdateTask(ctx, id, created, 0); !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("UpdateTask of trashed task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+
+		trashed, err := repo.GetTrashedTaskByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetTrashedTaskByID: %v", err)
+		}
+		if trashed.DeletedAt == nil {
+			t.Error("trashed task has no DeletedAt")
+		}
+		if page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{Limit: 10}); err != nil || page.Total != 0 {
+			t.Errorf("GetAllTasks = %+v, %v; want no live tasks", page, err)
+		}
+		if page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{Deleted: true, Limit: 10}); err != nil || page.Total != 1 {
+			t.Errorf("GetAllTasks of trash = %+v, %v; want one task", page, err)
+		}
+
+		restored, err := repo.RestoreTask(ctx, id)
+		if err != nil {
+			t.Fatalf("RestoreTask: %v", err)
+		}
+		if restored.DeletedAt != nil {
+			t.Error("restored task still has DeletedAt")
+		}
+		if _, err := repo.GetTaskByID(ctx, id); err != nil {
+			t.Errorf("GetTaskByID after restore: %v", err)
+		}
+		if _, err := repo.RestoreTask(ctx, id); !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("RestoreTask of live task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+		if err := repo.PurgeTask(ctx, id); !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("PurgeTask of live task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+
+		if err := repo.DeleteTask(ctx, id, 0); err != nil {
+			t.Fatalf("DeleteTask: %v", err)
+		}
+		if err := repo.PurgeTask(ctx, id); err != nil {
+			t.Fatalf("PurgeTask: %v", err)
+		}
+		if _, err := repo.GetTrashedTaskByID(ctx, id); !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("GetTrashedTaskByID after purge: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+	})
+
+	t.Run("PurgeDeletedBefore", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		var ids []string
+		for _, title := range []string{"old", "live"} {
+			created, err := repo.CreateTask(ctx, newTask(title, time.Hour, primitive.NewObjectID()))
+			if err != nil {
+				t.Fatalf("CreateTask: %v", err)
+			}
+			ids = append(ids, created.ID.Hex())
+		}
+		if err := repo.DeleteTask(ctx, ids[0], 0); err != nil {
+			t.Fatalf("DeleteTask: %v", err)
+		}
+
+		if purged, err := repo.PurgeDeletedBefore(ctx, time.Now().Add(-time.Hour)); err != nil || len(purged) != 0 {
+			t.Errorf("PurgeDeletedBefore an hour ago = %v, %v; want none", purged, err)
+		}
+		if purged, err := repo.PurgeDeletedBefore(ctx, time.Now().Add(time.Second)); err != nil || len(purged) != 1 || purged[0].Hex() != ids[0] {
+			t.Errorf("PurgeDeletedBefore now = %v, %v; want [%s]", purged, err, ids[0])
+		}
+		if _, err := repo.GetTaskByID(ctx, ids[1]); err != nil {
+			t.Errorf("live task was purged: %v", err)
+		}
+	})
+
+	t.Run("Series", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		ownerID := primitive.NewObjectID()
+		first := newTask("Weekly report", time.Hour, ownerID)
+		first.Recurrence = "FREQ=WEEKLY"
+		first.StartSeries()
+		created, err := repo.CreateTask(ctx, first)
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if created.SeriesID == nil || *created.SeriesID != *first.SeriesID || created.Occurrence != 1 || created.SeriesTemplate == nil {
+			t.Errorf("CreateTask series = %v, %d, %v; want %v, 1, template", created.SeriesID, created.Occurrence, created.SeriesTemplate, first.SeriesID)
+		}
+
+		next, ok := created.NextOccurrence(time.Now())
+		if !ok {
+			t.Fatalf("NextOccurrence of %+v reported the end of the series", created)
+		}
+		if _, err := repo.CreateTask(ctx, next); err != nil {
+			t.Fatalf("CreateTask next occurrence: %v", err)
+		}
+		if _, err := repo.CreateTask(ctx, next); !errors.Is(err, Domain.ErrOccurrenceExists) {
+			t.Errorf("CreateTask duplicate occurrence error = %v; want ErrOccurrenceExists", err)
+		}
+		if _, err := repo.CreateTask(ctx, newTask("standalone", time.Hour, ownerID)); err != nil {
+			t.Errorf("CreateTask standalone: %v", err)
+		}
+		if _, err := repo.CreateTask(ctx, newTask("another standalone", time.Hour, ownerID)); err != nil {
+			t.Errorf("CreateTask second standalone: %v", err)
+		}
+
+		page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{SeriesID: first.SeriesID.Hex(), SortBy: Domain.SortByDueDate})
+		if err != nil {
+			t.Fatalf("GetAllTasks: %v", err)
+		}
+		if page.Total != 2 || len(page.Tasks) != 2 || page.Tasks[0].Occurrence != 1 || page.Tasks[1].Occurrence != 2 {
+			t.Errorf("GetAllTasks by series = %+v; want occurrences 1 and 2", page)
+		}
+		if _, err := repo.GetAllTasks(ctx, Domain.TaskQuery{SeriesID: "not-an-id"}); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetAllTasks with invalid series_id: got %v, want %v", err, Domain.ErrValidation)
+		}
+
+		// UpdateTask replaces the series fields, e.g. to end the series.
+		ended := created
+		ended.Recurrence = ""
+		ended.SeriesTemplate = nil
+		updated, err := repo.UpdateTask(ctx, created.ID.Hex(), ended, 0)
+		if err != nil {
+			t.Fatalf("UpdateTask: %v", err)
+		}
+		if updated.Recurrence != "" || updated.SeriesTemplate != nil || updated.SeriesID == nil || updated.Occurrence != 1 {
+			t.Errorf("UpdateTask series = %q, %v, %v, %d; want series ended", updated.Recurrence, updated.SeriesTemplate, updated.SeriesID, updated.Occurrence)
+		}
+	})
+
+	t.Run("Relations", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		ownerID := primitive.NewObjectID()
+		parent, err := repo.CreateTask(ctx, newTask("parent", time.Hour, ownerID))
+		if err != nil {
+			t.Fatalf("CreateTask parent: %v", err)
+		}
+		blocker, err := repo.CreateTask(ctx, newTask("blocker", time.Hour, ownerID))
+		if err != nil {
+			t.Fatalf("CreateTask blocker: %v", err)
+		}
+		child := newTask("child", time.Hour, ownerID)
+		child.ParentID = &parent.ID
+		child.Checklist = []Domain.ChecklistItem{{Text: "step one", Done: true}, {Text: "step two"}}
+		child.BlockedBy = []primitive.ObjectID{blocker.ID}
+		created, err := repo.CreateTask(ctx, child)
+		if err != nil {
+			t.Fatalf("CreateTask child: %v", err)
+		}
+		got, err := repo.GetTaskByID(ctx, created.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetTaskByID: %v", err)
+		}
+		if got.ParentID == nil || *got.ParentID != parent.ID || len(got.Checklist) != 2 || !got.Checklist[0].Done || len(got.BlockedBy) != 1 || got.BlockedBy[0] != blocker.ID {
+			t.Errorf("GetTaskByID relations = %v, %v, %v; want parent, checklist and blocker", got.ParentID, got.Checklist, got.BlockedBy)
+		}
+
+		page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{ParentID: parent.ID.Hex(), Limit: Domain.DefaultTaskLimit})
+		if err != nil {
+			t.Fatalf("GetAllTasks: %v", err)
+		}
+		if page.Total != 1 || len(page.Tasks) != 1 || page.Tasks[0].ID != created.ID {
+			t.Errorf("GetAllTasks by parent = %+v; want only the child", page)
+		}
+		if _, err := repo.GetAllTasks(ctx, Domain.TaskQuery{ParentID: "not-an-id"}); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetAllTasks with invalid parent_id: got %v, want %v", err, Domain.ErrValidation)
+		}
+
+		// A zero parent in a patch moves the task to the top level.
+		topLevel := primitive.NilObjectID
+		patched, err := repo.PatchTask(ctx, created.ID.Hex(), Domain.TaskPatch{ParentID: &topLevel, BlockedBy: &[]primitive.ObjectID{}}, 0)
+		if err != nil {
+			t.Fatalf("PatchTask: %v", err)
+		}
+		if patched.ParentID != nil || len(patched.BlockedBy) != 0 || len(patched.Checklist) != 2 {
+			t.Errorf("PatchTask relations = %v, %v, %v; want top level, unblocked, checklist kept", patched.ParentID, patched.BlockedBy, patched.Checklist)
+		}
+	})
+
+	t.Run("ProjectsAndTags", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		projectID := primitive.NewObjectID()
+		create := func(title string, ownerID primitive.ObjectID, project *primitive.ObjectID, tags ...string) Domain.Task {
+			task := newTask(title, time.Hour, ownerID)
+			task.ProjectID = project
+			task.Tags = tags
+			created, err := repo.CreateTask(ctx, task)
+			if err != nil {
+				t.Fatalf("CreateTask %s: %v", title, err)
+			}
+			return created
+		}
+		create("alice private", alice, nil, "home", "urgent")
+		create("bob private", bob, nil, "home")
+		create("bob in project", bob, &projectID, "urgent", "review")
+		create("alice in project", alice, &projectID, "urgent")
+
+		// Tasks in a project are visible to its members only, whoever owns
+		// them.
+		for _, tc := range []struct {
+			name     string
+			projects []primitive.ObjectID
+			want     int64
+		}{
+			{"member", []primitive.ObjectID{projectID}, 3},
+			{"not a member", nil, 1},
+		} {
+			page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{VisibleTo: alice.Hex(), VisibleProjects: tc.projects, Limit: 10})
+			if err != nil {
+				t.Fatalf("GetAllTasks: %v", err)
+			}
+			if page.Total != tc.want {
+				t.Errorf("GetAllTasks visible to %s = %v; want %d tasks", tc.name, taskTitles(page.Tasks), tc.want)
+			}
+		}
+
+		page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{ProjectID: projectID.Hex(), Tag: "urgent", SortBy: Domain.SortByTitle, Limit: 10})
+		if err != nil {
+			t.Fatalf("GetAllTasks: %v", err)
+		}
+		if got := taskTitles(page.Tasks); len(got) != 2 || got[0] != "alice in project" || got[1] != "bob in project" {
+			t.Errorf("GetAllTasks by project and tag = %v; want both project tasks", got)
+		}
+		if _, err := repo.GetAllTasks(ctx, Domain.TaskQuery{ProjectID: "not-an-id"}); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetAllTasks with invalid project_id: got %v, want %v", err, Domain.ErrValidation)
+		}
+
+		tags, err := repo.ListTags(ctx, Domain.TagQuery{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListTags: %v", err)
+		}
+		want := []Domain.TagCount{{Tag: "urgent", Count: 3}, {Tag: "home", Count: 2}, {Tag: "review", Count: 1}}
+		if fmt.Sprint(tags) != fmt.Sprint(want) {
+			t.Errorf("ListTags = %v; want %v", tags, want)
+		}
+		tags, err = repo.ListTags(ctx, Domain.TagQuery{VisibleTo: bob.Hex(), Prefix: "u", Limit: 10})
+		if err != nil {
+			t.Fatalf("ListTags: %v", err)
+		}
+		if fmt.Sprint(tags) != fmt.Sprint([]Domain.TagCount{}) {
+			t.Errorf("ListTags visible to bob outside the project = %v; want none", tags)
+		}
+		tags, err = repo.ListTags(ctx, Domain.TagQuery{ProjectID: projectID.Hex(), Prefix: "r", Limit: 10})
+		if err != nil {
+			t.Fatalf("ListTags: %v", err)
+		}
+		if len(tags) != 1 || tags[0] != (Domain.TagCount{Tag: "review", Count: 1}) {
+			t.Errorf("ListTags in project with prefix = %v; want [review 1]", tags)
+		}
+	})
+
+	t.Run("Assignees", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		projectID := primitive.NewObjectID()
+		create := func(title string, project *primitive.ObjectID, assignees ...primitive.ObjectID) Domain.Task {
+			task := newTask(title, time.Hour, alice)
+			task.ProjectID = project
+			task.Assignees = assignees
+			created, err := repo.CreateTask(ctx, task)
+			if err != nil {
+				t.Fatalf("CreateTask %s: %v", title, err)
+			}
+			return created
+		}
+		create("unassigned", nil)
+		assigned := create("assigned", nil, bob)
+		create("assigned in project", &projectID, alice, bob)
+
+		// Assignees see a task even if they neither own it nor are members
+		// of its project.
+		page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{VisibleTo: bob.Hex(), SortBy: Domain.SortByTitle, Limit: 10})
+		if err != nil {
+			t.Fatalf("GetAllTasks: %v", err)
+		}
+		if got := taskTitles(page.Tasks); fmt.Sprint(got) != "[assigned assigned in project]" {
+			t.Errorf("GetAllTasks visible to assignee = %v; want both assigned tasks", got)
+		}
+		page, err = repo.GetAllTasks(ctx, Domain.TaskQuery{AssigneeID: alice.Hex(), Limit: 10})
+		if err != nil {
+			t.Fatalf("GetAllTasks: %v", err)
+		}
+		if got := taskTitles(page.Tasks); fmt.Sprint(got) != "[assigned in project]" {
+			t.Errorf("GetAllTasks by assignee = %v; want [assigned in project]", got)
+		}
+		if _, err := repo.GetAllTasks(ctx, Domain.TaskQuery{AssigneeID: "not-an-id"}); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetAllTasks with invalid assignee_id: got %v, want %v", err, Domain.ErrValidation)
+		}
+
+		assignees := []primitive.ObjectID{alice}
+		patched, err := repo.PatchTask(ctx, assigned.ID.Hex(), Domain.TaskPatch{Assignees: &assignees}, 0)
+		if err != nil {
+			t.Fatalf("PatchTask: %v", err)
+		}
+		if len(patched.Assignees) != 1 || patched.Assignees[0] != alice {
+			t.Errorf("assignees after patch = %v; want [%s]", patched.Assignees, alice.Hex())
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		ownerID, otherID := primitive.NewObjectID(), primitive.NewObjectID()
+		create := func(title, description string, status Domain.Status, ownerID primitive.ObjectID) Domain.Task {
+			t.Helper()
+			task := newTask(title, time.Hour, ownerID)
+			task.Description = description
+			task.Status = status
+			created, err := repo.CreateTask(ctx, task)
+			if err != nil {
+				t.Fatalf("CreateTask: %v", err)
+			}
+			return created
+		}
+		create("Quarterly report", "Prepare the quarterly reports for finance", Domain.Pending, ownerID)
+		lunch := create("Team lunch", "Book a table and bring the report", Domain.Pending, ownerID)
+		create("report", "", Domain.Completed, ownerID)
+		create("Gym", "Leg day", Domain.Pending, ownerID)
+		create("Expense report", "Someone else's task", Domain.Pending, otherID)
+		trashed := create("Old report", "", Domain.Pending, ownerID)
+		if err := repo.DeleteTask(ctx, trashed.ID.Hex(), 0); err != nil {
+			t.Fatalf("DeleteTask: %v", err)
+		}
+
+		search := func(query Domain.TaskQuery) []string {
+			t.Helper()
+			query.VisibleTo = ownerID.Hex()
+			if query.Limit == 0 {
+				query.Limit = 10
+			}
+			page, err := repo.SearchTasks(ctx, query)
+			if err != nil {
+				t.Fatalf("SearchTasks(%q): %v", query.Text, err)
+			}
+			titles := make([]string, len(page.Results))
+			for i, result := range page.Results {
+				titles[i] = result.Task.Title
+				if result.Score <= 0 {
+					t.Errorf("SearchTasks(%q): %q has score %v", query.Text, result.Task.Title, result.Score)
+				}
+			}
+			if page.Total < int64(len(titles)) {
+				t.Errorf("SearchTasks(%q): total %d is less than %d results", query.Text, page.Total, len(titles))
+			}
+			return titles
+		}
+		tests := []struct {
+			name  string
+			query Domain.TaskQuery
+			want  []string
+		}{
+			{"ranked by relevance", Domain.TaskQuery{Text: "report"}, []string{"report", "Quarterly report", "Team lunch"}},
+			{"plural and case", Domain.TaskQuery{Text: "REPORTS"}, []string{"report", "Quarterly report", "Team lunch"}},
+			{"any term", Domain.TaskQuery{Text: "gym lunch"}, []string{"Gym", "Team lunch"}},
+			{"excluded term", Domain.TaskQuery{Text: "report -lunch"}, []string{"report", "Quarterly report"}},
+			{"phrase", Domain.TaskQuery{Text: `"quarterly reports"`}, []string{"Quarterly report"}},
+			{"stop words only", Domain.TaskQuery{Text: "the and"}, []string{}},
+			{"with filter", Domain.TaskQuery{Text: "report", Status: Domain.Completed}, []string{"report"}},
+			{"paginated", Domain.TaskQuery{Text: "report", Limit: 1, Offset: 1}, []string{"Quarterly report"}},
+		}
+		for _, tt := range tests {
+			if got := search(tt.query); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("%s: SearchTasks(%q) = %q, want %q", tt.name, tt.query.Text, got, tt.want)
+			}
+		}
+
+		lunch.Description = "Book a table"
+		if _, err := repo.UpdateTask(ctx, lunch.ID.Hex(), lunch, 0); err != nil {
+			t.Fatalf("UpdateTask: %v", err)
+		}
+		if got, want := search(Domain.TaskQuery{Text: "report"}), []string{"report", "Quarterly report"}; fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("SearchTasks after update = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("WriteTasks", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		ownerID, otherID := primitive.NewObjectID(), primitive.NewObjectID()
+		existing, err := repo.CreateTask(ctx, newTask("existing", time.Hour, ownerID))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		doomed, err := repo.CreateTask(ctx, newTask("doomed", time.Hour, ownerID))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		foreign, err := repo.CreateTask(ctx, newTask("foreign", time.Hour, otherID))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		renamed := existing
+		renamed.Title = "renamed"
+		// Bulk updates cannot change tags and the other relations.
+		retagged := renamed
+		retagged.Tags = []string{"urgent"}
+
+		results, err := repo.WriteTasks(ctx, Domain.TaskBatch{
+			VisibleTo: ownerID.Hex(),
+			Operations: []Domain.BulkOperation{
+				{Op: Domain.BulkCreate, Task: newTask("new", time.Hour, ownerID)},
+				{Op: Domain.BulkUpdate, ID: existing.ID.Hex(), Version: 1, Task: renamed},
+				{Op: Domain.BulkUpdate, ID: existing.ID.Hex(), Version: 1, Task: renamed},
+				{Op: Domain.BulkDelete, ID: doomed.ID.Hex()},
+				{Op: Domain.BulkDelete, ID: primitive.NewObjectID().Hex()},
+				{Op: Domain.BulkDelete, ID: foreign.ID.Hex()},
+				{Op: Domain.BulkUpdate, ID: "not-an-id", Task: renamed},
+				{Op: Domain.BulkUpdate, ID: existing.ID.Hex(), Task: retagged},
+			},
+		})
+		if err != nil {
+			t.Fatalf("WriteTasks: %v", err)
+		}
+		wantErrs := []error{nil, nil, Domain.ErrVersionMismatch, nil, Domain.ErrTaskNotFound, Domain.ErrTaskNotFound, Domain.ErrValidation, Domain.ErrValidation}
+		if len(results) != len(wantErrs) {
+			t.Fatalf("WriteTasks returned %d results, want %d", len(results), len(wantErrs))
+		}
+		for i, want := range wantErrs {
+			if got := results[i].Err; (want == nil && got != nil) || (want != nil && !errors.Is(got, want)) {
+				t.Errorf("result %d: got error %v, want %v", i, got, want)
+			}
+		}
+		if results[0].Task.ID.IsZero() || results[0].Task.Version != 1 {
+			t.Errorf("created task = %+v", results[0].Task)
+		}
+		if results[1].Task.Title != "renamed" || results[1].Task.Version != 2 || results[1].Previous == nil || results[1].Previous.Title != "existing" {
+			t.Errorf("updated task = %+v, previous %+v", results[1].Task, results[1].Previous)
+		}
+
+		if got, err := repo.GetTaskByID(ctx, results[0].Task.ID.Hex()); err != nil || got.Title != "new" {
+			t.Errorf("GetTaskByID of created task = %+v, %v", got, err)
+		}
+		if got, err := repo.GetTaskByID(ctx, existing.ID.Hex()); err != nil || got.Title != "renamed" || got.Version != 2 {
+			t.Errorf("GetTaskByID of updated task = %+v, %v", got, err)
+		}
+		if _, err := repo.GetTrashedTaskByID(ctx, doomed.ID.Hex()); err != nil {
+			t.Errorf("deleted task is not in the trash: %v", err)
+		}
+		if _, err := repo.GetTaskByID(ctx, foreign.ID.Hex()); err != nil {
+			t.Errorf("task of another user was deleted: %v", err)
+		}
+	})
+
+	t.Run("WriteTasksAtomic", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		existing, err := repo.CreateTask(ctx, newTask("existing", time.Hour, primitive.NewObjectID()))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		renamed := existing
+		renamed.Title = "renamed"
+
+		results, err := repo.WriteTasks(ctx, Domain.TaskBatch{
+			Atomic: true,
+			Operations: []Domain.BulkOperation{
+				{Op: Domain.BulkCreate, Task: newTask("new", time.Hour, existing.OwnerID)},
+				{Op: Domain.BulkUpdate, ID: existing.ID.Hex(), Task: renamed},
+				{Op: Domain.BulkDelete, ID: primitive.NewObjectID().Hex()},
+			},
+		})
+		if err != nil {
+			t.Fatalf("WriteTasks: %v", err)
+		}
+		wantErrs := []error{Domain.ErrBatchAborted, Domain.ErrBatchAborted, Domain.ErrTaskNotFound}
+		for i, want := range wantErrs {
+			if !errors.Is(results[i].Err, want) {
+				t.Errorf("result %d: got error %v, want %v", i, results[i].Err, want)
+			}
+		}
+		if page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{Limit: 10}); err != nil || fmt.Sprint(taskTitles(page.Tasks)) != "[existing]" {
+			t.Errorf("GetAllTasks after aborted batch = %v, %v; want only the existing task", taskTitles(page.Tasks), err)
+		}
+
+		results, err = repo.WriteTasks(ctx, Domain.TaskBatch{
+			Atomic: true,
+			Operations: []Domain.BulkOperation{
+				{Op: Domain.BulkCreate, Task: newTask("new", time.Hour, existing.OwnerID)},
+				{Op: Domain.BulkUpdate, ID: existing.ID.Hex(), Version: 1, Task: renamed},
+				{Op: Domain.BulkDelete, ID: existing.ID.Hex(), Version: 2},
+			},
+		})
+		if err != nil {
+			t.Fatalf("WriteTasks: %v", err)
+		}
+		for i, result := range results {
+			if result.Err != nil {
+				t.Errorf("result %d: %v", i, result.Err)
+			}
+		}
+		if page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{Limit: 10}); err != nil || fmt.Sprint(taskTitles(page.Tasks)) != "[new]" {
+			t.Errorf("GetAllTasks after atomic batch = %v, %v; want only the new task", taskTitles(page.Tasks), err)
+		}
+		if trashed, err := repo.GetTrashedTaskByID(ctx, existing.ID.Hex()); err != nil || trashed.Title != "renamed" || trashed.Version != 3 {
+			t.Errorf("GetTrashedTaskByID = %+v, %v", trashed, err)
+		}
+	})
+
+	t.Run("Versioning", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		created, err := repo.CreateTask(ctx, newTask("v", time.Hour, primitive.NewObjectID()))
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if created.Version != 1 {
+			t.Fatalf("new task has version %d, want 1", created.Version)
+		}
+		id := created.ID.Hex()
+
+		updated, err := repo.UpdateTask(ctx, id, created, 1)
+		if err != nil {
+			t.Fatalf("UpdateTask with current version: %v", err)
+		}
+		if updated.Version != 2 {
+			t.Errorf("version after update = %d, want 2", updated.Version)
+		}
+
+		status := Domain.Completed
+		if _, err := repo.PatchTask(ctx, id, Domain.TaskPatch{Status: &status}, 1); !errors.Is(err, Domain.ErrVersionMismatch) {
+			t.Errorf("PatchTask with stale version: got %v, want %v", err, Domain.ErrVersionMismatch)
+		}
+		if _, err := repo.UpdateTask(ctx, id, created, 1); !errors.Is(err, Domain.ErrVersionMismatch) {
+			t.Errorf("UpdateTask with stale version: got %v, want %v", err, Domain.ErrVersionMismatch)
+		}
+		if err := repo.DeleteTask(ctx, id, 1); !errors.Is(err, Domain.ErrVersionMismatch) {
+			t.Errorf("DeleteTask with stale version: got %v, want %v", err, Domain.ErrVersionMismatch)
+		}
+
+		patched, err := repo.PatchTask(ctx, id, Domain.TaskPatch{Status: &status}, 0)
+		if err != nil {
+			t.Fatalf("unconditional PatchTask: %v", err)
+		}
+		if patched.Version != 3 {
+			t.Errorf("version after patch = %d, want 3", patched.Version)
+		}
+		if _, err := repo.UpdateTask(ctx, primitive.NewObjectID().Hex(), created, 3); !errors.Is(err, Domain.ErrTaskNotFound) {
+			t.Errorf("conditional UpdateTask of missing task: got %v, want %v", err, Domain.ErrTaskNotFound)
+		}
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		for i := 1; i <= 5; i++ {
+			task := newTask(fmt.Sprintf("task %d", i), time.Duration(i)*time.Hour, alice)
+			if i%2 == 0 {
+				task.Status = Domain.Completed
+			}
+			if _, err := repo.CreateTask(ctx, task); err != nil {
+				t.Fatalf("CreateTask: %v", err)
+			}
+		}
+		if _, err := repo.CreateTask(ctx, newTask("bob's task", time.Hour, bob)); err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+
+		page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{
+			VisibleTo: alice.Hex(),
+			Status:    Domain.Pending,
+			SortBy:    Domain.SortByDueDate,
+			SortDesc:  true,
+			Limit:     2,
+			Offset:    1,
+		})
+		if err != nil {
+			t.Fatalf("GetAllTasks: %v", err)
+		}
+		if page.Total != 3 {
+			t.Errorf("Total = %d, want 3", page.Total)
+		}
+		if titles := taskTitles(page.Tasks); fmt.Sprint(titles) != "[task 3 task 1]" {
+			t.Errorf("tasks = %v, want [task 3 task 1]", titles)
+		}
+
+		for _, tt := range []struct {
+			status   Domain.Status
+			excluded []Domain.Status
+			want     string
+		}{
+			{"", []Domain.Status{Domain.Completed}, "[task 1 task 3 task 5]"},
+			{"", []Domain.Status{Domain.Pending}, "[task 2 task 4]"},
+			{"", []Domain.Status{Domain.Pending, Domain.Completed}, "[]"},
+			{Domain.Pending, []Domain.Status{Domain.NotDone}, "[task 1 task 3 task 5]"},
+			{Domain.Pending, []Domain.Status{Domain.Pending}, "[]"},
+		} {
+			page, err := repo.GetAllTasks(ctx, Domain.TaskQuery{VisibleTo: alice.Hex(), Status: tt.status, ExcludeStatuses: tt.excluded, SortBy: Domain.SortByTitle, Limit: 10})
+			if err != nil {
+				t.Fatalf("GetAllTasks: %v", err)
+			}
+			if titles := taskTitles(page.Tasks); fmt.Sprint(titles) != tt.want || page.Total != int64(len(page.Tasks)) {
+				t.Errorf("tasks with status %q excluding %v = %v (total %d), want %s", tt.status, tt.excluded, titles, page.Total, tt.want)
+			}
+		}
+
+		dueBefore := time.Now().Add(150 * time.Minute)
+		page, err = repo.GetAllTasks(ctx, Domain.TaskQuery{DueBefore: &dueBefore, SortBy: Domain.SortByTitle, Limit: 10})
+		if err != nil {
+			t.Fatalf("GetAllTasks: %v", err)
+		}
+		if titles := taskTitles(page.Tasks); fmt.Sprint(titles) != "[bob's task task 1 task 2]" {
+			t.Errorf("tasks = %v, want [bob's task task 1 task 2]", titles)
+		}
+
+		page, err = repo.GetAllTasks(ctx, Domain.TaskQuery{Limit: 10, Offset: 10})
+		if err != nil {
+			t.Fatalf("GetAllTasks: %v", err)
+		}
+		if page.Total != 6 || page.Tasks == nil || len(page.Tasks) != 0 {
+			t.Errorf("page past the end = %+v, want total 6 and no tasks", page)
+		}
+	})
+}
+
+// UserRepository runs the user repository conformance tests. newRepo must
+// return a new, empty repository on every call.
+func UserRepository(t *testing.T, newRepo func(t *testing.T) Domain.UserRepository) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		created, err := repo.CreateUser(ctx, Domain.User{Username: "alice", Password: "hashed", Role: Domain.RoleUser})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if created.ID.IsZero() {
+			t.Fatal("CreateUser did not assign an ID")
+		}
+		if created.Password != "" {
+			t.Error("CreateUser returned the password hash")
+		}
+
+		got, err := repo.GetUserByUsername(ctx, "alice")
+		if err != nil {
+			t.Fatalf("GetUserByUsername: %v", err)
+		}
+		if got.ID != created.ID || got.Password != "hashed" || got.Role != Domain.RoleUser {
+			t.Errorf("GetUserByUsername = %+v", got)
+		}
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		_, err := newRepo(t).GetUserByUsername(context.Background(), "nobody")
+		if !errors.Is(err, Domain.ErrUserNotFound) {
+			t.Errorf("GetUserByUsername of missing user: got %v, want %v", err, Domain.ErrUserNotFound)
+		}
+	})
+
+	t.Run("GetByID", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		created, err := repo.CreateUser(ctx, Domain.User{Username: "alice", Password: "hashed", Role: Domain.RoleUser})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+
+		got, err := repo.GetUserByID(ctx, created.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if got.Username != "alice" || got.Role != Domain.RoleUser {
+			t.Errorf("GetUserByID = %+v", got)
+		}
+		if _, err := repo.GetUserByID(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, Domain.ErrUserNotFound) {
+			t.Errorf("GetUserByID of missing user: got %v, want %v", err, Domain.ErrUserNotFound)
+		}
+		if _, err := repo.GetUserByID(ctx, "not-an-id"); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetUserByID with invalid ID: got %v, want %v", err, Domain.ErrValidation)
+		}
+	})
+
+	t.Run("DuplicateUsername", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		user := Domain.User{Username: "bob", Password: "hashed", Role: Domain.RoleUser}
+		if _, err := repo.CreateUser(ctx, user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if _, err := repo.CreateUser(ctx, user); !errors.Is(err, Domain.ErrUsernameTaken) {
+			t.Errorf("CreateUser with duplicate username: got %v, want %v", err, Domain.ErrUsernameTaken)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		for _, user := range []Domain.User{
+			{Username: "carol", Password: "hashed", Role: Domain.RoleUser},
+			{Username: "Alice", Password: "hashed", Role: Domain.RoleAdmin},
+			{Username: "bob", Password: "hashed", Role: Domain.RoleUser, Disabled: true},
+			{Username: "alina", Password: "hashed", Role: Domain.RoleUser},
+		} {
+			if _, err := repo.CreateUser(ctx, user); err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+		}
+
+		usernames := func(page Domain.UserPage) []string {
+			var names []string
+			for _, user := range page.Users {
+				if user.Password != "" {
+					t.Errorf("ListUsers returned the password hash of %s", user.Username)
+				}
+				names = append(names, user.Username)
+			}
+			return names
+		}
+		disabled, enabled := true, false
+		for _, tc := range []struct {
+			name  string
+			query Domain.UserQuery
+			want  []string
+			total int64
+		}{
+			{"all", Domain.UserQuery{Limit: 10}, []string{"Alice", "alina", "bob", "carol"}, 4},
+			{"page", Domain.UserQuery{Limit: 2, Offset: 1}, []string{"alina", "bob"}, 4},
+			{"search ignores case", Domain.UserQuery{Search: "AL", Limit: 10}, []string{"Alice", "alina"}, 2},
+			{"search is literal", Domain.UserQuery{Search: "a.i", Limit: 10}, nil, 0},
+			{"role", Domain.UserQuery{Role: Domain.RoleAdmin, Limit: 10}, []string{"Alice"}, 1},
+			{"disabled", Domain.UserQuery{Disabled: &disabled, Limit: 10}, []string{"bob"}, 1},
+			{"enabled", Domain.UserQuery{Disabled: &enabled, Limit: 10}, []string{"Alice", "alina", "carol"}, 3},
+		} {
+			page, err := repo.ListUsers(ctx, tc.query)
+			if err != nil {
+				t.Fatalf("%s: ListUsers: %v", tc.name, err)
+			}
+			if got := usernames(page); !slices.Equal(got, tc.want) || page.Total != tc.total {
+				t.Errorf("%s: ListUsers = %v (total %d), want %v (total %d)", tc.name, got, page.Total, tc.want, tc.total)
+			}
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		created, err := repo.CreateUser(ctx, Domain.User{Username: "alice", Password: "hashed", Role: Domain.RoleUser})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+
+		updated, err := repo.UpdateUser(ctx, Domain.User{ID: created.ID, Username: "ignored", Password: "ignored", Role: Domain.RoleAdmin, Disabled: true})
+		if err != nil {
+			t.Fatalf("UpdateUser: %v", err)
+		}
+		if updated.Username != "alice" || updated.Role != Domain.RoleAdmin || !updated.Disabled || updated.Password != "" {
+			t.Errorf("UpdateUser = %+v", updated)
+		}
+		got, err := repo.GetUserByUsername(ctx, "alice")
+		if err != nil {
+			t.Fatalf("GetUserByUsername: %v", err)
+		}
+		if got.Password != "hashed" || got.Role != Domain.RoleAdmin || !got.Disabled {
+			t.Errorf("GetUserByUsername after UpdateUser = %+v", got)
+		}
+		if _, err := repo.UpdateUser(ctx, Domain.User{ID: primitive.NewObjectID(), Role: Domain.RoleUser}); !errors.Is(err, Domain.ErrUserNotFound) {
+			t.Errorf("UpdateUser of missing user: got %v, want %v", err, Domain.ErrUserNotFound)
+		}
+
+		if err := repo.DeleteUser(ctx, created.ID.Hex()); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+		if _, err := repo.GetUserByID(ctx, created.ID.Hex()); !errors.Is(err, Domain.ErrUserNotFound) {
+			t.Errorf("GetUserByID after DeleteUser: got %v, want %v", err, Domain.ErrUserNotFound)
+		}
+		if err := repo.DeleteUser(ctx, created.ID.Hex()); !errors.Is(err, Domain.ErrUserNotFound) {
+			t.Errorf("DeleteUser of missing user: got %v, want %v", err, Domain.ErrUserNotFound)
+		}
+		// The username is free again.
+		if _, err := repo.CreateUser(ctx, Domain.User{Username: "alice", Password: "hashed", Role: Domain.RoleUser}); err != nil {
+			t.Errorf("CreateUser after DeleteUser: %v", err)
+		}
+	})
+
+	t.Run("SetPassword", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		created, err := repo.CreateUser(ctx, Domain.User{Username: "alice", Password: "hashed", Role: Domain.RoleUser, Email: "alice@example.com"})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+
+		changedAt := time.Now().UTC().Truncate(time.Millisecond)
+		if err := repo.SetPassword(ctx, created.ID.Hex(), "rehashed", changedAt); err != nil {
+			t.Fatalf("SetPassword: %v", err)
+		}
+		got, err := repo.GetUserByID(ctx, created.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if got.Password != "rehashed" || got.Email != "alice@example.com" || got.PasswordChangedAt == nil || !got.PasswordChangedAt.Equal(changedAt) {
+			t.Errorf("GetUserByID after SetPassword = %+v", got)
+		}
+		if err := repo.SetPassword(ctx, primitive.NewObjectID().Hex(), "rehashed", changedAt); !errors.Is(err, Domain.ErrUserNotFound) {
+			t.Errorf("SetPassword of missing user: got %v, want %v", err, Domain.ErrUserNotFound)
+		}
+		if err := repo.SetPassword(ctx, "not-an-id", "rehashed", changedAt); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("SetPassword with invalid ID: got %v, want %v", err, Domain.ErrValidation)
+		}
+	})
+}
+
+// AuditRepository runs the audit repository conformance tests. newRepo must
+// return a new, empty repository on every call.
+func AuditRepository(t *testing.T, newRepo func(t *testing.T) Domain.AuditRepository) {
+	t.Run("RecordAndQuery", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		taskA, taskB := primitive.NewObjectID(), primitive.NewObjectID()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		start := time.Now().UTC().Truncate(time.Millisecond)
+
+		record := func(taskID, actorID primitive.ObjectID, action Domain.AuditAction, minutes int) {
+			t.Helper()
+			title := fmt.Sprintf("title %d", minutes)
+			entry, err := repo.RecordEntry(ctx, Domain.AuditEntry{
+				TaskID:        taskID,
+				Action:        action,
+				ActorID:       actorID,
+				ActorUsername: "user",
+				Changes:       []Domain.FieldChange{{Field: "title", New: &title}},
+				Timestamp:     start.Add(time.Duration(minutes) * time.Minute),
+			})
+			if err != nil {
+				t.Fatalf("RecordEntry: %v", err)
+			}
+			if entry.ID.IsZero() {
+				t.Fatal("RecordEntry did not assign an ID")
+			}
+		}
+		record(taskA, alice, Domain.AuditCreated, 0)
+		record(taskA, bob, Domain.AuditUpdated, 1)
+		record(taskB, bob, Domain.AuditCreated, 2)
+		record(taskA, alice, Domain.AuditDeleted, 3)
+
+		actions := func(page Domain.AuditPage) []Domain.AuditAction {
+			got := make([]Domain.AuditAction, len(page.Entries))
+			for i, entry := range page.Entries {
+				got[i] = entry.Action
+			}
+			return got
+		}
+		from, to := start.Add(time.Minute), start.Add(3*time.Minute)
+		tests := []struct {
+			name  string
+			query Domain.AuditQuery
+			want  []Domain.AuditAction
+			total int64
+		}{
+			{"all newest first", Domain.AuditQuery{Limit: 10}, []Domain.AuditAction{Domain.AuditDeleted, Domain.AuditCreated, Domain.AuditUpdated, Domain.AuditCreated}, 4},
+			{"by task", Domain.AuditQuery{TaskID: taskA.Hex(), Limit: 10}, []Domain.AuditAction{Domain.AuditDeleted, Domain.AuditUpdated, Domain.AuditCreated}, 3},
+			{"by user", Domain.AuditQuery{UserID: bob.Hex(), Limit: 10}, []Domain.AuditAction{Domain.AuditCreated, Domain.AuditUpdated}, 2},
+			{"time range", Domain.AuditQuery{From: &from, To: &to, Limit: 10}, []Domain.AuditAction{Domain.AuditCreated, Domain.AuditUpdated}, 2},
+			{"paginated", Domain.AuditQuery{Limit: 1, Offset: 1}, []Domain.AuditAction{Domain.AuditCreated}, 4},
+		}
+		for _, tt := range tests {
+			page, err := repo.GetEntries(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("%s: GetEntries: %v", tt.name, err)
+			}
+			if got := actions(page); fmt.Sprint(got) != fmt.Sprint(tt.want) || page.Total != tt.total {
+				t.Errorf("%s: got %v (total %d), want %v (total %d)", tt.name, got, page.Total, tt.want, tt.total)
+			}
+		}
+
+		page, err := repo.GetEntries(ctx, Domain.AuditQuery{TaskID: taskB.Hex(), Limit: 10})
+		if err != nil {
+			t.Fatalf("GetEntries: %v", err)
+		}
+		entry := page.Entries[0]
+		if entry.ActorID != bob || !entry.Timestamp.Equal(start.Add(2*time.Minute)) ||
+			len(entry.Changes) != 1 || entry.Changes[0].Old != nil || *entry.Changes[0].New != "title 2" {
+			t.Errorf("GetEntries = %+v", entry)
+		}
+	})
+
+	t.Run("InvalidID", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, query := range []Domain.AuditQuery{{TaskID: "not-an-id"}, {UserID: "not-an-id"}} {
+			if _, err := repo.GetEntries(context.Background(), query); !errors.Is(err, Domain.ErrValidation) {
+				t.Errorf("GetEntries(%+v): got %v, want %v", query, err, Domain.ErrValidation)
+			}
+		}
+	})
+}
+
+// ReminderRepository runs the reminder repository conformance tests.
+// newRepo must return a new, empty repository on every call.
+func ReminderRepository(t *testing.T, newRepo func(t *testing.T) Domain.ReminderRepository) {
+	t.Run("Preferences", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		userID := primitive.NewObjectID()
+
+		if _, err := repo.GetPreferences(ctx, userID.Hex()); !errors.Is(err, Domain.ErrReminderPreferencesNotFound) {
+			t.Errorf("GetPreferences before saving: got %v, want %v", err, Domain.ErrReminderPreferencesNotFound)
+		}
+		if _, err := repo.GetPreferences(ctx, "not-an-id"); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetPreferences with invalid ID: got %v, want %v", err, Domain.ErrValidation)
+		}
+
+		for _, want := range []Domain.ReminderPreferences{
+			{UserID: userID, Enabled: true, LeadMinutes: 60, Email: "alice@example.com"},
+			{UserID: userID, Enabled: false, LeadMinutes: 15},
+		} {
+			if _, err := repo.SavePreferences(ctx, want); err != nil {
+				t.Fatalf("SavePreferences: %v", err)
+			}
+			got, err := repo.GetPreferences(ctx, userID.Hex())
+			if err != nil {
+				t.Fatalf("GetPreferences: %v", err)
+			}
+			if got != want {
+				t.Errorf("GetPreferences = %+v, want %+v", got, want)
+			}
+		}
+	})
+
+	t.Run("MarkReminderSent", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		taskID := primitive.NewObjectID()
+		dueDate := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+
+		mark := func(dueDate time.Time, want bool) {
+			t.Helper()
+			newly, err := repo.MarkReminderSent(ctx, taskID, dueDate)
+			if err != nil {
+				t.Fatalf("MarkReminderSent: %v", err)
+			}
+			if newly != want {
+				t.Errorf("MarkReminderSent(%v) = %v, want %v", dueDate, newly, want)
+			}
+		}
+		mark(dueDate, true)
+		mark(dueDate, false)
+		// A new due date is a new reminder.
+		mark(dueDate.Add(time.Minute), true)
+
+		if err := repo.UnmarkReminderSent(ctx, taskID, dueDate); err != nil {
+			t.Fatalf("UnmarkReminderSent: %v", err)
+		}
+		mark(dueDate, true)
+	})
+}
+
+// PasswordResetRepository runs the password reset repository conformance
+// tests. newRepo must return a new, empty repository on every call.
+func PasswordResetRepository(t *testing.T, newRepo func(t *testing.T) Domain.PasswordResetRepository) {
+	t.Run("CreateAndConsume", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		reset := Domain.PasswordReset{
+			UserID:    primitive.NewObjectID(),
+			TokenHash: Domain.HashResetToken("first"),
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+		}
+
+		create := func(reset Domain.PasswordReset, notBefore time.Time, want bool) {
+			t.Helper()
+			created, err := repo.CreateReset(ctx, reset, notBefore)
+			if err != nil {
+				t.Fatalf("CreateReset: %v", err)
+			}
+			if created != want {
+				t.Errorf("CreateReset(notBefore %v) = %v, want %v", notBefore.Sub(now), created, want)
+			}
+		}
+		create(reset, now.Add(-time.Minute), true)
+
+		// The first reset is too recent to be replaced.
+		second := reset
+		second.TokenHash = Domain.HashResetToken("second")
+		second.CreatedAt = now.Add(30 * time.Second)
+		create(second, now.Add(-time.Second), false)
+		if _, err := repo.ConsumeReset(ctx, second.TokenHash, now); !errors.Is(err, Domain.ErrResetTokenNotFound) {
+			t.Errorf("ConsumeReset of rejected reset: got %v, want %v", err, Domain.ErrResetTokenNotFound)
+		}
+
+		// Once it is old enough, it is replaced and its token stops working.
+		create(second, now, true)
+		if _, err := repo.ConsumeReset(ctx, reset.TokenHash, now); !errors.Is(err, Domain.ErrResetTokenNotFound) {
+			t.Errorf("ConsumeReset of replaced reset: got %v, want %v", err, Domain.ErrResetTokenNotFound)
+		}
+
+		got, err := repo.ConsumeReset(ctx, second.TokenHash, now)
+		if err != nil {
+			t.Fatalf("ConsumeReset: %v", err)
+		}
+		if got.UserID != second.UserID || got.TokenHash != second.TokenHash || !got.CreatedAt.Equal(second.CreatedAt) || !got.ExpiresAt.Equal(second.ExpiresAt) {
+			t.Errorf("ConsumeReset = %+v, want %+v", got, second)
+		}
+		// Tokens can only be used once.
+		if _, err := repo.ConsumeReset(ctx, second.TokenHash, now); !errors.Is(err, Domain.ErrResetTokenNotFound) {
+			t.Errorf("ConsumeReset twice: got %v, want %v", err, Domain.ErrResetTokenNotFound)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		reset := Domain.PasswordReset{
+			UserID:    primitive.NewObjectID(),
+			TokenHash: Domain.HashResetToken("token"),
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+		}
+		if _, err := repo.CreateReset(ctx, reset, now); err != nil {
+			t.Fatalf("CreateReset: %v", err)
+		}
+		if _, err := repo.ConsumeReset(ctx, reset.TokenHash, reset.ExpiresAt); !errors.Is(err, Domain.ErrResetTokenNotFound) {
+			t.Errorf("ConsumeReset after expiry: got %v, want %v", err, Domain.ErrResetTokenNotFound)
+		}
+	})
+}
+
+// LoginAttemptStore runs the login attempt store conformance tests.
+// newStore must return a new, empty store on every call.
+func LoginAttemptStore(t *testing.T, newStore func(t *testing.T) Domain.LoginAttemptStore) {
+	t.Run("ReserveAndReset", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+
+		get := func(key string, now time.Time, want int) Domain.LoginAttempts {
+			t.Helper()
+			attempts, err := store.GetAttempts(ctx, key, now)
+			if err != nil {
+				t.Fatalf("GetAttempts: %v", err)
+			}
+			if attempts.Failures != want {
+				t.Errorf("GetAttempts(%s).Failures = %d, want %d", key, attempts.Failures, want)
+			}
+			return attempts
+		}
+		reserve := func(key string, seen Domain.LoginAttempts, now time.Time, want bool) {
+			t.Helper()
+			reserved, err := store.ReserveAttempt(ctx, key, seen, now, now.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("ReserveAttempt: %v", err)
+			}
+			if reserved != want {
+				t.Errorf("ReserveAttempt(%s, %d failures) = %v, want %v", key, seen.Failures, reserved, want)
+			}
+		}
+
+		none := get("user:alice", now, 0)
+		reserve("user:alice", none, now, true)
+		// The record has changed since none was read.
+		reserve("user:alice", none, now, false)
+		one := get("user:alice", now, 1)
+		if !one.LastFailure.Equal(now) || !one.ExpiresAt.Equal(now.Add(time.Hour)) {
+			t.Errorf("GetAttempts = %+v, want the failure at %v, expiring an hour later", one, now)
+		}
+		reserve("user:alice", one, now.Add(time.Second), true)
+		reserve("user:alice", one, now.Add(time.Second), false)
+		get("user:alice", now.Add(time.Second), 2)
+
+		// Failures are forgotten once they expire.
+		expired := get("user:alice", now.Add(time.Hour+time.Second), 0)
+		reserve("user:alice", expired, now.Add(2*time.Hour), true)
+		reserve("ip:192.0.2.1", get("ip:192.0.2.1", now.Add(2*time.Hour), 0), now.Add(2*time.Hour), true)
+		two := get("ip:192.0.2.1", now.Add(2*time.Hour), 1)
+		reserve("ip:192.0.2.1", two, now.Add(2*time.Hour), true)
+
+		// Released attempts no longer count.
+		if err := store.ReleaseAttempt(ctx, "ip:192.0.2.1"); err != nil {
+			t.Fatalf("ReleaseAttempt: %v", err)
+		}
+		get("ip:192.0.2.1", now.Add(2*time.Hour), 1)
+		if err := store.ReleaseAttempt(ctx, "ip:192.0.2.1"); err != nil {
+			t.Fatalf("ReleaseAttempt: %v", err)
+		}
+		released := get("ip:192.0.2.1", now.Add(2*time.Hour), 0)
+		if err := store.ReleaseAttempt(ctx, "ip:192.0.2.1"); err != nil {
+			t.Fatalf("ReleaseAttempt: %v", err)
+		}
+		get("ip:192.0.2.1", now.Add(2*time.Hour), 0)
+		reserve("ip:192.0.2.1", released, now.Add(2*time.Hour), true)
+		if err := store.ReleaseAttempt(ctx, "ip:192.0.2.9"); err != nil {
+			t.Errorf("ReleaseAttempt of unknown key: %v", err)
+		}
+
+		if err := store.ResetAttempts(ctx, "user:alice"); err != nil {
+			t.Fatalf("ResetAttempts: %v", err)
+		}
+		get("user:alice", now.Add(2*time.Hour), 0)
+		get("ip:192.0.2.1", now.Add(2*time.Hour), 1)
+		if err := store.ResetAttempts(ctx, "user:nobody"); err != nil {
+			t.Errorf("ResetAttempts of unknown key: %v", err)
+		}
+	})
+
+	t.Run("ConcurrentReservations", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+
+		// Attempts that all saw the same record reserve it only once.
+		seen, err := store.GetAttempts(ctx, "user:alice", now)
+		if err != nil {
+			t.Fatalf("GetAttempts: %v", err)
+		}
+		var wg sync.WaitGroup
+		var reserved atomic.Int32
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := store.ReserveAttempt(ctx, "user:alice", seen, now, now.Add(time.Hour))
+				if err != nil {
+					t.Errorf("ReserveAttempt: %v", err)
+				}
+				if ok {
+					reserved.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if got := reserved.Load(); got != 1 {
+			t.Errorf("%d concurrent reservations succeeded, want 1", got)
+		}
+	})
+}
+
+// RevocationStore runs the revocation store conformance tests. newStore
+// must return a new, empty store on every call.
+func RevocationStore(t *testing.T, newStore func(t *testing.T) Domain.RevocationStore) {
+	t.Run("RevokeAndCheck", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+
+		revoked := func(want bool, ids ...string) {
+			t.Helper()
+			got, err := store.IsRevoked(ctx, ids...)
+			if err != nil {
+				t.Fatalf("IsRevoked: %v", err)
+			}
+			if got != want {
+				t.Errorf("IsRevoked(%q) = %v, want %v", ids, got, want)
+			}
+		}
+		revoke := func(id string, expiresAt time.Time, want bool) {
+			t.Helper()
+			newly, err := store.Revoke(ctx, id, expiresAt)
+			if err != nil {
+				t.Fatalf("Revoke: %v", err)
+			}
+			if newly != want {
+				t.Errorf("Revoke(%s) = %v, want %v", id, newly, want)
+			}
+		}
+
+		revoked(false, "token-1")
+		revoked(false)
+		revoke("token-1", expiresAt, true)
+		revoke("token-1", expiresAt, false)
+		revoked(true, "token-1")
+		// Any one of several ids is enough, e.g. a token or its family.
+		revoked(true, "token-2", "token-1")
+		revoked(false, "token-2", "family-2")
+		revoke("family-2", expiresAt, true)
+		revoked(true, "token-2", "family-2")
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+
+		if _, err := store.Revoke(ctx, "expired", now.Add(-time.Second)); err != nil {
+			t.Fatalf("Revoke: %v", err)
+		}
+		if _, err := store.Revoke(ctx, "expiring", now.Add(200*time.Millisecond)); err != nil {
+			t.Fatalf("Revoke: %v", err)
+		}
+		if got, err := store.IsRevoked(ctx, "expired"); err != nil || got {
+			t.Errorf("IsRevoked of an expired id = %v, %v; want false", got, err)
+		}
+		if got, err := store.IsRevoked(ctx, "expiring"); err != nil || !got {
+			t.Errorf("IsRevoked before expiry = %v, %v; want true", got, err)
+		}
+		time.Sleep(time.Until(now.Add(300 * time.Millisecond)))
+		if got, err := store.IsRevoked(ctx, "expiring", "expired"); err != nil || got {
+			t.Errorf("IsRevoked after expiry = %v, %v; want false", got, err)
+		}
+	})
+}
+
+// WebhookRepository runs the conformance tests for Domain.WebhookRepository
+// against the repositories returned by newRepo.
+func WebhookRepository(t *testing.T, newRepo func(t *testing.T) Domain.WebhookRepository) {
+	t.Run("Subscriptions", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		sub, err := repo.CreateSubscription(ctx, Domain.WebhookSubscription{
+			URL:       "http://localhost:9999/hook",
+			Secret:    "0123456789abcdef",
+			Events:    []Domain.TaskEventType{Domain.TaskCreated, Domain.TaskCompleted},
+			CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+		})
+		if err != nil {
+			t.Fatalf("CreateSubscription: %v", err)
+		}
+		if sub.ID.IsZero() {
+			t.Fatal("CreateSubscription did not assign an ID")
+		}
+
+		got, err := repo.GetSubscription(ctx, sub.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetSubscription: %v", err)
+		}
+		if got.URL != sub.URL || got.Secret != sub.Secret || len(got.Events) != 2 || !got.CreatedAt.Equal(sub.CreatedAt) {
+			t.Errorf("GetSubscription = %+v, want %+v", got, sub)
+		}
+		subs, err := repo.ListSubscriptions(ctx)
+		if err != nil {
+			t.Fatalf("ListSubscriptions: %v", err)
+		}
+		if len(subs) != 1 || subs[0].ID != sub.ID {
+			t.Errorf("ListSubscriptions = %+v, want [%s]", subs, sub.ID.Hex())
+		}
+
+		if _, err := repo.GetSubscription(ctx, "not-an-id"); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetSubscription with invalid ID: got %v, want %v", err, Domain.ErrValidation)
+		}
+		if err := repo.DeleteSubscription(ctx, sub.ID.Hex()); err != nil {
+			t.Fatalf("DeleteSubscription: %v", err)
+		}
+		if _, err := repo.GetSubscription(ctx, sub.ID.Hex()); !errors.Is(err, Domain.ErrWebhookNotFound) {
+			t.Errorf("GetSubscription after delete: got %v, want %v", err, Domain.ErrWebhookNotFound)
+		}
+		if err := repo.DeleteSubscription(ctx, sub.ID.Hex()); !errors.Is(err, Domain.ErrWebhookNotFound) {
+			t.Errorf("DeleteSubscription twice: got %v, want %v", err, Domain.ErrWebhookNotFound)
+		}
+	})
+
+	t.Run("Deliveries", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		subID, otherID := primitive.NewObjectID(), primitive.NewObjectID()
+
+		delivery := func(subID primitive.ObjectID, created time.Duration, next *time.Time) Domain.WebhookDelivery {
+			return Domain.WebhookDelivery{
+				SubscriptionID: subID,
+				EventID:        primitive.NewObjectID(),
+				EventType:      Domain.TaskCreated,
+				Payload:        `{"type":"task.created"}`,
+				Status:         Domain.DeliveryPending,
+				NextAttemptAt:  next,
+				CreatedAt:      now.Add(created),
+			}
+		}
+		past, future := now.Add(-time.Minute), now.Add(time.Hour)
+		created, err := repo.CreateDeliveries(ctx, []Domain.WebhookDelivery{
+			delivery(subID, -3*time.Second, &past),
+			delivery(subID, -2*time.Second, &now),
+			delivery(otherID, -time.Second, &future),
+		})
+		if err != nil {
+			t.Fatalf("CreateDeliveries: %v", err)
+		}
+		if len(created) != 3 || created[0].ID.IsZero() || created[0].ID == created[1].ID {
+			t.Fatalf("CreateDeliveries did not assign IDs: %+v", created)
+		}
+
+		page, err := repo.ListDeliveries(ctx, Domain.WebhookDeliveryQuery{SubscriptionID: subID.Hex(), Limit: 10})
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		if page.Total != 2 || len(page.Deliveries) != 2 || page.Deliveries[0].ID != created[1].ID {
+			t.Errorf("ListDeliveries by subscription = %+v, want newest of 2 first", page)
+		}
+
+		// Only due deliveries are claimed, oldest first, and a claimed
+		// delivery is not claimed again until its lease has expired.
+		claimed, err := repo.ClaimDueDeliveries(ctx, now, 1, time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimDueDeliveries: %v", err)
+		}
+		if len(claimed) != 1 || claimed[0].ID != created[0].ID {
+			t.Fatalf("ClaimDueDeliveries = %+v, want %s", claimed, created[0].ID.Hex())
+		}
+		claimed, err = repo.ClaimDueDeliveries(ctx, now, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimDueDeliveries: %v", err)
+		}
+		if len(claimed) != 1 || claimed[0].ID != created[1].ID {
+			t.Fatalf("ClaimDueDeliveries again = %+v, want %s", claimed, created[1].ID.Hex())
+		}
+		if claimed, _ := repo.ClaimDueDeliveries(ctx, now, 10, time.Minute); len(claimed) != 0 {
+			t.Errorf("ClaimDueDeliveries with nothing due = %+v, want none", claimed)
+		}
+
+		dead := claimed[0]
+		dead.Status = Domain.DeliveryDead
+		dead.Attempts = 3
+		dead.NextAttemptAt = nil
+		dead.LastStatusCode = 500
+		dead.LastError = "unexpected status 500"
+		if _, err := repo.UpdateDelivery(ctx, dead); err != nil {
+			t.Fatalf("UpdateDelivery: %v", err)
+		}
+		got, err := repo.GetDelivery(ctx, dead.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetDelivery: %v", err)
+		}
+		if got.Status != Domain.DeliveryDead || got.Attempts != 3 || got.NextAttemptAt != nil || got.LastStatusCode != 500 {
+			t.Errorf("GetDelivery after update = %+v, want %+v", got, dead)
+		}
+		page, err = repo.ListDeliveries(ctx, Domain.WebhookDeliveryQuery{Status: Domain.DeliveryDead, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		if page.Total != 1 || page.Deliveries[0].ID != dead.ID {
+			t.Errorf("ListDeliveries of dead deliveries = %+v, want [%s]", page, dead.ID.Hex())
+		}
+		if _, err := repo.GetDelivery(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, Domain.ErrDeliveryNotFound) {
+			t.Errorf("GetDelivery of missing delivery: got %v, want %v", err, Domain.ErrDeliveryNotFound)
+		}
+	})
+}
+
+// CommentRepository runs the conformance tests for Domain.CommentRepository
+// against the repositories returned by newRepo.
+func CommentRepository(t *testing.T, newRepo func(t *testing.T) Domain.CommentRepository) {
+	t.Run("CRUD", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+
+		created, err := repo.CreateComment(ctx, Domain.Comment{
+			TaskID:         primitive.NewObjectID(),
+			AuthorID:       primitive.NewObjectID(),
+			AuthorUsername: "alice",
+			Body:           "First!",
+			CreatedAt:      now,
+		})
+		if err != nil {
+			t.Fatalf("CreateComment: %v", err)
+		}
+		if created.ID.IsZero() {
+			t.Fatal("CreateComment did not assign an ID")
+		}
+
+		edited := now.Add(time.Minute)
+		created.Body = "Edited"
+		created.UpdatedAt = &edited
+		created.AuthorUsername = "mallory"
+		if _, err := repo.UpdateComment(ctx, created); err != nil {
+			t.Fatalf("UpdateComment: %v", err)
+		}
+		got, err := repo.GetComment(ctx, created.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetComment: %v", err)
+		}
+		if got.Body != "Edited" || got.UpdatedAt == nil || !got.UpdatedAt.Equal(edited) || got.AuthorUsername != "alice" || !got.CreatedAt.Equal(now) {
+			t.Errorf("GetComment after update = %+v, want edited body and original author", got)
+		}
+
+		if err := repo.DeleteComment(ctx, created.ID.Hex()); err != nil {
+			t.Fatalf("DeleteComment: %v", err)
+		}
+		if _, err := repo.GetComment(ctx, created.ID.Hex()); !errors.Is(err, Domain.ErrCommentNotFound) {
+			t.Errorf("GetComment after delete: got %v, want %v", err, Domain.ErrCommentNotFound)
+		}
+		if err := repo.DeleteComment(ctx, created.ID.Hex()); !errors.Is(err, Domain.ErrCommentNotFound) {
+			t.Errorf("DeleteComment twice: got %v, want %v", err, Domain.ErrCommentNotFound)
+		}
+		if _, err := repo.UpdateComment(ctx, created); !errors.Is(err, Domain.ErrCommentNotFound) {
+			t.Errorf("UpdateComment of missing comment: got %v, want %v", err, Domain.ErrCommentNotFound)
+		}
+		if _, err := repo.GetComment(ctx, "not-an-id"); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetComment with invalid ID: got %v, want %v", err, Domain.ErrValidation)
+		}
+	})
+
+	t.Run("ListAndDeleteTaskComments", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		taskID, otherID, thirdID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+		for i, body := range []string{"one", "two", "three"} {
+			if _, err := repo.CreateComment(ctx, Domain.Comment{TaskID: taskID, Body: body, CreatedAt: now.Add(time.Duration(i) * time.Second)}); err != nil {
+				t.Fatalf("CreateComment: %v", err)
+			}
+		}
+		for _, id := range []primitive.ObjectID{otherID, thirdID} {
+			if _, err := repo.CreateComment(ctx, Domain.Comment{TaskID: id, Body: "other", CreatedAt: now}); err != nil {
+				t.Fatalf("CreateComment: %v", err)
+			}
+		}
+
+		page, err := repo.ListComments(ctx, Domain.CommentQuery{TaskID: taskID.Hex(), Limit: 2, Offset: 1})
+		if err != nil {
+			t.Fatalf("ListComments: %v", err)
+		}
+		if page.Total != 3 || len(page.Comments) != 2 || page.Comments[0].Body != "two" || page.Comments[1].Body != "three" {
+			t.Errorf("ListComments = %+v, want [two three] of 3", page)
+		}
+
+		deleted, err := repo.DeleteTaskComments(ctx, taskID, thirdID)
+		if err != nil {
+			t.Fatalf("DeleteTaskComments: %v", err)
+		}
+		if deleted != 4 {
+			t.Errorf("DeleteTaskComments = %d, want 4", deleted)
+		}
+		if deleted, err := repo.DeleteTaskComments(ctx); err != nil || deleted != 0 {
+			t.Errorf("DeleteTaskComments without tasks = %d, %v; want 0", deleted, err)
+		}
+		for id, want := range map[primitive.ObjectID]int64{taskID: 0, otherID: 1, thirdID: 0} {
+			page, err := repo.ListComments(ctx, Domain.CommentQuery{TaskID: id.Hex(), Limit: 10})
+			if err != nil {
+				t.Fatalf("ListComments: %v", err)
+			}
+			if page.Total != want {
+				t.Errorf("ListComments(%s) after DeleteTaskComments: total %d, want %d", id.Hex(), page.Total, want)
+			}
+		}
+	})
+}
+
+// ProjectRepository runs the conformance tests for Domain.ProjectRepository
+// against the repositories returned by newRepo.
+func ProjectRepository(t *testing.T, newRepo func(t *testing.T) Domain.ProjectRepository) {
+	t.Run("CRUD", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		ownerID, memberID := primitive.NewObjectID(), primitive.NewObjectID()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+
+		created, err := repo.CreateProject(ctx, Domain.Project{Name: "Launch", OwnerID: ownerID, CreatedAt: now, UpdatedAt: now})
+		if err != nil {
+			t.Fatalf("CreateProject: %v", err)
+		}
+		if created.ID.IsZero() || created.Members == nil {
+			t.Fatalf("CreateProject = %+v, want an ID and empty members", created)
+		}
+		got, err := repo.GetProject(ctx, created.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetProject: %v", err)
+		}
+		if got.Name != "Launch" || got.OwnerID != ownerID || !got.CreatedAt.Equal(now) {
+			t.Errorf("GetProject = %+v, want the created project", got)
+		}
+
+		created.Name = "Launch v2"
+		created.Members = []primitive.ObjectID{memberID}
+		created.Archived = true
+		updated, err := repo.UpdateProject(ctx, created)
+		if err != nil {
+			t.Fatalf("UpdateProject: %v", err)
+		}
+		if updated.Name != "Launch v2" || !updated.Archived || !updated.HasMember(memberID.Hex()) || updated.OwnerID != ownerID {
+			t.Errorf("UpdateProject = %+v, want renamed, archived, with member", updated)
+		}
+
+		if err := repo.DeleteProject(ctx, created.ID.Hex()); err != nil {
+			t.Fatalf("DeleteProject: %v", err)
+		}
+		if _, err := repo.GetProject(ctx, created.ID.Hex()); !errors.Is(err, Domain.ErrProjectNotFound) {
+			t.Errorf("GetProject after delete: got %v, want %v", err, Domain.ErrProjectNotFound)
+		}
+		if err := repo.DeleteProject(ctx, created.ID.Hex()); !errors.Is(err, Domain.ErrProjectNotFound) {
+			t.Errorf("DeleteProject twice: got %v, want %v", err, Domain.ErrProjectNotFound)
+		}
+		if _, err := repo.UpdateProject(ctx, created); !errors.Is(err, Domain.ErrProjectNotFound) {
+			t.Errorf("UpdateProject after delete: got %v, want %v", err, Domain.ErrProjectNotFound)
+		}
+		if _, err := repo.GetProject(ctx, "not-an-id"); !errors.Is(err, Domain.ErrValidation) {
+			t.Errorf("GetProject with invalid ID: got %v, want %v", err, Domain.ErrValidation)
+		}
+	})
+
+	t.Run("ListAndMembership", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		alice, bob := primitive.NewObjectID(), primitive.NewObjectID()
+		create := func(name string, ownerID primitive.ObjectID, archived bool, members ...primitive.ObjectID) Domain.Project {
+			project, err := repo.CreateProject(ctx, Domain.Project{Name: name, OwnerID: ownerID, Members: members, Archived: archived})
+			if err != nil {
+				t.Fatalf("CreateProject %s: %v", name, err)
+			}
+			return project
+		}
+		owned := create("b owned", alice, false)
+		shared := create("a shared", bob, true, alice)
+		create("c other", bob, false)
+
+		page, err := repo.ListProjects(ctx, Domain.ProjectQuery{MemberID: alice.Hex(), Limit: 10})
+		if err != nil {
+			t.Fatalf("ListProjects: %v", err)
+		}
+		if page.Total != 2 || len(page.Projects) != 2 || page.Projects[0].ID != shared.ID || page.Projects[1].ID != owned.ID {
+			t.Errorf("ListProjects for member = %+v, want [a shared, b owned]", page)
+		}
+		archived := false
+		page, err = repo.ListProjects(ctx, Domain.ProjectQuery{Archived: &archived, Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("ListProjects: %v", err)
+		}
+		if page.Total != 2 || len(page.Projects) != 1 || page.Projects[0].Name != "c other" {
+			t.Errorf("ListProjects unarchived, second page = %+v, want [c other] of 2", page)
+		}
+
+		ids, err := repo.MemberProjectIDs(ctx, alice.Hex())
+		if err != nil {
+			t.Fatalf("MemberProjectIDs: %v", err)
+		}
+		if len(ids) != 2 || !slices.Contains(ids, owned.ID) || !slices.Contains(ids, shared.ID) {
+			t.Errorf("MemberProjectIDs = %v, want %s and %s", ids, owned.ID.Hex(), shared.ID.Hex())
+		}
+		if ids, err := repo.MemberProjectIDs(ctx, primitive.NewObjectID().Hex()); err != nil || len(ids) != 0 {
+			t.Errorf("MemberProjectIDs for a stranger = %v, %v; want none", ids, err)
+		}
+	})
+}
+
+// mongoUnavailable remembers why MongoDatabase skipped, so that later
+// tests skip right away instead of waiting for the server again.
+var mongoUnavailable struct {
+	sync.Mutex
+	reason string
+}
+
+// MongoDatabase connects to the MongoDB server at MONGODB_TEST_URI (default
+// mongodb://localhost:27017) and returns a client and the name of a fresh
+// database that is dropped when the test ends. The test is skipped when no
+// server is reachable.
+func MongoDatabase(t *testing.T) (*mongo.Client, string) {
+	t.Helper()
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+	mongoUnavailable.Lock()
+	defer mongoUnavailable.Unlock()
+	if mongoUnavailable.reason != "" {
+		t.Skip(mongoUnavailable.reason)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetServerSelectionTimeout(2*time.Second))
+	if err != nil {
+		mongoUnavailable.reason = fmt.Sprintf("MongoDB not available: %v", err)
+		t.Skip(mongoUnavailable.reason)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		mongoUnavailable.reason = fmt.Sprintf("MongoDB not available at %s: %v", uri, err)
+		t.Skip(mongoUnavailable.reason)
+	}
+
+	dbName := "task_manager_test_" + primitive.NewObjectID().Hex()
+	t.Cleanup(func() {
+		client.Database(dbName).Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	return client, dbName
+}
+
+// newTask returns a valid pending task due d from now.
+func newTask(title string, d time.Duration, ownerID primitive.ObjectID) Domain.Task {
+	return Domain.Task{
+		Title:       title,
+		Description: "conformance test task",
+		DueDate:     time.Now().Add(d).UTC().Truncate(time.Millisecond),
+		Status:      Domain.Pending,
+		OwnerID:     ownerID,
+	}
+}
+
+// taskTitles returns the titles of tasks in order.
+func taskTitles(tasks []Domain.Task) []string {
+	titles := make([]string, len(tasks))
+	for i, task := range tasks {
+		titles[i] = task.Title
+	}
+	return titles
+}
@@ -0,0 +1,72 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// revokedToken is the stored form of a revoked token or token family ID.
+type revokedToken struct {
+	ID        string    `bson:"_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// MongoRevocationStore implements Domain.RevocationStore using MongoDB.
+// Expired entries are removed by a TTL index on expires_at.
+type MongoRevocationStore struct {
+	collection *mongo.Collection
+}
+
+// Revoke implements Domain.RevocationStore.
+func (m *MongoRevocationStore) Revoke(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := m.collection.InsertOne(ctx, revokedToken{ID: id, ExpiresAt: expiresAt})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, Domain.Internal("failed to revoke token", err)
+	}
+	return true, nil
+}
+
+// IsRevoked implements Domain.RevocationStore.
+func (m *MongoRevocationStore) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// The TTL monitor only runs once a minute, so expired entries are filtered out explicitly.
+	count, err := m.collection.CountDocuments(ctx, bson.M{
+		"_id":        bson.M{"$in": ids},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, Domain.Internal("failed to check token revocation", err)
+	}
+	return count > 0, nil
+}
+
+// NewMongoRevocationStore creates a new MongoRevocationStore
+func NewMongoRevocationStore(client *mongo.Client, dbName, collName string) Domain.RevocationStore {
+	collection := client.Database(dbName).Collection(collName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to create revocation index: %w", err))
+	}
+
+	return &MongoRevocationStore{collection: collection}
+}
@@ -0,0 +1,145 @@
+package Repositories
+
+import (
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// plannedTaskWrite is an operation of a task batch, checked against the
+// stored tasks before anything is written.
+type plannedTaskWrite struct {
+	op     Domain.BulkOperation
+	before Domain.Task
+	after  Domain.Task
+	err    error
+}
+
+// batchTaskIDs returns the IDs of the tasks that the updates and deletes of
+// batch refer to. Malformed IDs are skipped; planTaskBatch reports them.
+func batchTaskIDs(batch Domain.TaskBatch) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool)
+	var ids []primitive.ObjectID
+	for _, op := range batch.Operations {
+		if op.Op == Domain.BulkCreate {
+			continue
+		}
+		if objID, err := primitive.ObjectIDFromHex(op.ID); err == nil && !seen[objID] {
+			seen[objID] = true
+			ids = append(ids, objID)
+		}
+	}
+	return ids
+}
+
+// planTaskBatch checks every operation of batch against live, the stored
+// live tasks it refers to, and computes the task each one writes. The
+// operations are applied in order to a copy of live, so that an operation
+// sees the effect of earlier operations on the same task.
+func planTaskBatch(batch Domain.TaskBatch, live map[primitive.ObjectID]Domain.Task, now time.Time) []plannedTaskWrite {
+	var ownerID primitive.ObjectID
+	if batch.VisibleTo != "" {
+		ownerID, _ = primitive.ObjectIDFromHex(batch.VisibleTo)
+	}
+	state := make(map[primitive.ObjectID]Domain.Task, len(live))
+	for objID, task := range live {
+		state[objID] = task
+	}
+
+	plan := make([]plannedTaskWrite, len(batch.Operations))
+	for i, op := range batch.Operations {
+		p := &plan[i]
+		p.op = op
+		if op.Op == Domain.BulkCreate {
+			p.after = op.Task
+			p.after.ID = primitive.NewObjectID()
+			p.after.Version = 1
+			p.after.DeletedAt = nil
+			continue
+		}
+		if !op.Op.IsValid() {
+			p.err = Domain.NewValidationError("op", fmt.Sprintf("invalid operation: %s", op.Op))
+			continue
+		}
+		if op.Op == Domain.BulkUpdate {
+			// Only the fields copied below are written for an update.
+			if err := op.Task.ValidateBulkUpdate(); err != nil {
+				p.err = err
+				continue
+			}
+		}
+
+		objID, err := parseID("id", op.ID)
+		if err != nil {
+			p.err = err
+			continue
+		}
+		stored, exists := state[objID]
+		if !exists || (batch.VisibleTo != "" && !stored.VisibleTo(ownerID, batch.VisibleProjects)) {
+			p.err = fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, op.ID)
+			continue
+		}
+		if op.Version != 0 && op.Version != stored.Version {
+			p.err = fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, op.ID)
+			continue
+		}
+
+		p.before = stored
+		p.after = stored
+		p.after.Version++
+		switch op.Op {
+		case Domain.BulkUpdate:
+			p.after.Title = op.Task.Title
+			p.after.Description = op.Task.Description
+			p.after.Status = op.Task.Status
+			p.after.DueDate = op.Task.DueDate
+			if p.after.Status != stored.Status {
+				p.after.EnterStatus(now)
+			}
+			state[objID] = p.after
+		case Domain.BulkDelete:
+			deletedAt := now
+			p.after.DeletedAt = &deletedAt
+			delete(state, objID)
+		}
+	}
+	return plan
+}
+
+// planFailed reports whether any operation of plan failed.
+func planFailed(plan []plannedTaskWrite) bool {
+	for _, p := range plan {
+		if p.err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// batchResults turns a plan into the results of WriteTasks. When aborted,
+// none of the operations has been applied.
+func batchResults(plan []plannedTaskWrite, aborted bool) []Domain.BulkResult {
+	results := make([]Domain.BulkResult, len(plan))
+	for i, p := range plan {
+		// Failed creates have no ID; failed updates and deletes keep the
+		// ID they referred to, if it is well-formed.
+		var target Domain.Task
+		if p.op.Op != Domain.BulkCreate {
+			target.ID, _ = primitive.ObjectIDFromHex(p.op.ID)
+		}
+		switch {
+		case p.err != nil:
+			results[i] = Domain.BulkResult{Task: target, Err: p.err}
+		case aborted:
+			results[i] = Domain.BulkResult{Task: target, Err: Domain.ErrBatchAborted}
+		case p.op.Op == Domain.BulkCreate:
+			results[i] = Domain.BulkResult{Task: p.after}
+		default:
+			before := p.before
+			results[i] = Domain.BulkResult{Task: p.after, Previous: &before}
+		}
+	}
+	return results
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"task_manager/Domain"
@@ -16,117 +20,762 @@ import (
 // MongoTaskRepository implements Domain.TaskRepository using MongoDB.
 type MongoTaskRepository struct {
 	collection *mongo.Collection
+	// noTransactions is set once the server has turned out not to support
+	// multi-document transactions (e.g. a standalone mongod).
+	noTransactions atomic.Bool
 }
 
 // CreateTask implements Domain.TaskRepository.
 func (m *MongoTaskRepository) CreateTask(ctx context.Context, task Domain.Task) (Domain.Task, error) {
 	task.ID = primitive.NewObjectID()
+	task.Version = 1
 	_, err := m.collection.InsertOne(ctx, task)
 	if err != nil {
-		return Domain.Task{}, fmt.Errorf("failed to create task: %w", err)
+		if mongo.IsDuplicateKeyError(err) {
+			return Domain.Task{}, Domain.ErrOccurrenceExists
+		}
+		return Domain.Task{}, Domain.Internal("failed to create task", err)
 	}
 
 	return task, nil
 }
 
+// WriteTasks implements Domain.TaskRepository. Creates are inserted with
+// InsertMany and updates and deletes applied with a single BulkWrite. Atomic
+// batches run in a multi-document transaction where the server supports
+// them; otherwise every operation is checked before anything is written, so
+// only a concurrent change to one of the tasks can still leave the batch
+// partially applied.
+func (m *MongoTaskRepository) WriteTasks(ctx context.Context, batch Domain.TaskBatch) ([]Domain.BulkResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if !batch.Atomic || m.noTransactions.Load() {
+		plan, err := m.writeBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		return batchResults(plan, batch.Atomic && planFailed(plan)), nil
+	}
+
+	session, err := m.collection.Database().Client().StartSession()
+	if err != nil {
+		return nil, Domain.Internal("failed to start session", err)
+	}
+	defer session.EndSession(ctx)
+
+	var plan []plannedTaskWrite
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		var err error
+		if plan, err = m.writeBatch(sc, batch); err != nil {
+			return nil, err
+		}
+		if planFailed(plan) {
+			return nil, errRollback
+		}
+		return nil, nil
+	})
+	switch {
+	case err == nil:
+		return batchResults(plan, false), nil
+	case errors.Is(err, errRollback):
+		return batchResults(plan, true), nil
+	case isTransactionsUnsupported(err):
+		log.Println("MongoDB does not support transactions; atomic bulk writes are only checked before writing")
+		m.noTransactions.Store(true)
+		return m.WriteTasks(ctx, batch)
+	default:
+		return nil, Domain.Internal("failed to write tasks", err)
+	}
+}
+
+// writeBatch plans batch against the stored tasks and writes the operations
+// that can be applied. For atomic batches nothing is written if any
+// operation fails the checks. Operations whose task was changed concurrently
+// between planning and writing fail with Domain.ErrVersionMismatch.
+func (m *MongoTaskRepository) writeBatch(ctx context.Context, batch Domain.TaskBatch) ([]plannedTaskWrite, error) {
+	live := make(map[primitive.ObjectID]Domain.Task)
+	if ids := batchTaskIDs(batch); len(ids) > 0 {
+		cursor, err := m.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "deleted_at": nil})
+		if err != nil {
+			return nil, Domain.Internal("failed to fetch tasks", err)
+		}
+		var tasks []Domain.Task
+		if err := cursor.All(ctx, &tasks); err != nil {
+			return nil, Domain.Internal("failed to decode tasks", err)
+		}
+		for _, task := range tasks {
+			live[task.ID] = task
+		}
+	}
+
+	plan := planTaskBatch(batch, live, time.Now().UTC().Truncate(time.Millisecond))
+	if batch.Atomic && planFailed(plan) {
+		return plan, nil
+	}
+
+	var (
+		docs       []interface{}
+		docIndex   []int
+		models     []mongo.WriteModel
+		modelIndex []int
+	)
+	for i, p := range plan {
+		if p.err != nil {
+			continue
+		}
+		if p.op.Op == Domain.BulkCreate {
+			docs = append(docs, p.after)
+			docIndex = append(docIndex, i)
+			continue
+		}
+
+		set := bson.M{"version": p.after.Version}
+		if p.op.Op == Domain.BulkDelete {
+			set["deleted_at"] = p.after.DeletedAt
+		} else {
+			// The fields planTaskBatch copies for an update; it rejects
+			// updates that set any other.
+			set["title"] = p.after.Title
+			set["description"] = p.after.Description
+			set["status"] = p.after.Status
+			set["due_date"] = p.after.DueDate
+			if p.after.StatusEnteredAt != nil {
+				set["status_entered_at"] = p.after.StatusEnteredAt
+			}
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(versionFilter(p.before.ID, p.before.Version)).
+			SetUpdate(bson.M{"$set": set}))
+		modelIndex = append(modelIndex, i)
+	}
+
+	if len(docs) > 0 {
+		_, err := m.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+		var bulkErr mongo.BulkWriteException
+		switch {
+		case errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil:
+			for _, writeErr := range bulkErr.WriteErrors {
+				plan[docIndex[writeErr.Index]].err = Domain.Internal("failed to create task", writeErr)
+			}
+		case err != nil:
+			return nil, Domain.Internal("failed to create tasks", err)
+		}
+	}
+
+	if len(models) > 0 {
+		// Ordered, because later operations may expect the version written
+		// by an earlier one.
+		result, err := m.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+		if err != nil {
+			return nil, Domain.Internal("failed to write tasks", err)
+		}
+		if result.MatchedCount < int64(len(models)) {
+			if err := m.markConcurrentChanges(ctx, plan, modelIndex); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return plan, nil
+}
+
+// markConcurrentChanges finds the planned updates and deletes whose filter
+// did not match because their task was changed after planning, and marks
+// them as failed.
+func (m *MongoTaskRepository) markConcurrentChanges(ctx context.Context, plan []plannedTaskWrite, indexes []int) error {
+	ids := make([]primitive.ObjectID, len(indexes))
+	for i, index := range indexes {
+		ids[i] = plan[index].before.ID
+	}
+	cursor, err := m.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return Domain.Internal("failed to fetch tasks", err)
+	}
+	var tasks []Domain.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return Domain.Internal("failed to decode tasks", err)
+	}
+	stored := make(map[primitive.ObjectID]Domain.Task, len(tasks))
+	for _, task := range tasks {
+		stored[task.ID] = task
+	}
+
+	// A task that was written to more than once in the batch is only
+	// checked against its final planned state.
+	final := make(map[primitive.ObjectID]Domain.Task)
+	for _, index := range indexes {
+		final[plan[index].before.ID] = plan[index].after
+	}
+	for _, index := range indexes {
+		p := &plan[index]
+		want, got := final[p.before.ID], stored[p.before.ID]
+		applied := got.Version == want.Version &&
+			(got.DeletedAt != nil) == (want.DeletedAt != nil) &&
+			got.Title == want.Title && got.Description == want.Description &&
+			got.Status == want.Status && got.DueDate.Equal(want.DueDate)
+		if !applied {
+			p.err = fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, p.op.ID)
+		}
+	}
+	return nil
+}
+
+// errRollback aborts the transaction of an atomic batch with failed
+// operations.
+var errRollback = errors.New("rollback")
+
+// isTransactionsUnsupported reports whether err means that the server does
+// not support multi-document transactions.
+func isTransactionsUnsupported(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == illegalOperationCode
+}
+
 // DeleteTask implements Domain.TaskRepository.
-func (m *MongoTaskRepository) DeleteTask(ctx context.Context, id string) error {
-	objID, err := primitive.ObjectIDFromHex(id)
+func (m *MongoTaskRepository) DeleteTask(ctx context.Context, id string, version int64) error {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = m.update(ctx, objID, id, version, bson.M{"deleted_at": time.Now().UTC()})
+	return err
+}
+
+// GetTrashedTaskByID implements Domain.TaskRepository.
+func (m *MongoTaskRepository) GetTrashedTaskByID(ctx context.Context, id string) (Domain.Task, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	var task Domain.Task
+	err = m.collection.FindOne(ctx, bson.M{"_id": objID, "deleted_at": bson.M{"$ne": nil}}).Decode(&task)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id)
+		}
+		return Domain.Task{}, Domain.Internal("failed to retrieve task", err)
+	}
+	return task, nil
+}
+
+// RestoreTask implements Domain.TaskRepository.
+func (m *MongoTaskRepository) RestoreTask(ctx context.Context, id string) (Domain.Task, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var task Domain.Task
+	err = m.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID, "deleted_at": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deleted_at": ""}, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id)
+		}
+		return Domain.Task{}, Domain.Internal("failed to restore task", err)
+	}
+	return task, nil
+}
+
+// PurgeTask implements Domain.TaskRepository.
+func (m *MongoTaskRepository) PurgeTask(ctx context.Context, id string) error {
+	objID, err := parseID("id", id)
 	if err != nil {
-		return fmt.Errorf("invalid ID format: %w", err)
+		return err
 	}
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	result, err := m.collection.DeleteOne(ctx, bson.M{"_id":objID})
+	result, err := m.collection.DeleteOne(ctx, bson.M{"_id": objID, "deleted_at": bson.M{"$ne": nil}})
 	if err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
+		return Domain.Internal("failed to purge task", err)
 	}
 	if result.DeletedCount == 0 {
-		return fmt.Errorf("task not found: %s", id)
+		return fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id)
 	}
 	return nil
 }
 
+// PurgeDeletedBefore implements Domain.TaskRepository.
+func (m *MongoTaskRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"deleted_at": bson.M{"$ne": nil, "$lt": cutoff}}
+	candidates, err := m.taskIDs(ctx, filter)
+	if err != nil {
+		return nil, Domain.Internal("failed to purge trash", err)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	if _, err := m.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": candidates}, "deleted_at": filter["deleted_at"]}); err != nil {
+		return nil, Domain.Internal("failed to purge trash", err)
+	}
+	// A task restored in the meantime is still there and was not purged.
+	remaining, err := m.taskIDs(ctx, bson.M{"_id": bson.M{"$in": candidates}})
+	if err != nil {
+		return nil, Domain.Internal("failed to purge trash", err)
+	}
+	return slices.DeleteFunc(candidates, func(id primitive.ObjectID) bool {
+		return slices.Contains(remaining, id)
+	}), nil
+}
+
+// taskIDs returns the IDs of the tasks matching filter.
+func (m *MongoTaskRepository) taskIDs(ctx context.Context, filter bson.M) ([]primitive.ObjectID, error) {
+	cursor, err := m.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids, nil
+}
+
 // GetAllTasks implements Domain.TaskRepository.
-func (m *MongoTaskRepository) GetAllTasks(ctx context.Context) ([]Domain.Task, error) {
+func (m *MongoTaskRepository) GetAllTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskPage, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	cursor, err := m.collection.Find(ctx, bson.M{})
+
+	filter, err := taskQueryFilter(query)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	total, err := m.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return Domain.TaskPage{}, Domain.Internal("failed to count tasks", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(taskQuerySort(query)).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit))
+	cursor, err := m.collection.Find(ctx, filter, findOptions)
 	if err != nil{
-		return nil, fmt.Errorf("failed to fetch tasks: %w", err)
+		return Domain.TaskPage{}, Domain.Internal("failed to fetch tasks", err)
 	}
 
 	defer cursor.Close(ctx)
-	var tasks []Domain.Task
+	tasks := []Domain.Task{}
 	if err = cursor.All(ctx, &tasks); err != nil {
-		return nil, fmt.Errorf("failed to decode tasks: %w", err)
+		return Domain.TaskPage{}, Domain.Internal("failed to decode tasks", err)
 	}
-	return tasks, nil
+	return Domain.TaskPage{Tasks: tasks, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// SearchTasks implements Domain.TaskRepository.
+func (m *MongoTaskRepository) SearchTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskSearchPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter, err := taskQueryFilter(query)
+	if err != nil {
+		return Domain.TaskSearchPage{}, err
+	}
+	filter["$text"] = bson.M{"$search": query.Text}
+	total, err := m.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return Domain.TaskSearchPage{}, Domain.Internal("failed to count search results", err)
+	}
+
+	score := bson.M{"$meta": "textScore"}
+	findOptions := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit))
+	cursor, err := m.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return Domain.TaskSearchPage{}, Domain.Internal("failed to search tasks", err)
+	}
+
+	defer cursor.Close(ctx)
+	var found []struct {
+		Task  Domain.Task `bson:",inline"`
+		Score float64     `bson:"score"`
+	}
+	if err = cursor.All(ctx, &found); err != nil {
+		return Domain.TaskSearchPage{}, Domain.Internal("failed to decode search results", err)
+	}
+	results := make([]Domain.TaskSearchResult, len(found))
+	for i, f := range found {
+		results[i] = Domain.TaskSearchResult{Task: f.Task, Score: f.Score}
+	}
+	return Domain.TaskSearchPage{Results: results, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// ListTags implements Domain.TaskRepository.
+func (m *MongoTaskRepository) ListTags(ctx context.Context, query Domain.TagQuery) ([]Domain.TagCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter, err := taskQueryFilter(Domain.TaskQuery{VisibleTo: query.VisibleTo, VisibleProjects: query.VisibleProjects, ProjectID: query.ProjectID})
+	if err != nil {
+		return nil, err
+	}
+	// The first match uses the tags index to find the tasks, the second
+	// drops their other tags after unwinding.
+	tags := bson.M{"$regex": "^" + regexp.QuoteMeta(query.Prefix)}
+	filter["tags"] = tags
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$match", Value: bson.M{"tags": tags}}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: query.Limit}},
+	}
+	cursor, err := m.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, Domain.Internal("failed to aggregate tags", err)
+	}
+
+	defer cursor.Close(ctx)
+	var rows []struct {
+		Tag   string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, Domain.Internal("failed to decode tags", err)
+	}
+	counts := make([]Domain.TagCount, len(rows))
+	for i, row := range rows {
+		counts[i] = Domain.TagCount{Tag: row.Tag, Count: row.Count}
+	}
+	return counts, nil
+}
+
+// taskQueryFilter builds the Mongo filter for a task query.
+func taskQueryFilter(query Domain.TaskQuery) (bson.M, error) {
+	filter := bson.M{"deleted_at": nil}
+	if query.Deleted {
+		filter["deleted_at"] = bson.M{"$ne": nil}
+	}
+	if query.VisibleTo != "" {
+		ownerID, err := parseID("visible_to", query.VisibleTo)
+		if err != nil {
+			return nil, err
+		}
+		// Tasks are visible to their assignees; tasks in a project are
+		// also visible to its members, other tasks to their owner, as in
+		// Domain.Task.VisibleTo.
+		filter["$or"] = bson.A{
+			bson.M{"assignees": ownerID},
+			bson.M{"owner_id": ownerID, "project_id": nil},
+			bson.M{"project_id": bson.M{"$in": append([]primitive.ObjectID{}, query.VisibleProjects...)}},
+		}
+	}
+	if query.SeriesID != "" {
+		seriesID, err := parseID("series_id", query.SeriesID)
+		if err != nil {
+			return nil, err
+		}
+		filter["series_id"] = seriesID
+	}
+	if query.ParentID != "" {
+		parentID, err := parseID("parent_id", query.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		filter["parent_id"] = parentID
+	}
+	if query.ProjectID != "" {
+		projectID, err := parseID("project_id", query.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		filter["project_id"] = projectID
+	}
+	if query.Tag != "" {
+		filter["tags"] = query.Tag
+	}
+	if query.AssigneeID != "" {
+		assigneeID, err := parseID("assignee_id", query.AssigneeID)
+		if err != nil {
+			return nil, err
+		}
+		filter["assignees"] = assigneeID
+	}
+	status := bson.M{}
+	if query.Status != "" {
+		status["$eq"] = query.Status
+	}
+	if len(query.ExcludeStatuses) > 0 {
+		status["$nin"] = query.ExcludeStatuses
+	}
+	if len(status) > 0 {
+		filter["status"] = status
+	}
+	dueDate := bson.M{}
+	if query.DueBefore != nil {
+		dueDate["$lt"] = *query.DueBefore
+	}
+	if query.DueAfter != nil {
+		dueDate["$gt"] = *query.DueAfter
+	}
+	if len(dueDate) > 0 {
+		filter["due_date"] = dueDate
+	}
+	return filter, nil
+}
+
+// taskQuerySort builds the Mongo sort document for a task query. The _id is
+// always used as a tie-breaker so that pages are stable.
+func taskQuerySort(query Domain.TaskQuery) bson.D {
+	direction := 1
+	if query.SortDesc {
+		direction = -1
+	}
+	if query.SortBy == "" {
+		return bson.D{{Key: "_id", Value: direction}}
+	}
+	return bson.D{{Key: string(query.SortBy), Value: direction}, {Key: "_id", Value: direction}}
 }
 
 // GetTaskByID implements Domain.TaskRepository.
 func (m *MongoTaskRepository) GetTaskByID(ctx context.Context, id string) (Domain.Task, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+	objID, err := parseID("id", id)
 	if err != nil{
-		return Domain.Task{}, fmt.Errorf("invalid ID format: %w", err)
+		return Domain.Task{}, err
 	}
 
 	var task Domain.Task
-	err = m.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&task)
+	err = m.collection.FindOne(ctx, bson.M{"_id": objID, "deleted_at": nil}).Decode(&task)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return Domain.Task{}, fmt.Errorf("task not found: %s", id)
+			return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id)
 		}
-		return  Domain.Task{}, fmt.Errorf("failed to retrieve task:%w", err)
+		return  Domain.Task{}, Domain.Internal("failed to retrieve task", err)
 	}
 
 	return task, nil
 }
 
 // UpdateTask implements Domain.TaskRepository.
-func (m *MongoTaskRepository) UpdateTask(ctx context.Context, id string, task Domain.Task) (Domain.Task, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+func (m *MongoTaskRepository) UpdateTask(ctx context.Context, id string, task Domain.Task, version int64) (Domain.Task, error) {
+	objID, err := parseID("id", id)
 	if err != nil {
-		return Domain.Task{}, fmt.Errorf("invalid ID format: %w", err)
+		return Domain.Task{}, err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	update := bson.M{
-		"$set": bson.M{
-			"title":       task.Title,
-			"description": task.Description,
-			"status":      task.Status,
-			"due_date":    task.DueDate,
-		},
+	set := bson.M{
+		"title":           task.Title,
+		"description":     task.Description,
+		"status":          task.Status,
+		"due_date":        task.DueDate,
+		"recurrence":      task.Recurrence,
+		"series_id":       task.SeriesID,
+		"occurrence":      task.Occurrence,
+		"series_template": task.SeriesTemplate,
+		"parent_id":       task.ParentID,
+		"checklist":       task.Checklist,
+		"blocked_by":      task.BlockedBy,
+		"project_id":      task.ProjectID,
+		"tags":            task.Tags,
+		"assignees":       task.Assignees,
 	}
+	// Tasks written before status times were recorded have none, which
+	// must not overwrite times recorded since.
+	if task.StatusEnteredAt != nil {
+		set["status_entered_at"] = task.StatusEnteredAt
+	}
+	return m.update(ctx, objID, id, version, set)
+}
 
-	result, err := m.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
-	if err != nil{
-		return Domain.Task{}, fmt.Errorf("failed to update task: %w", err)
+// PatchTask implements Domain.TaskRepository.
+func (m *MongoTaskRepository) PatchTask(ctx context.Context, id string, patch Domain.TaskPatch, version int64) (Domain.Task, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	set := bson.M{}
+	if patch.Title != nil {
+		set["title"] = *patch.Title
+	}
+	if patch.Description != nil {
+		set["description"] = *patch.Description
 	}
+	if patch.DueDate != nil {
+		set["due_date"] = *patch.DueDate
+	}
+	if patch.Status != nil {
+		set["status"] = *patch.Status
+		if patch.EnteredStatusAt != nil {
+			set["status_entered_at."+string(*patch.Status)] = *patch.EnteredStatusAt
+		}
+	}
+	if patch.Recurrence != nil {
+		set["recurrence"] = *patch.Recurrence
+	}
+	if patch.ParentID != nil {
+		set["parent_id"] = nil
+		if !patch.ParentID.IsZero() {
+			set["parent_id"] = *patch.ParentID
+		}
+	}
+	if patch.Checklist != nil {
+		set["checklist"] = *patch.Checklist
+	}
+	if patch.BlockedBy != nil {
+		set["blocked_by"] = *patch.BlockedBy
+	}
+	if patch.ProjectID != nil {
+		set["project_id"] = nil
+		if !patch.ProjectID.IsZero() {
+			set["project_id"] = *patch.ProjectID
+		}
+	}
+	if patch.Tags != nil {
+		set["tags"] = *patch.Tags
+	}
+	if patch.Assignees != nil {
+		set["assignees"] = *patch.Assignees
+	}
+	return m.update(ctx, objID, id, version, set)
+}
 
-	task.ID = objID
-	if result.MatchedCount == 0 {
-		return Domain.Task{}, fmt.Errorf("task not found: %s", id)
+// update sets the given fields and bumps the version in a single atomic
+// operation, returning the updated task. The version check is part of the
+// update filter, so concurrent writers cannot both succeed.
+func (m *MongoTaskRepository) update(ctx context.Context, objID primitive.ObjectID, id string, version int64, set bson.M) (Domain.Task, error) {
+	update := bson.M{"$inc": bson.M{"version": 1}}
+	if len(set) > 0 {
+		update["$set"] = set
 	}
 
+	var task Domain.Task
+	err := m.collection.FindOneAndUpdate(ctx, versionFilter(objID, version), update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&task)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.Task{}, m.writeMissed(ctx, objID, id)
+		}
+		return Domain.Task{}, Domain.Internal("failed to update task", err)
+	}
 	return task, nil
 }
 
+// versionFilter matches the live task with the given ID and, if version is
+// not zero, only while its stored version still equals version.
+func versionFilter(objID primitive.ObjectID, version int64) bson.M {
+	filter := bson.M{"_id": objID, "deleted_at": nil}
+	if version != 0 {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// writeMissed explains why a conditional write matched no task: either the
+// task does not exist, or its version has moved on.
+func (m *MongoTaskRepository) writeMissed(ctx context.Context, objID primitive.ObjectID, id string) error {
+	count, err := m.collection.CountDocuments(ctx, bson.M{"_id": objID, "deleted_at": nil})
+	if err != nil {
+		return Domain.Internal("failed to retrieve task", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id)
+	}
+	return fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, id)
+}
+
+// parseID converts a hex ID into an ObjectID, reporting a malformed ID as a
+// validation error on the given field.
+func parseID(field, id string) (primitive.ObjectID, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, Domain.NewValidationError(field, "invalid ID format")
+	}
+	return objID, nil
+}
+
 // NewMongoTaskRepository creates a new MongoTaskRepository
 func NewMongoTaskRepository(client *mongo.Client, dbName, collName string) Domain.TaskRepository {
 	collection := client.Database(dbName).Collection(collName)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.M{"_id": 1},
-		Options: options.Index(),
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().
+				SetName("task_text").
+				SetDefaultLanguage("english").
+				SetWeights(bson.M{"title": Domain.TitleSearchWeight, "description": Domain.DescriptionSearchWeight}),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "due_date", Value: 1}}},
+		{Keys: bson.M{"due_date": 1}},
+		{Keys: bson.M{"parent_id": 1}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}, {Key: "due_date", Value: 1}}},
+		{Keys: bson.M{"tags": 1}},
+		{Keys: bson.D{{Key: "assignees", Value: 1}, {Key: "due_date", Value: 1}}},
+		{
+			// Makes generating the next occurrence of a series idempotent.
+			Keys: bson.D{{Key: "series_id", Value: 1}, {Key: "occurrence", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"series_id": bson.M{"$type": "objectId"}}),
+		},
 	})
 	if err != nil {
-		panic(fmt.Errorf("failed to create task index: %w", err))
+		panic(fmt.Errorf("failed to create task indexes: %w", err))
 	}
 	return &MongoTaskRepository{collection: collection}
 }
+
+// EnsureTaskTrashTTLIndex creates a TTL index on deleted_at so that MongoDB
+// itself removes trashed tasks once they have been in the trash for longer
+// than retention. Live tasks have no deleted_at and never expire. An
+// existing index with a different retention is updated in place.
+func EnsureTaskTrashTTLIndex(ctx context.Context, client *mongo.Client, dbName, collName string, retention time.Duration) error {
+	db := client.Database(dbName)
+	seconds := int32(retention.Seconds())
+	_, err := db.Collection(collName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"deleted_at": 1},
+		Options: options.Index().SetName(trashTTLIndexName).SetExpireAfterSeconds(seconds),
+	})
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == indexOptionsConflictCode {
+		err = db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collName},
+			{Key: "index", Value: bson.M{"name": trashTTLIndexName, "expireAfterSeconds": seconds}},
+		}).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create trash TTL index: %w", err)
+	}
+	return nil
+}
+
+const (
+	trashTTLIndexName        = "deleted_at_ttl"
+	illegalOperationCode     = 20
+	indexOptionsConflictCode = 85
+)
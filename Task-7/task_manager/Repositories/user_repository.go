@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"task_manager/Domain"
 	"time"
 
@@ -24,9 +25,9 @@ func (m *MongoUserRepository) CreateUser(ctx context.Context, user Domain.User)
 	_, err := m.collection.InsertOne(ctx, user)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err){
-			return Domain.User{}, fmt.Errorf("username already taken")
+			return Domain.User{}, Domain.ErrUsernameTaken
 		}
-		return Domain.User{}, fmt.Errorf("failed to create user")
+		return Domain.User{}, Domain.Internal("failed to create user", err)
 	}
 	user.Password = ""
 	return user, nil
@@ -38,22 +39,145 @@ func (m *MongoUserRepository) GetUserByUsername(ctx context.Context, username st
 	err := m.collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
 	if err != nil{
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return Domain.User{}, fmt.Errorf("user not found")
+			return Domain.User{}, Domain.ErrUserNotFound
 		}
-		return Domain.User{}, fmt.Errorf("failed to retrieve user: %w", err)
+		return Domain.User{}, Domain.Internal("failed to retrieve user", err)
 	}
 	return user, nil
 }
 
+// GetUserByID implements Domain.UserRepository.
+func (m *MongoUserRepository) GetUserByID(ctx context.Context, id string) (Domain.User, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var user Domain.User
+	if err := m.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.User{}, Domain.ErrUserNotFound
+		}
+		return Domain.User{}, Domain.Internal("failed to retrieve user", err)
+	}
+	return user, nil
+}
+
+// ListUsers implements Domain.UserRepository.
+func (m *MongoUserRepository) ListUsers(ctx context.Context, query Domain.UserQuery) (Domain.UserPage, error) {
+	filter := bson.M{}
+	if query.Search != "" {
+		filter["username"] = primitive.Regex{Pattern: regexp.QuoteMeta(query.Search), Options: "i"}
+	}
+	if query.Role != "" {
+		filter["role"] = query.Role
+	}
+	if query.Disabled != nil {
+		if *query.Disabled {
+			filter["disabled"] = true
+		} else {
+			filter["disabled"] = bson.M{"$ne": true}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	total, err := m.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return Domain.UserPage{}, Domain.Internal("failed to count users", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "username", Value: 1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit)).
+		SetProjection(bson.M{"password": 0})
+	cursor, err := m.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return Domain.UserPage{}, Domain.Internal("failed to fetch users", err)
+	}
+
+	defer cursor.Close(ctx)
+	users := []Domain.User{}
+	if err = cursor.All(ctx, &users); err != nil {
+		return Domain.UserPage{}, Domain.Internal("failed to decode users", err)
+	}
+	return Domain.UserPage{Users: users, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// UpdateUser implements Domain.UserRepository.
+func (m *MongoUserRepository) UpdateUser(ctx context.Context, user Domain.User) (Domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"role": user.Role, "disabled": user.Disabled}}
+	updateOptions := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"password": 0})
+	var updated Domain.User
+	if err := m.collection.FindOneAndUpdate(ctx, bson.M{"_id": user.ID}, update, updateOptions).Decode(&updated); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.User{}, fmt.Errorf("%w: %s", Domain.ErrUserNotFound, user.ID.Hex())
+		}
+		return Domain.User{}, Domain.Internal("failed to update user", err)
+	}
+	return updated, nil
+}
+
+// SetPassword implements Domain.UserRepository.
+func (m *MongoUserRepository) SetPassword(ctx context.Context, id string, hash string, changedAt time.Time) error {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"password": hash, "password_changed_at": changedAt}}
+	result, err := m.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return Domain.Internal("failed to set password", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: %s", Domain.ErrUserNotFound, id)
+	}
+	return nil
+}
+
+// DeleteUser implements Domain.UserRepository.
+func (m *MongoUserRepository) DeleteUser(ctx context.Context, id string) error {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := m.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return Domain.Internal("failed to delete user", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s", Domain.ErrUserNotFound, id)
+	}
+	return nil
+}
+
 // NewMongoUserRepository creates a new MongoUserRepository
 func NewMongoUserRepository(client *mongo.Client, dbName, collName string) Domain.UserRepository {
 	collection := client.Database(dbName).Collection(collName)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.M{"_id": 1},
-		Options: options.Index(),
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"_id": 1}, Options: options.Index()},
+		{Keys: bson.M{"username": 1}, Options: options.Index().SetUnique(true)},
 	})
 
 	if err != nil {
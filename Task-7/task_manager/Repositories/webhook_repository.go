@@ -0,0 +1,236 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoWebhookRepository implements Domain.WebhookRepository using MongoDB.
+type MongoWebhookRepository struct {
+	subscriptions *mongo.Collection
+	deliveries    *mongo.Collection
+}
+
+// CreateSubscription implements Domain.WebhookRepository.
+func (m *MongoWebhookRepository) CreateSubscription(ctx context.Context, sub Domain.WebhookSubscription) (Domain.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sub.ID = primitive.NewObjectID()
+	if _, err := m.subscriptions.InsertOne(ctx, sub); err != nil {
+		return Domain.WebhookSubscription{}, Domain.Internal("failed to create webhook", err)
+	}
+	return sub, nil
+}
+
+// GetSubscription implements Domain.WebhookRepository.
+func (m *MongoWebhookRepository) GetSubscription(ctx context.Context, id string) (Domain.WebhookSubscription, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.WebhookSubscription{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var sub Domain.WebhookSubscription
+	err = m.subscriptions.FindOne(ctx, bson.M{"_id": objID}).Decode(&sub)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.WebhookSubscription{}, fmt.Errorf("%w: %s", Domain.ErrWebhookNotFound, id)
+		}
+		return Domain.WebhookSubscription{}, Domain.Internal("failed to retrieve webhook", err)
+	}
+	return sub, nil
+}
+
+// ListSubscriptions implements Domain.WebhookRepository.
+func (m *MongoWebhookRepository) ListSubscriptions(ctx context.Context) ([]Domain.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := m.subscriptions.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, Domain.Internal("failed to fetch webhooks", err)
+	}
+
+	defer cursor.Close(ctx)
+	subs := []Domain.WebhookSubscription{}
+	if err = cursor.All(ctx, &subs); err != nil {
+		return nil, Domain.Internal("failed to decode webhooks", err)
+	}
+	return subs, nil
+}
+
+// DeleteSubscription implements Domain.WebhookRepository.
+func (m *MongoWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := m.subscriptions.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return Domain.Internal("failed to delete webhook", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s", Domain.ErrWebhookNotFound, id)
+	}
+	_, err = m.deliveries.DeleteMany(ctx, bson.M{"subscription_id": objID, "status": Domain.DeliveryPending})
+	if err != nil {
+		return Domain.Internal("failed to delete pending webhook deliveries", err)
+	}
+	return nil
+}
+
+// CreateDeliveries implements Domain.WebhookRepository.
+func (m *MongoWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []Domain.WebhookDelivery) ([]Domain.WebhookDelivery, error) {
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	docs := make([]interface{}, len(deliveries))
+	for i := range deliveries {
+		deliveries[i].ID = primitive.NewObjectID()
+		docs[i] = deliveries[i]
+	}
+	if _, err := m.deliveries.InsertMany(ctx, docs); err != nil {
+		return nil, Domain.Internal("failed to queue webhook deliveries", err)
+	}
+	return deliveries, nil
+}
+
+// GetDelivery implements Domain.WebhookRepository.
+func (m *MongoWebhookRepository) GetDelivery(ctx context.Context, id string) (Domain.WebhookDelivery, error) {
+	objID, err := parseID("id", id)
+	if err != nil {
+		return Domain.WebhookDelivery{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var delivery Domain.WebhookDelivery
+	err = m.deliveries.FindOne(ctx, bson.M{"_id": objID}).Decode(&delivery)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Domain.WebhookDelivery{}, fmt.Errorf("%w: %s", Domain.ErrDeliveryNotFound, id)
+		}
+		return Domain.WebhookDelivery{}, Domain.Internal("failed to retrieve webhook delivery", err)
+	}
+	return delivery, nil
+}
+
+// ListDeliveries implements Domain.WebhookRepository.
+func (m *MongoWebhookRepository) ListDeliveries(ctx context.Context, query Domain.WebhookDeliveryQuery) (Domain.WebhookDeliveryPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if query.SubscriptionID != "" {
+		subID, err := parseID("subscription_id", query.SubscriptionID)
+		if err != nil {
+			return Domain.WebhookDeliveryPage{}, err
+		}
+		filter["subscription_id"] = subID
+	}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	total, err := m.deliveries.CountDocuments(ctx, filter)
+	if err != nil {
+		return Domain.WebhookDeliveryPage{}, Domain.Internal("failed to count webhook deliveries", err)
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit))
+	cursor, err := m.deliveries.Find(ctx, filter, findOptions)
+	if err != nil {
+		return Domain.WebhookDeliveryPage{}, Domain.Internal("failed to fetch webhook deliveries", err)
+	}
+
+	defer cursor.Close(ctx)
+	deliveries := []Domain.WebhookDelivery{}
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return Domain.WebhookDeliveryPage{}, Domain.Internal("failed to decode webhook deliveries", err)
+	}
+	return Domain.WebhookDeliveryPage{Deliveries: deliveries, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+// ClaimDueDeliveries implements Domain.WebhookRepository. Deliveries are
+// claimed one at a time, so that concurrent workers never claim the same one.
+func (m *MongoWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Domain.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"status": Domain.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	findOptions := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	claimed := []Domain.WebhookDelivery{}
+	for len(claimed) < limit {
+		var delivery Domain.WebhookDelivery
+		err := m.deliveries.FindOneAndUpdate(ctx, filter, update, findOptions).Decode(&delivery)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return nil, Domain.Internal("failed to claim webhook deliveries", err)
+		}
+		claimed = append(claimed, delivery)
+	}
+	return claimed, nil
+}
+
+// UpdateDelivery implements Domain.WebhookRepository.
+func (m *MongoWebhookRepository) UpdateDelivery(ctx context.Context, delivery Domain.WebhookDelivery) (Domain.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := m.deliveries.ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery)
+	if err != nil {
+		return Domain.WebhookDelivery{}, Domain.Internal("failed to update webhook delivery", err)
+	}
+	if result.MatchedCount == 0 {
+		return Domain.WebhookDelivery{}, fmt.Errorf("%w: %s", Domain.ErrDeliveryNotFound, delivery.ID.Hex())
+	}
+	return delivery, nil
+}
+
+// NewMongoWebhookRepository creates a new MongoWebhookRepository that keeps
+// subscriptions in subsCollName and deliveries in deliveriesCollName.
+func NewMongoWebhookRepository(client *mongo.Client, dbName, subsCollName, deliveriesCollName string) Domain.WebhookRepository {
+	db := client.Database(dbName)
+	deliveries := db.Collection(deliveriesCollName)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.M{"created_at": -1}},
+	})
+	if err != nil {
+		panic(fmt.Errorf("failed to create webhook delivery indexes: %w", err))
+	}
+
+	return &MongoWebhookRepository{subscriptions: db.Collection(subsCollName), deliveries: deliveries}
+}
@@ -0,0 +1,19 @@
+package Usecase
+
+import (
+	"context"
+	"task_manager/Domain"
+)
+
+// authorize returns the actor in ctx if authz grants it every one of
+// permissions.
+func authorize(ctx context.Context, authz Domain.Authorizer, permissions ...Domain.Permission) (Domain.Actor, error) {
+	actor, ok := Domain.ActorFromContext(ctx)
+	if !ok {
+		return Domain.Actor{}, Domain.ErrNoActor
+	}
+	if err := Domain.Authorize(authz, actor, permissions...); err != nil {
+		return Domain.Actor{}, err
+	}
+	return actor, nil
+}
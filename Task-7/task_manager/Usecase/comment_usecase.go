@@ -0,0 +1,129 @@
+package Usecase
+
+import (
+	"context"
+	"fmt"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CommentUsecase defines the business logic of task comments. Actors can
+// read the comments on the tasks they can see and, with
+// Domain.PermCommentWrite, write them; only the author of a comment or an
+// actor with Domain.PermCommentModerate may edit or delete it.
+type CommentUsecase interface {
+	CreateComment(ctx context.Context, taskID string, comment Domain.Comment) (Domain.Comment, error)
+	// ListComments lists the comments on query.TaskID, oldest first.
+	ListComments(ctx context.Context, query Domain.CommentQuery) (Domain.CommentPage, error)
+	UpdateComment(ctx context.Context, taskID, id string, body string) (Domain.Comment, error)
+	DeleteComment(ctx context.Context, taskID, id string) error
+}
+
+// commentUsecase implements CommentUsecase.
+type commentUsecase struct {
+	membership
+	taskRepo    Domain.TaskRepository
+	commentRepo Domain.CommentRepository
+}
+
+// CreateComment implements CommentUsecase.
+func (u *commentUsecase) CreateComment(ctx context.Context, taskID string, comment Domain.Comment) (Domain.Comment, error) {
+	actor, task, err := u.visibleTask(ctx, taskID, Domain.PermCommentWrite)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	if err := comment.Validate(); err != nil {
+		return Domain.Comment{}, err
+	}
+	authorID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return Domain.Comment{}, Domain.NewError(Domain.ErrUnauthorized, "invalid user ID in token")
+	}
+
+	return u.commentRepo.CreateComment(ctx, Domain.Comment{
+		TaskID:         task.ID,
+		AuthorID:       authorID,
+		AuthorUsername: actor.Username,
+		Body:           comment.Body,
+		CreatedAt:      time.Now().UTC().Truncate(time.Millisecond),
+	})
+}
+
+// ListComments implements CommentUsecase.
+func (u *commentUsecase) ListComments(ctx context.Context, query Domain.CommentQuery) (Domain.CommentPage, error) {
+	if _, _, err := u.visibleTask(ctx, query.TaskID, Domain.PermTaskRead); err != nil {
+		return Domain.CommentPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return Domain.CommentPage{}, err
+	}
+	return u.commentRepo.ListComments(ctx, query)
+}
+
+// UpdateComment implements CommentUsecase.
+func (u *commentUsecase) UpdateComment(ctx context.Context, taskID, id string, body string) (Domain.Comment, error) {
+	comment, err := u.editableComment(ctx, taskID, id)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	comment.Body = body
+	if err := comment.Validate(); err != nil {
+		return Domain.Comment{}, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	comment.UpdatedAt = &now
+	return u.commentRepo.UpdateComment(ctx, comment)
+}
+
+// DeleteComment implements CommentUsecase.
+func (u *commentUsecase) DeleteComment(ctx context.Context, taskID, id string) error {
+	if _, err := u.editableComment(ctx, taskID, id); err != nil {
+		return err
+	}
+	return u.commentRepo.DeleteComment(ctx, id)
+}
+
+// visibleTask returns the actor in ctx and the live task taskID, which the
+// actor must be allowed to see. The actor must also hold permission.
+func (u *commentUsecase) visibleTask(ctx context.Context, taskID string, permission Domain.Permission) (Domain.Actor, Domain.Task, error) {
+	actor, err := u.authorize(ctx, Domain.PermTaskRead, permission)
+	if err != nil {
+		return Domain.Actor{}, Domain.Task{}, err
+	}
+	task, err := u.taskRepo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return Domain.Actor{}, Domain.Task{}, err
+	}
+	if !u.canView(ctx, actor, task) {
+		return Domain.Actor{}, Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, taskID)
+	}
+	return actor, task, nil
+}
+
+// editableComment returns comment id on task taskID if the actor in ctx may
+// edit it. Comments on other tasks are reported as not found.
+func (u *commentUsecase) editableComment(ctx context.Context, taskID, id string) (Domain.Comment, error) {
+	actor, task, err := u.visibleTask(ctx, taskID, Domain.PermCommentWrite)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	comment, err := u.commentRepo.GetComment(ctx, id)
+	if err != nil {
+		return Domain.Comment{}, err
+	}
+	if comment.TaskID != task.ID {
+		return Domain.Comment{}, fmt.Errorf("%w: %s", Domain.ErrCommentNotFound, id)
+	}
+	if comment.AuthorID.Hex() != actor.UserID && !u.authz.Can(actor, Domain.PermCommentModerate) {
+		return Domain.Comment{}, Domain.NewError(Domain.ErrForbidden, "only the author or a moderator can change a comment")
+	}
+	return comment, nil
+}
+
+// NewCommentUsecase creates a new CommentUsecase
+func NewCommentUsecase(taskRepo Domain.TaskRepository, commentRepo Domain.CommentRepository, projectRepo Domain.ProjectRepository, authz Domain.Authorizer) CommentUsecase {
+	return &commentUsecase{membership: membership{projectRepo: projectRepo, authz: authz}, taskRepo: taskRepo, commentRepo: commentRepo}
+}
@@ -0,0 +1,102 @@
+package Usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"task_manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// membership decides which tasks and projects an actor can see and change.
+// Tasks are visible to their assignees; tasks in a project are also visible
+// to the project's owner and members, other tasks to their owner. Actors
+// with Domain.PermTaskReadAny see every task, and those with
+// Domain.PermTaskUpdateAny may change every task they can see.
+type membership struct {
+	projectRepo Domain.ProjectRepository
+	authz       Domain.Authorizer
+}
+
+// authorize returns the actor in ctx if it holds every one of permissions.
+func (m membership) authorize(ctx context.Context, permissions ...Domain.Permission) (Domain.Actor, error) {
+	return authorize(ctx, m.authz, permissions...)
+}
+
+// projects returns the IDs of the projects the actor is a member of.
+func (m membership) projects(ctx context.Context, actor Domain.Actor) ([]primitive.ObjectID, error) {
+	return m.projectRepo.MemberProjectIDs(ctx, actor.UserID)
+}
+
+// canView reports whether actor may see task. A failure to look up the
+// task's project is logged and treated as not visible.
+func (m membership) canView(ctx context.Context, actor Domain.Actor, task Domain.Task) bool {
+	return m.authz.Can(actor, Domain.PermTaskReadAny) || m.isMember(ctx, actor, task)
+}
+
+// checkChange checks that actor may change task. Tasks the actor cannot
+// see are reported as not found so their existence is not leaked.
+func (m membership) checkChange(ctx context.Context, actor Domain.Actor, task Domain.Task) error {
+	if m.isMember(ctx, actor, task) {
+		return nil
+	}
+	if !m.authz.Can(actor, Domain.PermTaskReadAny) {
+		return fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, task.ID.Hex())
+	}
+	return Domain.Authorize(m.authz, actor, Domain.PermTaskUpdateAny)
+}
+
+// isMember reports whether task is visible to actor without any
+// permission beyond Domain.PermTaskRead.
+func (m membership) isMember(ctx context.Context, actor Domain.Actor, task Domain.Task) bool {
+	if slices.ContainsFunc(task.Assignees, func(id primitive.ObjectID) bool { return id.Hex() == actor.UserID }) {
+		return true
+	}
+	if task.ProjectID == nil {
+		return task.OwnerID.Hex() == actor.UserID
+	}
+	project, err := m.projectRepo.GetProject(ctx, task.ProjectID.Hex())
+	if err != nil {
+		if !errors.Is(err, Domain.ErrNotFound) {
+			log.Printf("Failed to look up project %s of task %s: %v", task.ProjectID.Hex(), task.ID.Hex(), err)
+		}
+		return false
+	}
+	return project.HasMember(actor.UserID)
+}
+
+// visibleProject returns the project id if actor may see it. Projects the
+// actor is not a member of are reported as not found.
+func (m membership) visibleProject(ctx context.Context, actor Domain.Actor, id string) (Domain.Project, error) {
+	project, err := m.projectRepo.GetProject(ctx, id)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	if !m.authz.Can(actor, Domain.PermProjectManageAny) && !project.HasMember(actor.UserID) {
+		return Domain.Project{}, fmt.Errorf("%w: %s", Domain.ErrProjectNotFound, id)
+	}
+	return project, nil
+}
+
+// checkProject checks that actor may put task in its project. existing is
+// the stored task when task replaces it; a task that stays in its project
+// is not checked again, even if the project has been archived since.
+func (m membership) checkProject(ctx context.Context, actor Domain.Actor, existing *Domain.Task, task Domain.Task) error {
+	if task.ProjectID == nil || (existing != nil && existing.ProjectID != nil && *existing.ProjectID == *task.ProjectID) {
+		return nil
+	}
+	project, err := m.visibleProject(ctx, actor, task.ProjectID.Hex())
+	if errors.Is(err, Domain.ErrNotFound) {
+		return Domain.NewValidationError("project_id", fmt.Sprintf("project %s not found", task.ProjectID.Hex()))
+	}
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		return Domain.NewValidationError("project_id", fmt.Sprintf("project %s is archived", project.ID.Hex()))
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package Usecase
+
+import (
+	"context"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProjectUsecase defines the business logic of projects. Actors see the
+// projects they own or are a member of; only the owner of a project may
+// change or delete it. Actors with Domain.PermProjectManageAny may see and
+// change every project.
+type ProjectUsecase interface {
+	CreateProject(ctx context.Context, project Domain.Project) (Domain.Project, error)
+	GetProject(ctx context.Context, id string) (Domain.Project, error)
+	ListProjects(ctx context.Context, query Domain.ProjectQuery) (Domain.ProjectPage, error)
+	// UpdateProject replaces the name, description, members and archived
+	// flag of a project.
+	UpdateProject(ctx context.Context, id string, project Domain.Project) (Domain.Project, error)
+	// DeleteProject fails with Domain.ErrProjectNotEmpty while the project
+	// has tasks, including tasks in the trash.
+	DeleteProject(ctx context.Context, id string) error
+	// ListProjectTasks lists the tasks of project id, filtered like
+	// TaskUsecase.GetAllTasks.
+	ListProjectTasks(ctx context.Context, id string, query Domain.TaskQuery) (Domain.TaskPage, error)
+}
+
+// projectUsecase implements ProjectUsecase.
+type projectUsecase struct {
+	membership
+	projectRepo Domain.ProjectRepository
+	taskRepo    Domain.TaskRepository
+}
+
+// CreateProject implements ProjectUsecase.
+func (u *projectUsecase) CreateProject(ctx context.Context, project Domain.Project) (Domain.Project, error) {
+	actor, err := u.authorize(ctx, Domain.PermProjectWrite)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	if err := project.Validate(); err != nil {
+		return Domain.Project{}, err
+	}
+	ownerID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return Domain.Project{}, Domain.NewError(Domain.ErrUnauthorized, "invalid user ID in token")
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	project.OwnerID = ownerID
+	project.CreatedAt = now
+	project.UpdatedAt = now
+	return u.projectRepo.CreateProject(ctx, project)
+}
+
+// GetProject implements ProjectUsecase.
+func (u *projectUsecase) GetProject(ctx context.Context, id string) (Domain.Project, error) {
+	actor, err := u.authorize(ctx, Domain.PermProjectRead)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	return u.visibleProject(ctx, actor, id)
+}
+
+// ListProjects implements ProjectUsecase.
+func (u *projectUsecase) ListProjects(ctx context.Context, query Domain.ProjectQuery) (Domain.ProjectPage, error) {
+	actor, err := u.authorize(ctx, Domain.PermProjectRead)
+	if err != nil {
+		return Domain.ProjectPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return Domain.ProjectPage{}, err
+	}
+
+	query.MemberID = ""
+	if !u.authz.Can(actor, Domain.PermProjectManageAny) {
+		query.MemberID = actor.UserID
+	}
+	return u.projectRepo.ListProjects(ctx, query)
+}
+
+// UpdateProject implements ProjectUsecase.
+func (u *projectUsecase) UpdateProject(ctx context.Context, id string, project Domain.Project) (Domain.Project, error) {
+	existing, err := u.ownedProject(ctx, id)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	if err := project.Validate(); err != nil {
+		return Domain.Project{}, err
+	}
+
+	existing.Name = project.Name
+	existing.Description = project.Description
+	existing.Members = project.Members
+	existing.Archived = project.Archived
+	existing.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	return u.projectRepo.UpdateProject(ctx, existing)
+}
+
+// DeleteProject implements ProjectUsecase.
+func (u *projectUsecase) DeleteProject(ctx context.Context, id string) error {
+	project, err := u.ownedProject(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, deleted := range []bool{false, true} {
+		page, err := u.taskRepo.GetAllTasks(ctx, Domain.TaskQuery{ProjectID: project.ID.Hex(), Deleted: deleted, Limit: 1})
+		if err != nil {
+			return err
+		}
+		if page.Total > 0 {
+			return Domain.ErrProjectNotEmpty
+		}
+	}
+	return u.projectRepo.DeleteProject(ctx, id)
+}
+
+// ListProjectTasks implements ProjectUsecase.
+func (u *projectUsecase) ListProjectTasks(ctx context.Context, id string, query Domain.TaskQuery) (Domain.TaskPage, error) {
+	actor, err := u.authorize(ctx, Domain.PermProjectRead, Domain.PermTaskRead)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	project, err := u.visibleProject(ctx, actor, id)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return Domain.TaskPage{}, err
+	}
+
+	// Every member can see all tasks of the project.
+	query.ProjectID = project.ID.Hex()
+	query.VisibleTo, query.VisibleProjects = "", nil
+	query.Deleted = false
+	return u.taskRepo.GetAllTasks(ctx, query)
+}
+
+// ownedProject returns project id if the actor in ctx may change it.
+func (u *projectUsecase) ownedProject(ctx context.Context, id string) (Domain.Project, error) {
+	actor, err := u.authorize(ctx, Domain.PermProjectWrite)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	project, err := u.visibleProject(ctx, actor, id)
+	if err != nil {
+		return Domain.Project{}, err
+	}
+	if project.OwnerID.Hex() != actor.UserID && !u.authz.Can(actor, Domain.PermProjectManageAny) {
+		return Domain.Project{}, Domain.NewError(Domain.ErrForbidden, "only the owner can change a project")
+	}
+	return project, nil
+}
+
+// NewProjectUsecase creates a new ProjectUsecase
+func NewProjectUsecase(projectRepo Domain.ProjectRepository, taskRepo Domain.TaskRepository, authz Domain.Authorizer) ProjectUsecase {
+	return &projectUsecase{membership: membership{projectRepo: projectRepo, authz: authz}, projectRepo: projectRepo, taskRepo: taskRepo}
+}
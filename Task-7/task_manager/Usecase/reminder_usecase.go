@@ -0,0 +1,149 @@
+package Usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReminderUsecase defines the due-date reminder business logic.
+type ReminderUsecase interface {
+	// GetPreferences returns the reminder preferences of the actor, or the
+	// defaults if they have not set any.
+	GetPreferences(ctx context.Context) (Domain.ReminderPreferences, error)
+	// UpdatePreferences replaces the reminder preferences of the actor.
+	UpdatePreferences(ctx context.Context, prefs Domain.ReminderPreferences) (Domain.ReminderPreferences, error)
+	// SendDueReminders sends a reminder for every pending task that falls
+	// due within its owner's lead time after now and has not been reminded
+	// of yet, and returns how many were sent. It is run by the server itself
+	// and does not need an actor.
+	SendDueReminders(ctx context.Context, now time.Time) (int, error)
+}
+
+// reminderUsecase implements ReminderUsecase.
+type reminderUsecase struct {
+	taskRepo     Domain.TaskRepository
+	reminderRepo Domain.ReminderRepository
+	notifier     Domain.Notifier
+	defaults     Domain.ReminderPreferences
+}
+
+// GetPreferences implements ReminderUsecase.
+func (r *reminderUsecase) GetPreferences(ctx context.Context) (Domain.ReminderPreferences, error) {
+	actor, ok := Domain.ActorFromContext(ctx)
+	if !ok {
+		return Domain.ReminderPreferences{}, Domain.ErrNoActor
+	}
+	return r.preferences(ctx, actor.UserID)
+}
+
+// UpdatePreferences implements ReminderUsecase.
+func (r *reminderUsecase) UpdatePreferences(ctx context.Context, prefs Domain.ReminderPreferences) (Domain.ReminderPreferences, error) {
+	actor, ok := Domain.ActorFromContext(ctx)
+	if !ok {
+		return Domain.ReminderPreferences{}, Domain.ErrNoActor
+	}
+	if err := prefs.Validate(); err != nil {
+		return Domain.ReminderPreferences{}, err
+	}
+
+	userID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return Domain.ReminderPreferences{}, Domain.NewError(Domain.ErrUnauthorized, "invalid user ID in token")
+	}
+	prefs.UserID = userID
+	return r.reminderRepo.SavePreferences(ctx, prefs)
+}
+
+// SendDueReminders implements ReminderUsecase.
+func (r *reminderUsecase) SendDueReminders(ctx context.Context, now time.Time) (int, error) {
+	// Users may choose any lead time up to the maximum, so every task due
+	// within it is a candidate.
+	horizon := now.Add(Domain.MaxReminderLead)
+	// Every status but Completed is open, whatever the workflow calls it.
+	query := Domain.TaskQuery{
+		ExcludeStatuses: []Domain.Status{Domain.Completed},
+		DueAfter:        &now,
+		DueBefore:       &horizon,
+		SortBy:          Domain.SortByDueDate,
+		Limit:           Domain.MaxTaskLimit,
+	}
+	preferences := make(map[primitive.ObjectID]Domain.ReminderPreferences)
+
+	sent := 0
+	for {
+		page, err := r.taskRepo.GetAllTasks(ctx, query)
+		if err != nil {
+			return sent, err
+		}
+		for _, task := range page.Tasks {
+			prefs, cached := preferences[task.OwnerID]
+			if !cached {
+				if prefs, err = r.preferences(ctx, task.OwnerID.Hex()); err != nil {
+					return sent, err
+				}
+				preferences[task.OwnerID] = prefs
+			}
+			if !prefs.Enabled || task.DueDate.After(now.Add(prefs.LeadTime())) {
+				continue
+			}
+
+			ok, err := r.send(ctx, task, prefs)
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+		query.Offset += len(page.Tasks)
+		if len(page.Tasks) < query.Limit || int64(query.Offset) >= page.Total {
+			return sent, nil
+		}
+	}
+}
+
+// send delivers the reminder for task unless it has already been sent, and
+// reports whether it was delivered. The reminder is recorded before it is
+// delivered, so that two servers never both send it; if delivery fails, the
+// record is removed again and the next run retries.
+func (r *reminderUsecase) send(ctx context.Context, task Domain.Task, prefs Domain.ReminderPreferences) (bool, error) {
+	fresh, err := r.reminderRepo.MarkReminderSent(ctx, task.ID, task.DueDate)
+	if err != nil || !fresh {
+		return false, err
+	}
+
+	err = r.notifier.Notify(ctx, Domain.Reminder{Task: task, UserID: task.OwnerID, Email: prefs.Email})
+	if errors.Is(err, Domain.ErrNoReminderRecipient) {
+		// Retrying would not help; the reminder stays recorded as handled.
+		return false, nil
+	}
+	if err != nil {
+		log.Printf("Failed to send reminder for task %s: %v", task.ID.Hex(), err)
+		if err := r.reminderRepo.UnmarkReminderSent(ctx, task.ID, task.DueDate); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// preferences returns the stored reminder preferences of a user or the
+// defaults.
+func (r *reminderUsecase) preferences(ctx context.Context, userID string) (Domain.ReminderPreferences, error) {
+	prefs, err := r.reminderRepo.GetPreferences(ctx, userID)
+	if errors.Is(err, Domain.ErrReminderPreferencesNotFound) {
+		return r.defaults, nil
+	}
+	return prefs, err
+}
+
+// NewReminderUsecase creates a new ReminderUsecase. defaults apply to users
+// who have not set their own preferences.
+func NewReminderUsecase(taskRepo Domain.TaskRepository, reminderRepo Domain.ReminderRepository, notifier Domain.Notifier, defaults Domain.ReminderPreferences) ReminderUsecase {
+	return &reminderUsecase{taskRepo: taskRepo, reminderRepo: reminderRepo, notifier: notifier, defaults: defaults}
+}
@@ -0,0 +1,129 @@
+package Usecase_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"task_manager/Domain"
+	"task_manager/Repositories"
+	"task_manager/Usecase"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordingNotifier records the reminders it is asked to send. While
+// failures is positive, Notify fails and decrements it instead.
+type recordingNotifier struct {
+	mu        sync.Mutex
+	failures  int
+	reminders []Domain.Reminder
+}
+
+// Notify implements Domain.Notifier.
+func (n *recordingNotifier) Notify(ctx context.Context, reminder Domain.Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if reminder.Email == "" {
+		return Domain.ErrNoReminderRecipient
+	}
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("mail server unavailable")
+	}
+	n.reminders = append(n.reminders, reminder)
+	return nil
+}
+
+// titles returns the task titles of the reminders sent so far and forgets
+// them.
+func (n *recordingNotifier) titles() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	titles := make([]string, len(n.reminders))
+	for i, reminder := range n.reminders {
+		titles[i] = reminder.Task.Title
+	}
+	n.reminders = nil
+	return titles
+}
+
+func TestSendDueReminders(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := Repositories.NewInMemoryTaskRepository()
+	reminderRepo := Repositories.NewInMemoryReminderRepository()
+	notifier := &recordingNotifier{}
+	defaults := Domain.ReminderPreferences{Enabled: true, LeadMinutes: 60}
+	reminders := Usecase.NewReminderUsecase(taskRepo, reminderRepo, notifier, defaults)
+
+	// The clock is fake: the scheduler only knows the time it is given. It
+	// starts in the future because the repository expires its records of
+	// sent reminders once the real time passes the due date.
+	clock := time.Now().UTC().Truncate(time.Hour).Add(48 * time.Hour)
+	alice, bob, carol := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	for _, prefs := range []Domain.ReminderPreferences{
+		{UserID: alice, Enabled: true, LeadMinutes: 60, Email: "alice@example.com"},
+		{UserID: bob, Enabled: false, LeadMinutes: 60, Email: "bob@example.com"},
+		{UserID: carol, Enabled: true, LeadMinutes: 24 * 60, Email: "carol@example.com"},
+	} {
+		if _, err := reminderRepo.SavePreferences(ctx, prefs); err != nil {
+			t.Fatalf("SavePreferences: %v", err)
+		}
+	}
+	for _, task := range []Domain.Task{
+		{Title: "alice soon", DueDate: clock.Add(30 * time.Minute), OwnerID: alice},
+		{Title: "alice later", DueDate: clock.Add(3 * time.Hour), OwnerID: alice},
+		{Title: "alice overdue", DueDate: clock.Add(-time.Minute), OwnerID: alice},
+		{Title: "alice done", DueDate: clock.Add(10 * time.Minute), OwnerID: alice, Status: Domain.Completed},
+		// Any status of the workflow but Completed is open.
+		{Title: "alice in review", DueDate: clock.Add(40 * time.Minute), OwnerID: alice, Status: "in-review"},
+		{Title: "bob soon", DueDate: clock.Add(30 * time.Minute), OwnerID: bob},
+		{Title: "carol tomorrow", DueDate: clock.Add(20 * time.Hour), OwnerID: carol},
+	} {
+		if task.Status == "" {
+			task.Status = Domain.Pending
+		}
+		if _, err := taskRepo.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+	}
+
+	run := func(want ...string) {
+		t.Helper()
+		sent, err := reminders.SendDueReminders(ctx, clock)
+		if err != nil {
+			t.Fatalf("SendDueReminders at %s: %v", clock.Format(time.Kitchen), err)
+		}
+		got := notifier.titles()
+		if sent != len(want) || !equalStrings(got, want) {
+			t.Errorf("SendDueReminders at %s sent %d %q, want %q", clock.Format(time.Kitchen), sent, got, want)
+		}
+	}
+
+	run("alice soon", "alice in review", "carol tomorrow")
+	// Reminders are only sent once per due date.
+	run()
+
+	// A failed delivery is retried on the next run.
+	clock = clock.Add(2*time.Hour + 30*time.Minute)
+	notifier.failures = 1
+	run()
+	clock = clock.Add(time.Minute)
+	run("alice later")
+	run()
+}
+
+// equalStrings reports whether a and b hold the same strings in order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package Usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetAssignedTasks implements TaskUsecase.
+func (t *taskUsecase) GetAssignedTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskPage, error) {
+	actor, err := t.authorize(ctx, Domain.PermTaskRead)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	query.AssigneeID = actor.UserID
+	query.Deleted = false
+	return t.GetAllTasks(ctx, query)
+}
+
+// AssignTask implements TaskUsecase.
+func (t *taskUsecase) AssignTask(ctx context.Context, id string, userIDs []string, version int64) (Domain.Task, error) {
+	return t.changeAssignees(ctx, id, userIDs, version, Domain.AddAssignees)
+}
+
+// UnassignTask implements TaskUsecase.
+func (t *taskUsecase) UnassignTask(ctx context.Context, id string, userIDs []string, version int64) (Domain.Task, error) {
+	return t.changeAssignees(ctx, id, userIDs, version, Domain.RemoveAssignees)
+}
+
+// changeAssignees sets the assignees of task id to change applied to its
+// current assignees and the users in userIDs. It goes through PatchTask, so
+// the change is validated, audited and published like any other edit of
+// the occurrence.
+func (t *taskUsecase) changeAssignees(ctx context.Context, id string, userIDs []string, version int64, change func(assignees, ids []primitive.ObjectID) []primitive.ObjectID) (Domain.Task, error) {
+	if len(userIDs) == 0 {
+		return Domain.Task{}, Domain.NewValidationError("user_ids", "cannot be empty")
+	}
+	ids := make([]primitive.ObjectID, len(userIDs))
+	verr := &Domain.ValidationError{}
+	for i, userID := range userIDs {
+		objID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			verr.Add("user_ids", fmt.Sprintf("invalid user ID: %q", userID))
+		}
+		ids[i] = objID
+	}
+	if err := verr.ErrOrNil(); err != nil {
+		return Domain.Task{}, err
+	}
+
+	existing, err := t.GetTaskByID(ctx, id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if version != 0 && version != existing.Version {
+		return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, id)
+	}
+	assignees := change(existing.Assignees, ids)
+	return t.PatchTask(ctx, id, Domain.TaskPatch{Assignees: &assignees}, version, Domain.ScopeThis)
+}
+
+// checkAssignees checks that the users task is assigned to exist. existing
+// is the stored task when task replaces it; users who were already
+// assigned are not checked again.
+func (t *taskUsecase) checkAssignees(ctx context.Context, existing *Domain.Task, task Domain.Task) error {
+	verr := &Domain.ValidationError{}
+	for _, userID := range task.Assignees {
+		if existing != nil && existing.IsAssignedTo(userID) {
+			continue
+		}
+		if _, err := t.userRepo.GetUserByID(ctx, userID.Hex()); err != nil {
+			if !errors.Is(err, Domain.ErrNotFound) {
+				return err
+			}
+			verr.Add("assignees", fmt.Sprintf("user %s not found", userID.Hex()))
+		}
+	}
+	return verr.ErrOrNil()
+}
@@ -0,0 +1,383 @@
+package Usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"task_manager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTaskTree implements TaskUsecase.
+func (t *taskUsecase) GetTaskTree(ctx context.Context, id string) (Domain.TaskTree, error) {
+	actor, err := t.authorize(ctx, Domain.PermTaskRead)
+	if err != nil {
+		return Domain.TaskTree{}, err
+	}
+	root, err := t.GetTaskByID(ctx, id)
+	if err != nil {
+		return Domain.TaskTree{}, err
+	}
+	return t.buildTree(ctx, actor, root, map[primitive.ObjectID]bool{})
+}
+
+// buildTree returns task with the subtasks below it that actor can see.
+// seen guards against cycles written behind the use case's back.
+func (t *taskUsecase) buildTree(ctx context.Context, actor Domain.Actor, task Domain.Task, seen map[primitive.ObjectID]bool) (Domain.TaskTree, error) {
+	seen[task.ID] = true
+	tree := Domain.TaskTree{Task: task, Subtasks: []Domain.TaskTree{}}
+	children, err := t.subtasks(ctx, task.ID)
+	if err != nil {
+		return Domain.TaskTree{}, err
+	}
+	for _, child := range children {
+		if seen[child.ID] || !t.canView(ctx, actor, child) {
+			continue
+		}
+		subtree, err := t.buildTree(ctx, actor, child, seen)
+		if err != nil {
+			return Domain.TaskTree{}, err
+		}
+		tree.Subtasks = append(tree.Subtasks, subtree)
+	}
+	return tree, nil
+}
+
+// subtasks returns the live direct subtasks of parentID.
+func (t *taskUsecase) subtasks(ctx context.Context, parentID primitive.ObjectID) ([]Domain.Task, error) {
+	var tasks []Domain.Task
+	query := Domain.TaskQuery{ParentID: parentID.Hex(), SortBy: Domain.SortByDueDate, Limit: Domain.MaxTaskLimit}
+	for {
+		page, err := t.taskRepo.GetAllTasks(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page.Tasks...)
+		query.Offset += len(page.Tasks)
+		if len(page.Tasks) == 0 || int64(query.Offset) >= page.Total {
+			return tasks, nil
+		}
+	}
+}
+
+// checkRelations checks that the parent and blockers of task exist, are
+// visible to the actor in ctx and do not form a cycle. existing is the
+// stored task when task replaces it; unchanged relations are not checked
+// again.
+func (t *taskUsecase) checkRelations(ctx context.Context, existing *Domain.Task, task Domain.Task) error {
+	if existing != nil && Domain.SameRelations(*existing, task) {
+		return nil
+	}
+
+	verr := &Domain.ValidationError{}
+	if task.ParentID != nil {
+		if _, err := t.GetTaskByID(ctx, task.ParentID.Hex()); err != nil {
+			if !errors.Is(err, Domain.ErrNotFound) {
+				return err
+			}
+			verr.Add("parent_id", fmt.Sprintf("task %s not found", task.ParentID.Hex()))
+		} else if !task.ID.IsZero() {
+			cycle, err := t.reaches(ctx, []primitive.ObjectID{*task.ParentID}, task.ID, func(ancestor Domain.Task) []primitive.ObjectID {
+				if ancestor.ParentID == nil {
+					return nil
+				}
+				return []primitive.ObjectID{*ancestor.ParentID}
+			})
+			if err != nil {
+				return err
+			}
+			if cycle {
+				verr.Add("parent_id", "cannot be the task itself or one of its subtasks")
+			}
+		}
+	}
+
+	var blockers []primitive.ObjectID
+	for _, id := range task.BlockedBy {
+		if _, err := t.GetTaskByID(ctx, id.Hex()); err != nil {
+			if !errors.Is(err, Domain.ErrNotFound) {
+				return err
+			}
+			verr.Add("blocked_by", fmt.Sprintf("task %s not found", id.Hex()))
+			continue
+		}
+		blockers = append(blockers, id)
+	}
+	if !task.ID.IsZero() && len(blockers) > 0 {
+		cycle, err := t.reaches(ctx, blockers, task.ID, func(blocker Domain.Task) []primitive.ObjectID {
+			return blocker.BlockedBy
+		})
+		if err != nil {
+			return err
+		}
+		if cycle {
+			verr.Add("blocked_by", "would create a dependency cycle")
+		}
+	}
+	return verr.ErrOrNil()
+}
+
+// reaches reports whether target can be reached from starts by repeatedly
+// following next. Tasks that no longer exist end a path.
+func (t *taskUsecase) reaches(ctx context.Context, starts []primitive.ObjectID, target primitive.ObjectID, next func(Domain.Task) []primitive.ObjectID) (bool, error) {
+	seen := map[primitive.ObjectID]bool{}
+	queue := append([]primitive.ObjectID(nil), starts...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == target {
+			return true, nil
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		task, err := t.taskRepo.GetTaskByID(ctx, id.Hex())
+		if errors.Is(err, Domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		queue = append(queue, next(task)...)
+	}
+	return false, nil
+}
+
+// checkCompletable fails with ErrTaskBlocked unless every task that task is
+// blocked by and every subtask of task is completed. Blockers that have
+// been deleted no longer count. batch is the state left by the earlier
+// operations of a bulk request, if any.
+func (t *taskUsecase) checkCompletable(ctx context.Context, batch *bulkState, task Domain.Task) error {
+	var reasons []string
+	for _, id := range task.BlockedBy {
+		blocker, err := t.bulkTask(ctx, batch, id)
+		if errors.Is(err, Domain.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if blocker.Status != Domain.Completed {
+			reasons = append(reasons, "blocked by "+id.Hex())
+		}
+	}
+	if !task.ID.IsZero() {
+		children, err := t.bulkSubtasks(ctx, batch, task.ID)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if child.Status != Domain.Completed {
+				reasons = append(reasons, fmt.Sprintf("subtask %s is open", child.ID.Hex()))
+			}
+		}
+	}
+	if len(reasons) > 0 {
+		return fmt.Errorf("%w: %s", Domain.ErrTaskBlocked, strings.Join(reasons, ", "))
+	}
+	return nil
+}
+
+// handleSubtasks deals with the subtasks of task before it is deleted,
+// according to policy.
+func (t *taskUsecase) handleSubtasks(ctx context.Context, task Domain.Task, policy Domain.SubtaskPolicy) error {
+	children, err := t.subtasks(ctx, task.ID)
+	if err != nil || len(children) == 0 {
+		return err
+	}
+
+	switch policy {
+	case Domain.SubtasksCascade:
+		seen := map[primitive.ObjectID]bool{task.ID: true}
+		for _, child := range children {
+			if err := t.deleteTree(ctx, child, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Domain.SubtasksReparent:
+		for _, child := range children {
+			before := child
+			child.ParentID = task.ParentID
+			updated, err := t.taskRepo.UpdateTask(ctx, child.ID.Hex(), child, child.Version)
+			if err != nil {
+				return err
+			}
+			t.record(ctx, updated.ID, Domain.AuditUpdated, Domain.DiffTasks(&before, updated))
+			t.publishUpdate(ctx, before, updated)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %s; delete it with subtasks=cascade or subtasks=reparent", Domain.ErrHasSubtasks, task.ID.Hex())
+	}
+}
+
+// deleteTree moves task and all of its descendants to the trash, deepest
+// first.
+func (t *taskUsecase) deleteTree(ctx context.Context, task Domain.Task, seen map[primitive.ObjectID]bool) error {
+	if seen[task.ID] {
+		return nil
+	}
+	seen[task.ID] = true
+	children, err := t.subtasks(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := t.deleteTree(ctx, child, seen); err != nil {
+			return err
+		}
+	}
+	return t.trash(ctx, task, task.Version)
+}
+
+// bulkState is the state that the operations of a bulk request checked so
+// far leave behind. The repository applies the operations in order, so each
+// one is checked against the tasks as the earlier ones change them.
+type bulkState struct {
+	// changed maps the tasks updated so far to their new state, and the
+	// tasks deleted so far to nil.
+	changed map[primitive.ObjectID]*Domain.Task
+	// created holds the tasks created so far. They have no ID yet.
+	created []Domain.Task
+}
+
+// bulkTask returns the task id as left by the earlier operations of batch,
+// which may be nil.
+func (t *taskUsecase) bulkTask(ctx context.Context, batch *bulkState, id primitive.ObjectID) (Domain.Task, error) {
+	if batch != nil {
+		if task, ok := batch.changed[id]; ok {
+			if task == nil {
+				return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id.Hex())
+			}
+			return *task, nil
+		}
+	}
+	return t.taskRepo.GetTaskByID(ctx, id.Hex())
+}
+
+// bulkSubtasks returns the live direct subtasks of parentID as left by the
+// earlier operations of batch, which may be nil.
+func (t *taskUsecase) bulkSubtasks(ctx context.Context, batch *bulkState, parentID primitive.ObjectID) ([]Domain.Task, error) {
+	children, err := t.subtasks(ctx, parentID)
+	if err != nil || batch == nil {
+		return children, err
+	}
+	var live []Domain.Task
+	for _, child := range children {
+		if changed, ok := batch.changed[child.ID]; ok {
+			if changed == nil {
+				continue
+			}
+			child = *changed
+		}
+		live = append(live, child)
+	}
+	for _, created := range batch.created {
+		if created.ParentID != nil && *created.ParentID == parentID {
+			live = append(live, created)
+		}
+	}
+	return live, nil
+}
+
+// checkBulkOperation applies the workflow, project, assignee, subtask and
+// dependency rules to a single operation of a bulk request, against the
+// state batch holds, and records the operation's effect in batch if it
+// passes. Updates and deletes without a version are pinned to the version
+// they were checked against. Bulk updates cannot change relations (see
+// Domain.Task.ValidateBulkUpdate), and bulk deletes cannot choose a
+// SubtaskPolicy, so tasks with subtasks must be deleted one at a time.
+// Operations on tasks that cannot be loaded are left for the repository to
+// report.
+func (t *taskUsecase) checkBulkOperation(ctx context.Context, actor Domain.Actor, batch *bulkState, op *Domain.BulkOperation) error {
+	if op.Op == Domain.BulkCreate {
+		if err := t.workflow.CheckInitial(op.Task.Status, actor.Role); err != nil {
+			return err
+		}
+		if err := t.checkProject(ctx, actor, nil, op.Task); err != nil {
+			return err
+		}
+		if err := t.checkAssignees(ctx, nil, op.Task); err != nil {
+			return err
+		}
+		if err := batch.checkDeletedRelations(op.Task); err != nil {
+			return err
+		}
+		if err := t.checkRelations(ctx, nil, op.Task); err != nil {
+			return err
+		}
+		if op.Task.Status == Domain.Completed {
+			if err := t.checkCompletable(ctx, batch, op.Task); err != nil {
+				return err
+			}
+		}
+		batch.created = append(batch.created, op.Task)
+		return nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(op.ID)
+	if err != nil {
+		return nil
+	}
+	stored, err := t.bulkTask(ctx, batch, id)
+	if err != nil || !t.canView(ctx, actor, stored) {
+		return nil
+	}
+	if op.Version != 0 && op.Version != stored.Version {
+		return fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, op.ID)
+	}
+	op.Version = stored.Version
+
+	switch op.Op {
+	case Domain.BulkUpdate:
+		if err := t.workflow.CheckTransition(stored.Status, op.Task.Status, actor.Role); err != nil {
+			return err
+		}
+		if completes(stored, op.Task) {
+			if err := t.checkCompletable(ctx, batch, stored); err != nil {
+				return err
+			}
+		}
+		updated := stored
+		updated.Title = op.Task.Title
+		updated.Description = op.Task.Description
+		updated.Status = op.Task.Status
+		updated.DueDate = op.Task.DueDate
+		updated.Version++
+		batch.changed[id] = &updated
+	case Domain.BulkDelete:
+		children, err := t.bulkSubtasks(ctx, batch, id)
+		if err != nil {
+			return err
+		}
+		if len(children) > 0 {
+			return fmt.Errorf("%w: %s; delete it with subtasks=cascade or subtasks=reparent", Domain.ErrHasSubtasks, op.ID)
+		}
+		batch.changed[id] = nil
+	}
+	return nil
+}
+
+// checkDeletedRelations fails if the parent or a blocker of task has been
+// deleted by an earlier operation of batch.
+func (batch *bulkState) checkDeletedRelations(task Domain.Task) error {
+	deleted := func(id primitive.ObjectID) bool {
+		changed, ok := batch.changed[id]
+		return ok && changed == nil
+	}
+	verr := &Domain.ValidationError{}
+	if task.ParentID != nil && deleted(*task.ParentID) {
+		verr.Add("parent_id", fmt.Sprintf("task %s not found", task.ParentID.Hex()))
+	}
+	for _, id := range task.BlockedBy {
+		if deleted(id) {
+			verr.Add("blocked_by", fmt.Sprintf("task %s not found", id.Hex()))
+		}
+	}
+	return verr.ErrOrNil()
+}
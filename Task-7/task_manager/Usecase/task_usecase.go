@@ -2,56 +2,819 @@ package Usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // TaskUsecase defines task-related business logic.
+// Every method expects the calling Domain.Actor in its context and fails
+// with Domain.ErrForbidden unless the actor holds the Domain.Permission it
+// needs. Actors without Domain.PermTaskReadAny only see the tasks they own
+// or are assigned to and the tasks of the projects they are a member of;
+// without Domain.PermTaskUpdateAny they can only modify those tasks.
 type TaskUsecase interface {
 	CreateTask(ctx context.Context, task Domain.Task) (Domain.Task, error)
 	GetTaskByID(ctx context.Context, id string) (Domain.Task, error)
-	GetAllTasks(ctx context.Context) ([]Domain.Task, error)
-	UpdateTask(ctx context.Context, id string, task Domain.Task) (Domain.Task, error)
-	DeleteTask(ctx context.Context, id string) error
+	GetAllTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskPage, error)
+	// GetAssignedTasks lists the live tasks assigned to the actor, filtered
+	// like GetAllTasks.
+	GetAssignedTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskPage, error)
+	// GetTaskTree returns a task with its subtasks, recursively, leaving out
+	// the subtasks the actor cannot see.
+	GetTaskTree(ctx context.Context, id string) (Domain.TaskTree, error)
+	// SearchTasks runs the full-text search in query.Text and highlights the
+	// matches. Results are ranked by relevance, so query may not set SortBy.
+	SearchTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskSearchPage, error)
+	// UpdateTask, PatchTask and DeleteTask fail with Domain.ErrVersionMismatch
+	// unless version is zero or equals the task's current version.
+	// For an occurrence of a recurring task, scope selects whether the edit
+	// also applies to the later occurrences. Completing an occurrence
+	// generates the next one. A task cannot be completed while a task it
+	// is blocked by or one of its subtasks is open. Status changes must be
+	// allowed by the workflow for the actor's role.
+	UpdateTask(ctx context.Context, id string, task Domain.Task, version int64, scope Domain.SeriesScope) (Domain.Task, error)
+	PatchTask(ctx context.Context, id string, patch Domain.TaskPatch, version int64, scope Domain.SeriesScope) (Domain.Task, error)
+	// AssignTask assigns a task to the users in userIDs, who must exist, in
+	// addition to its current assignees. UnassignTask removes them. Both
+	// check version like UpdateTask.
+	AssignTask(ctx context.Context, id string, userIDs []string, version int64) (Domain.Task, error)
+	UnassignTask(ctx context.Context, id string, userIDs []string, version int64) (Domain.Task, error)
+	// DeleteTask moves a task to the trash. Its comments are kept until it
+	// is purged. A task with subtasks can only be deleted with a
+	// Domain.SubtaskPolicy. It requires Domain.PermTaskDelete.
+	DeleteTask(ctx context.Context, id string, version int64, subtasks Domain.SubtaskPolicy) error
+	// BulkTasks validates and applies a batch of operations and returns the
+	// outcome of each one. Creates, updates and deletes need
+	// Domain.PermTaskCreate, Domain.PermTaskUpdate and Domain.PermTaskDelete
+	// respectively. When atomic, either all operations are applied or none.
+	BulkTasks(ctx context.Context, operations []Domain.BulkOperation, atomic bool) ([]Domain.BulkResult, error)
+	GetTrash(ctx context.Context, query Domain.TaskQuery) (Domain.TaskPage, error)
+	RestoreTask(ctx context.Context, id string) (Domain.Task, error)
+	// PurgeTask permanently deletes a trashed task and its comments. It
+	// requires Domain.PermTaskPurge.
+	PurgeTask(ctx context.Context, id string) error
+	// PurgeTrash permanently deletes every task that has been in the trash
+	// for longer than retention, together with its comments. It is run by
+	// the server itself and does not need an actor.
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+	// GetTaskHistory lists the audit entries of a live or trashed task.
+	GetTaskHistory(ctx context.Context, id string, query Domain.AuditQuery) (Domain.AuditPage, error)
+	// GetAuditLog lists the audit entries of all tasks. It requires
+	// Domain.PermAuditRead.
+	GetAuditLog(ctx context.Context, query Domain.AuditQuery) (Domain.AuditPage, error)
+	// ListTags suggests the tags in use on the tasks the actor can see that
+	// start with query.Prefix, most used first.
+	ListTags(ctx context.Context, query Domain.TagQuery) ([]Domain.TagCount, error)
+	// GetWorkflow returns the workflow that task statuses follow.
+	GetWorkflow(ctx context.Context) Domain.Workflow
+	// SubscribeTaskEvents streams changes to the tasks the actor can see.
+	// See Domain.TaskEventBroker.Subscribe for the meaning of afterID.
+	SubscribeTaskEvents(ctx context.Context, afterID uint64) (<-chan Domain.TaskEvent, error)
 }
 
 // taskUsecase implements TaskUsecase.
 type taskUsecase struct {
-	taskRepo Domain.TaskRepository
+	membership
+	taskRepo    Domain.TaskRepository
+	auditRepo   Domain.AuditRepository
+	commentRepo Domain.CommentRepository
+	userRepo    Domain.UserRepository
+	events      Domain.TaskEventBroker
+	webhooks    WebhookUsecase
+	workflow    Domain.Workflow
 }
 
 // CreateTask implements TaskUsecase.
 func (t *taskUsecase) CreateTask(ctx context.Context, task Domain.Task) (Domain.Task, error) {
+	actor, err := t.authorize(ctx, Domain.PermTaskCreate)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	task.Tags = Domain.NormalizeTags(task.Tags)
+	if task.Status == "" {
+		task.Status = t.workflow.Initial
+	}
 	if err := task.Validate(); err != nil {
 		return Domain.Task{}, err
 	}
-	return t.taskRepo.CreateTask(ctx, task)
+	if err := t.workflow.CheckInitial(task.Status, actor.Role); err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkProject(ctx, actor, nil, task); err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkAssignees(ctx, nil, task); err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkRelations(ctx, nil, task); err != nil {
+		return Domain.Task{}, err
+	}
+	if task.Status == Domain.Completed {
+		if err := t.checkCompletable(ctx, nil, task); err != nil {
+			return Domain.Task{}, err
+		}
+	}
+
+	ownerID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return Domain.Task{}, Domain.NewError(Domain.ErrUnauthorized, "invalid user ID in token")
+	}
+	task.OwnerID = ownerID
+	task.StatusEnteredAt = nil
+	task.EnterStatus(time.Now().UTC().Truncate(time.Millisecond))
+	task.StartSeries()
+	created, err := t.taskRepo.CreateTask(ctx, task)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	t.record(ctx, created.ID, Domain.AuditCreated, Domain.DiffTasks(nil, created))
+	t.publish(ctx, Domain.TaskCreated, created)
+	return created, nil
 }
 
 // DeleteTask implements TaskUsecase.
-func (t *taskUsecase) DeleteTask(ctx context.Context, id string) error {
-	return t.taskRepo.DeleteTask(ctx, id)
+func (t *taskUsecase) DeleteTask(ctx context.Context, id string, version int64, subtasks Domain.SubtaskPolicy) error {
+	_, err := retryUnversioned(version, func() (Domain.Task, error) {
+		return Domain.Task{}, t.deleteTask(ctx, id, version, subtasks)
+	})
+	return err
+}
+
+// deleteTask makes a single attempt at DeleteTask.
+func (t *taskUsecase) deleteTask(ctx context.Context, id string, version int64, subtasks Domain.SubtaskPolicy) error {
+	_, task, err := t.editableTask(ctx, id, Domain.PermTaskDelete)
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != task.Version {
+		return fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, id)
+	}
+	if err := t.handleSubtasks(ctx, task, subtasks); err != nil {
+		return err
+	}
+	return t.trash(ctx, task, task.Version)
+}
+
+// trash moves task to the trash, guarded by version. Its comments are kept
+// until it is purged, so that they come back if it is restored.
+func (t *taskUsecase) trash(ctx context.Context, task Domain.Task, version int64) error {
+	if err := t.taskRepo.DeleteTask(ctx, task.ID.Hex(), version); err != nil {
+		return err
+	}
+	t.record(ctx, task.ID, Domain.AuditDeleted, nil)
+	t.publish(ctx, Domain.TaskDeleted, task)
+	return nil
+}
+
+// BulkTasks implements TaskUsecase.
+func (t *taskUsecase) BulkTasks(ctx context.Context, operations []Domain.BulkOperation, atomic bool) ([]Domain.BulkResult, error) {
+	actor, err := t.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(operations) == 0 {
+		return nil, Domain.NewValidationError("operations", "cannot be empty")
+	}
+	if len(operations) > Domain.MaxBulkOperations {
+		return nil, Domain.NewValidationError("operations", fmt.Sprintf("cannot contain more than %d operations", Domain.MaxBulkOperations))
+	}
+	ownerID, err := primitive.ObjectIDFromHex(actor.UserID)
+	if err != nil {
+		return nil, Domain.NewError(Domain.ErrUnauthorized, "invalid user ID in token")
+	}
+
+	// Operations that fail validation are reported without being sent to
+	// the repository; index maps the others back to their position.
+	results := make([]Domain.BulkResult, len(operations))
+	batch := Domain.TaskBatch{Atomic: atomic}
+	if !t.authz.Can(actor, Domain.PermTaskUpdateAny) {
+		batch.VisibleTo = actor.UserID
+		if batch.VisibleProjects, err = t.projects(ctx, actor); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	state := &bulkState{changed: make(map[primitive.ObjectID]*Domain.Task)}
+	var index []int
+	for i, op := range operations {
+		op.Task.Tags = Domain.NormalizeTags(op.Task.Tags)
+		if op.Op == Domain.BulkCreate && op.Task.Status == "" {
+			op.Task.Status = t.workflow.Initial
+		}
+		err := validateBulkOperation(t.authz, actor, op)
+		if err == nil {
+			err = t.checkBulkOperation(ctx, actor, state, &op)
+		}
+		if err != nil {
+			results[i].Task.ID, _ = primitive.ObjectIDFromHex(op.ID)
+			results[i].Err = err
+			continue
+		}
+		if op.Op == Domain.BulkCreate {
+			op.Task.OwnerID = ownerID
+			op.Task.StatusEnteredAt = nil
+			op.Task.EnterStatus(now)
+			op.Task.StartSeries()
+		}
+		batch.Operations = append(batch.Operations, op)
+		index = append(index, i)
+	}
+
+	if atomic && len(index) < len(operations) {
+		for _, i := range index {
+			results[i].Task.ID, _ = primitive.ObjectIDFromHex(operations[i].ID)
+			results[i].Err = Domain.ErrBatchAborted
+		}
+		return results, nil
+	}
+	if len(batch.Operations) == 0 {
+		return results, nil
+	}
+
+	written, err := t.taskRepo.WriteTasks(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	for j, result := range written {
+		results[index[j]] = result
+		if result.Err != nil {
+			continue
+		}
+		switch batch.Operations[j].Op {
+		case Domain.BulkCreate:
+			t.record(ctx, result.Task.ID, Domain.AuditCreated, Domain.DiffTasks(nil, result.Task))
+			t.publish(ctx, Domain.TaskCreated, result.Task)
+		case Domain.BulkUpdate:
+			t.record(ctx, result.Task.ID, Domain.AuditUpdated, Domain.DiffTasks(result.Previous, result.Task))
+			t.publishUpdate(ctx, *result.Previous, result.Task)
+			t.scheduleNextOccurrence(ctx, *result.Previous, result.Task)
+		case Domain.BulkDelete:
+			t.record(ctx, result.Task.ID, Domain.AuditDeleted, nil)
+			t.publish(ctx, Domain.TaskDeleted, result.Task)
+		}
+	}
+	return results, nil
+}
+
+// validateBulkOperation checks a single operation of a bulk request before
+// it is sent to the repository, including that actor holds the permission
+// the operation needs.
+func validateBulkOperation(authz Domain.Authorizer, actor Domain.Actor, op Domain.BulkOperation) error {
+	switch op.Op {
+	case Domain.BulkCreate:
+		if err := Domain.Authorize(authz, actor, Domain.PermTaskCreate); err != nil {
+			return err
+		}
+		return op.Task.Validate()
+	case Domain.BulkUpdate:
+		if op.ID == "" {
+			return Domain.NewValidationError("id", "is required")
+		}
+		if err := Domain.Authorize(authz, actor, Domain.PermTaskUpdate); err != nil {
+			return err
+		}
+		if err := op.Task.ValidateBulkUpdate(); err != nil {
+			return err
+		}
+		return op.Task.Validate()
+	case Domain.BulkDelete:
+		if op.ID == "" {
+			return Domain.NewValidationError("id", "is required")
+		}
+		return Domain.Authorize(authz, actor, Domain.PermTaskDelete)
+	default:
+		return Domain.NewValidationError("op", fmt.Sprintf("invalid operation: %q; must be create, update or delete", op.Op))
+	}
 }
 
 // GetAllTasks implements TaskUsecase.
-func (t *taskUsecase) GetAllTasks(ctx context.Context) ([]Domain.Task, error) {
-	return t.taskRepo.GetAllTasks(ctx)
+func (t *taskUsecase) GetAllTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskPage, error) {
+	actor, err := t.authorize(ctx, Domain.PermTaskRead)
+	if err != nil {
+		return Domain.TaskPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return Domain.TaskPage{}, err
+	}
+
+	if err := t.restrictQuery(ctx, actor, &query); err != nil {
+		return Domain.TaskPage{}, err
+	}
+	return t.taskRepo.GetAllTasks(ctx, query)
+}
+
+// SearchTasks implements TaskUsecase.
+func (t *taskUsecase) SearchTasks(ctx context.Context, query Domain.TaskQuery) (Domain.TaskSearchPage, error) {
+	actor, err := t.authorize(ctx, Domain.PermTaskRead)
+	if err != nil {
+		return Domain.TaskSearchPage{}, err
+	}
+	if strings.TrimSpace(query.Text) == "" {
+		return Domain.TaskSearchPage{}, Domain.NewValidationError("q", "is required")
+	}
+	if query.SortBy != "" {
+		return Domain.TaskSearchPage{}, Domain.NewValidationError("sort", "is not supported; results are ranked by relevance")
+	}
+	if err := query.Normalize(); err != nil {
+		return Domain.TaskSearchPage{}, err
+	}
+
+	query.Deleted = false
+	if err := t.restrictQuery(ctx, actor, &query); err != nil {
+		return Domain.TaskSearchPage{}, err
+	}
+	page, err := t.taskRepo.SearchTasks(ctx, query)
+	if err != nil {
+		return Domain.TaskSearchPage{}, err
+	}
+
+	search := Domain.ParseTextSearch(query.Text)
+	for i := range page.Results {
+		page.Results[i].Highlights = search.Highlight(page.Results[i].Task)
+	}
+	return page, nil
+}
+
+// restrictQuery restricts query to the tasks actor can see.
+func (t *taskUsecase) restrictQuery(ctx context.Context, actor Domain.Actor, query *Domain.TaskQuery) error {
+	query.VisibleTo, query.VisibleProjects = "", nil
+	if t.authz.Can(actor, Domain.PermTaskReadAny) {
+		return nil
+	}
+	projects, err := t.projects(ctx, actor)
+	if err != nil {
+		return err
+	}
+	query.VisibleTo, query.VisibleProjects = actor.UserID, projects
+	return nil
+}
+
+// ListTags implements TaskUsecase.
+func (t *taskUsecase) ListTags(ctx context.Context, query Domain.TagQuery) ([]Domain.TagCount, error) {
+	actor, err := t.authorize(ctx, Domain.PermTaskRead)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	if query.ProjectID != "" {
+		if _, err := t.visibleProject(ctx, actor, query.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+
+	tasks := Domain.TaskQuery{}
+	if err := t.restrictQuery(ctx, actor, &tasks); err != nil {
+		return nil, err
+	}
+	query.VisibleTo, query.VisibleProjects = tasks.VisibleTo, tasks.VisibleProjects
+	return t.taskRepo.ListTags(ctx, query)
 }
 
 // GetTaskByID implements TaskUsecase.
+// Tasks the actor cannot see are reported as not found so their existence
+// is not leaked.
 func (t *taskUsecase) GetTaskByID(ctx context.Context, id string) (Domain.Task, error) {
-	return t.taskRepo.GetTaskByID(ctx, id)
+	actor, err := t.authorize(ctx, Domain.PermTaskRead)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	task, err := t.taskRepo.GetTaskByID(ctx, id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if !t.canView(ctx, actor, task) {
+		return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id)
+	}
+	return task, nil
+}
+
+// editableTask returns the actor in ctx and the live task id if the actor
+// holds permission and may change the task.
+func (t *taskUsecase) editableTask(ctx context.Context, id string, permission Domain.Permission) (Domain.Actor, Domain.Task, error) {
+	actor, err := t.authorize(ctx, permission)
+	if err != nil {
+		return Domain.Actor{}, Domain.Task{}, err
+	}
+	task, err := t.taskRepo.GetTaskByID(ctx, id)
+	if err != nil {
+		return Domain.Actor{}, Domain.Task{}, err
+	}
+	if err := t.checkChange(ctx, actor, task); err != nil {
+		return Domain.Actor{}, Domain.Task{}, err
+	}
+	return actor, task, nil
 }
 
 // UpdateTask implements TaskUsecase.
-func (t *taskUsecase) UpdateTask(ctx context.Context, id string, task Domain.Task) (Domain.Task, error) {
+func (t *taskUsecase) UpdateTask(ctx context.Context, id string, task Domain.Task, version int64, scope Domain.SeriesScope) (Domain.Task, error) {
+	return retryUnversioned(version, func() (Domain.Task, error) {
+		return t.updateTask(ctx, id, task, version, scope)
+	})
+}
+
+// updateTask makes a single attempt at UpdateTask.
+func (t *taskUsecase) updateTask(ctx context.Context, id string, task Domain.Task, version int64, scope Domain.SeriesScope) (Domain.Task, error) {
+	task.Tags = Domain.NormalizeTags(task.Tags)
 	if err := task.Validate(); err != nil{
 		return Domain.Task{}, err
 	}
 
-	return t.taskRepo.UpdateTask(ctx, id, task)
+	actor, existing, err := t.editableTask(ctx, id, Domain.PermTaskUpdate)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if version != 0 && version != existing.Version {
+		return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, id)
+	}
+
+	task.ID = existing.ID
+	task.OwnerID = existing.OwnerID
+	if err := t.workflow.CheckTransition(existing.Status, task.Status, actor.Role); err != nil {
+		return Domain.Task{}, err
+	}
+	task.StatusEnteredAt = existing.StatusEnteredAt
+	if task.Status != existing.Status {
+		task.EnterStatus(time.Now().UTC().Truncate(time.Millisecond))
+	}
+	if err := t.checkProject(ctx, actor, &existing, task); err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkAssignees(ctx, &existing, task); err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkRelations(ctx, &existing, task); err != nil {
+		return Domain.Task{}, err
+	}
+	if completes(existing, task) {
+		if err := t.checkCompletable(ctx, nil, task); err != nil {
+			return Domain.Task{}, err
+		}
+	}
+	// An omitted rule keeps the series as it is; ending a series requires a
+	// merge patch that removes the rule.
+	var rule *string
+	if task.Recurrence != "" {
+		rule = &task.Recurrence
+	}
+	task, err = Domain.EditSeries(existing, task, rule, scope)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	// The checks above were made against existing, so the write is guarded
+	// by the version it was read at even if the caller sent none.
+	updated, err := t.taskRepo.UpdateTask(ctx, id, task, existing.Version)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	t.record(ctx, updated.ID, Domain.AuditUpdated, Domain.DiffTasks(&existing, updated))
+	t.publishUpdate(ctx, existing, updated)
+	if scope == Domain.ScopeFuture {
+		if err := t.updateLaterOccurrences(ctx, updated); err != nil {
+			return Domain.Task{}, err
+		}
+	}
+	t.scheduleNextOccurrence(ctx, existing, updated)
+	return updated, nil
+}
+
+// PatchTask implements TaskUsecase.
+func (t *taskUsecase) PatchTask(ctx context.Context, id string, patch Domain.TaskPatch, version int64, scope Domain.SeriesScope) (Domain.Task, error) {
+	return retryUnversioned(version, func() (Domain.Task, error) {
+		return t.patchTask(ctx, id, patch, version, scope)
+	})
+}
+
+// patchTask makes a single attempt at PatchTask.
+func (t *taskUsecase) patchTask(ctx context.Context, id string, patch Domain.TaskPatch, version int64, scope Domain.SeriesScope) (Domain.Task, error) {
+	actor, existing, err := t.editableTask(ctx, id, Domain.PermTaskUpdate)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if version != 0 && version != existing.Version {
+		return Domain.Task{}, fmt.Errorf("%w: %s", Domain.ErrVersionMismatch, id)
+	}
+	if patch.IsEmpty() {
+		return existing, nil
+	}
+	if err := patch.Validate(existing); err != nil {
+		return Domain.Task{}, err
+	}
+	patch.EnteredStatusAt = nil
+	if patch.Status != nil && *patch.Status != existing.Status {
+		if err := t.workflow.CheckTransition(existing.Status, *patch.Status, actor.Role); err != nil {
+			return Domain.Task{}, err
+		}
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		patch.EnteredStatusAt = &now
+	}
+	after := patch.Apply(existing)
+	if err := t.checkProject(ctx, actor, &existing, after); err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkAssignees(ctx, &existing, after); err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkRelations(ctx, &existing, after); err != nil {
+		return Domain.Task{}, err
+	}
+	if completes(existing, after) {
+		if err := t.checkCompletable(ctx, nil, after); err != nil {
+			return Domain.Task{}, err
+		}
+	}
+
+	// Like in UpdateTask, the write is guarded by the version existing was
+	// read at even if the caller sent none.
+	var patched Domain.Task
+	if existing.SeriesID == nil && patch.Recurrence == nil {
+		patched, err = t.taskRepo.PatchTask(ctx, id, patch, existing.Version)
+	} else {
+		// Edits of a series also change the series fields, so the whole
+		// task is written.
+		var task Domain.Task
+		task, err = Domain.EditSeries(existing, patch.Apply(existing), patch.Recurrence, scope)
+		if err != nil {
+			return Domain.Task{}, err
+		}
+		patched, err = t.taskRepo.UpdateTask(ctx, id, task, existing.Version)
+	}
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	t.record(ctx, patched.ID, Domain.AuditUpdated, Domain.DiffTasks(&existing, patched))
+	t.publishUpdate(ctx, existing, patched)
+	if scope == Domain.ScopeFuture {
+		if err := t.updateLaterOccurrences(ctx, patched); err != nil {
+			return Domain.Task{}, err
+		}
+	}
+	t.scheduleNextOccurrence(ctx, existing, patched)
+	return patched, nil
+}
+
+// unversionedWriteAttempts is how often a write without a version is
+// attempted when the task keeps changing between reading and writing it.
+const unversionedWriteAttempts = 3
+
+// retryUnversioned calls write and, if the caller sent no version, calls it
+// again when it failed because the task changed after write read it. Writes
+// are validated against the task as read and guarded by its version, so a
+// caller without a version would otherwise see conflicts it did not ask for.
+func retryUnversioned(version int64, write func() (Domain.Task, error)) (Domain.Task, error) {
+	for attempt := 1; ; attempt++ {
+		task, err := write()
+		if version != 0 || attempt == unversionedWriteAttempts || !errors.Is(err, Domain.ErrVersionMismatch) {
+			return task, err
+		}
+	}
 }
 
-// NewTaskUsecase creates a new task with validation.
-func NewTaskUsecase(taskRepo Domain.TaskRepository) TaskUsecase {
-	return &taskUsecase{taskRepo: taskRepo}
+// updateLaterOccurrences applies a series-wide edit of task to the live
+// occurrences that follow it in its series.
+func (t *taskUsecase) updateLaterOccurrences(ctx context.Context, task Domain.Task) error {
+	if task.SeriesID == nil {
+		return nil
+	}
+	page, err := t.taskRepo.GetAllTasks(ctx, Domain.TaskQuery{SeriesID: task.SeriesID.Hex(), Limit: Domain.MaxTaskLimit})
+	if err != nil {
+		return err
+	}
+	for _, later := range page.Tasks {
+		if later.Occurrence <= task.Occurrence {
+			continue
+		}
+		before := later
+		later.Title = task.Title
+		later.Description = task.Description
+		later.Recurrence = task.Recurrence
+		later.SeriesTemplate = task.SeriesTemplate
+		updated, err := t.taskRepo.UpdateTask(ctx, later.ID.Hex(), later, later.Version)
+		if err != nil {
+			return err
+		}
+		t.record(ctx, updated.ID, Domain.AuditUpdated, Domain.DiffTasks(&before, updated))
+		t.publish(ctx, Domain.TaskUpdated, updated)
+	}
+	return nil
+}
+
+// scheduleNextOccurrence creates the next occurrence of a recurring task
+// that an update has just completed. Like record, it runs after the update
+// has been written and only logs failures. The repository refuses to create
+// an occurrence twice, so completing a task again after reopening it does
+// not generate another one.
+func (t *taskUsecase) scheduleNextOccurrence(ctx context.Context, before, after Domain.Task) {
+	if !completes(before, after) {
+		return
+	}
+	now := time.Now()
+	next, ok := after.NextOccurrence(now)
+	if !ok {
+		return
+	}
+	next.Status = t.workflow.Initial
+	next.EnterStatus(now.UTC().Truncate(time.Millisecond))
+	created, err := t.taskRepo.CreateTask(ctx, next)
+	if errors.Is(err, Domain.ErrOccurrenceExists) {
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create occurrence %d of series %s: %v", next.Occurrence, next.SeriesID.Hex(), err)
+		return
+	}
+	t.record(ctx, created.ID, Domain.AuditCreated, Domain.DiffTasks(nil, created))
+	t.publish(ctx, Domain.TaskCreated, created)
+}
+
+// GetTrash implements TaskUsecase.
+func (t *taskUsecase) GetTrash(ctx context.Context, query Domain.TaskQuery) (Domain.TaskPage, error) {
+	query.Deleted = true
+	return t.GetAllTasks(ctx, query)
+}
+
+// RestoreTask implements TaskUsecase.
+func (t *taskUsecase) RestoreTask(ctx context.Context, id string) (Domain.Task, error) {
+	actor, err := t.authorize(ctx, Domain.PermTaskUpdate)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+
+	task, err := t.taskRepo.GetTrashedTaskByID(ctx, id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	if err := t.checkChange(ctx, actor, task); err != nil {
+		return Domain.Task{}, err
+	}
+	restored, err := t.taskRepo.RestoreTask(ctx, id)
+	if err != nil {
+		return Domain.Task{}, err
+	}
+	t.record(ctx, restored.ID, Domain.AuditRestored, nil)
+	t.publish(ctx, Domain.TaskRestored, restored)
+	return restored, nil
+}
+
+// PurgeTask implements TaskUsecase.
+func (t *taskUsecase) PurgeTask(ctx context.Context, id string) error {
+	if _, err := t.authorize(ctx, Domain.PermTaskPurge); err != nil {
+		return err
+	}
+	task, err := t.taskRepo.GetTrashedTaskByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := t.taskRepo.PurgeTask(ctx, id); err != nil {
+		return err
+	}
+	t.record(ctx, task.ID, Domain.AuditPurged, nil)
+	t.deleteComments(ctx, task.ID)
+	return nil
+}
+
+// PurgeTrash implements TaskUsecase.
+func (t *taskUsecase) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := t.taskRepo.PurgeDeletedBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	t.deleteComments(ctx, purged...)
+	return int64(len(purged)), nil
+}
+
+// GetTaskHistory implements TaskUsecase.
+func (t *taskUsecase) GetTaskHistory(ctx context.Context, id string, query Domain.AuditQuery) (Domain.AuditPage, error) {
+	actor, err := t.authorize(ctx, Domain.PermTaskRead)
+	if err != nil {
+		return Domain.AuditPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return Domain.AuditPage{}, err
+	}
+
+	task, err := t.taskRepo.GetTaskByID(ctx, id)
+	if errors.Is(err, Domain.ErrTaskNotFound) {
+		task, err = t.taskRepo.GetTrashedTaskByID(ctx, id)
+	}
+	if err != nil {
+		return Domain.AuditPage{}, err
+	}
+	if !t.canView(ctx, actor, task) {
+		return Domain.AuditPage{}, fmt.Errorf("%w: %s", Domain.ErrTaskNotFound, id)
+	}
+
+	query.TaskID = task.ID.Hex()
+	return t.auditRepo.GetEntries(ctx, query)
+}
+
+// GetAuditLog implements TaskUsecase.
+func (t *taskUsecase) GetAuditLog(ctx context.Context, query Domain.AuditQuery) (Domain.AuditPage, error) {
+	if _, err := t.authorize(ctx, Domain.PermAuditRead); err != nil {
+		return Domain.AuditPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return Domain.AuditPage{}, err
+	}
+	return t.auditRepo.GetEntries(ctx, query)
+}
+
+// record stores an audit entry for a change the actor in ctx has made to a
+// task. The change itself has already been written, so a failure to record
+// it is logged rather than returned.
+func (t *taskUsecase) record(ctx context.Context, taskID primitive.ObjectID, action Domain.AuditAction, changes []Domain.FieldChange) {
+	actor, _ := Domain.ActorFromContext(ctx)
+	actorID, _ := primitive.ObjectIDFromHex(actor.UserID)
+	if changes == nil {
+		changes = []Domain.FieldChange{}
+	}
+
+	_, err := t.auditRepo.RecordEntry(ctx, Domain.AuditEntry{
+		TaskID:        taskID,
+		Action:        action,
+		ActorID:       actorID,
+		ActorUsername: actor.Username,
+		Changes:       changes,
+		Timestamp:     time.Now().UTC().Truncate(time.Millisecond),
+	})
+	if err != nil {
+		log.Printf("Failed to record %s audit entry for task %s: %v", action, taskID.Hex(), err)
+	}
+}
+
+// deleteComments removes the comments on tasks that have just been purged.
+// Like record, it runs after the purge and only logs failures.
+func (t *taskUsecase) deleteComments(ctx context.Context, taskIDs ...primitive.ObjectID) {
+	if len(taskIDs) == 0 {
+		return
+	}
+	if _, err := t.commentRepo.DeleteTaskComments(ctx, taskIDs...); err != nil {
+		log.Printf("Failed to delete the comments on %d purged tasks: %v", len(taskIDs), err)
+	}
+}
+
+// GetWorkflow implements TaskUsecase.
+func (t *taskUsecase) GetWorkflow(ctx context.Context) Domain.Workflow {
+	return t.workflow
+}
+
+// SubscribeTaskEvents implements TaskUsecase.
+func (t *taskUsecase) SubscribeTaskEvents(ctx context.Context, afterID uint64) (<-chan Domain.TaskEvent, error) {
+	actor, err := t.authorize(ctx, Domain.PermTaskRead)
+	if err != nil {
+		return nil, err
+	}
+	return t.events.Subscribe(ctx, afterID, func(event Domain.TaskEvent) bool {
+		return event.Task == nil || t.canView(ctx, actor, *event.Task)
+	})
+}
+
+// publish announces a change to task to event subscribers and queues it
+// for webhooks. Like record, it runs after the change has been written and
+// only logs failures.
+func (t *taskUsecase) publish(ctx context.Context, eventType Domain.TaskEventType, task Domain.Task) {
+	event := Domain.TaskEvent{Type: eventType, Task: &task}
+	if err := t.events.Publish(ctx, event); err != nil {
+		log.Printf("Failed to publish %s event for task %s: %v", eventType, task.ID.Hex(), err)
+	}
+	if err := t.webhooks.EnqueueTaskEvent(ctx, event); err != nil {
+		log.Printf("Failed to queue %s webhooks for task %s: %v", eventType, task.ID.Hex(), err)
+	}
+}
+
+// publishUpdate announces an update of a task from before to after, which
+// is also a completion if it sets the status to Completed.
+func (t *taskUsecase) publishUpdate(ctx context.Context, before, after Domain.Task) {
+	t.publish(ctx, Domain.TaskUpdated, after)
+	if completes(before, after) {
+		t.publish(ctx, Domain.TaskCompleted, after)
+	}
+}
+
+// completes reports whether an update from before to after completes a task.
+func completes(before, after Domain.Task) bool {
+	return before.Status != Domain.Completed && after.Status == Domain.Completed
+}
+
+// NewTaskUsecase creates a new task with validation. Task statuses follow
+// workflow, and authz decides what each actor may do.
+func NewTaskUsecase(taskRepo Domain.TaskRepository, auditRepo Domain.AuditRepository, commentRepo Domain.CommentRepository, userRepo Domain.UserRepository, projectRepo Domain.ProjectRepository, events Domain.TaskEventBroker, webhooks WebhookUsecase, workflow Domain.Workflow, authz Domain.Authorizer) TaskUsecase {
+	return &taskUsecase{
+		membership:  membership{projectRepo: projectRepo, authz: authz},
+		taskRepo:    taskRepo,
+		auditRepo:   auditRepo,
+		commentRepo: commentRepo,
+		userRepo:    userRepo,
+		events:      events,
+		webhooks:    webhooks,
+		workflow:    workflow,
+	}
 }
@@ -0,0 +1,385 @@
+package Usecase_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"task_manager/Domain"
+	"task_manager/Infrastructure"
+	"task_manager/Repositories"
+	"task_manager/Usecase"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTaskUsecase returns a TaskUsecase over taskRepo and commentRepo and
+// in-memory versions of its other dependencies, and a context acting as an
+// admin.
+func newTaskUsecase(taskRepo Domain.TaskRepository, commentRepo Domain.CommentRepository) (Usecase.TaskUsecase, context.Context) {
+	return newWorkflowTaskUsecase(taskRepo, commentRepo, Domain.DefaultWorkflow())
+}
+
+// newWorkflowTaskUsecase is newTaskUsecase with the given workflow.
+func newWorkflowTaskUsecase(taskRepo Domain.TaskRepository, commentRepo Domain.CommentRepository, workflow Domain.Workflow) (Usecase.TaskUsecase, context.Context) {
+	policy := Domain.DefaultPolicy()
+	webhooks := Usecase.NewWebhookUsecase(Repositories.NewInMemoryWebhookRepository(), Infrastructure.NewHTTPWebhookSender(time.Second), Domain.WebhookRetryPolicy{MaxAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Second}, time.Minute, policy)
+	tasks := Usecase.NewTaskUsecase(taskRepo, Repositories.NewInMemoryAuditRepository(), commentRepo, Repositories.NewInMemoryUserRepository(), Repositories.NewInMemoryProjectRepository(), Infrastructure.NewInMemoryTaskEventBroker(10, 10), webhooks, workflow, policy)
+	ctx := Domain.ContextWithActor(context.Background(), Domain.Actor{UserID: primitive.NewObjectID().Hex(), Username: "admin", Role: Domain.RoleAdmin})
+	return tasks, ctx
+}
+
+func TestTrashKeepsCommentsUntilPurged(t *testing.T) {
+	taskRepo := Repositories.NewInMemoryTaskRepository()
+	commentRepo := Repositories.NewInMemoryCommentRepository()
+	tasks, ctx := newTaskUsecase(taskRepo, commentRepo)
+	comments := Usecase.NewCommentUsecase(taskRepo, commentRepo, Repositories.NewInMemoryProjectRepository(), Domain.DefaultPolicy())
+
+	newTask := func(title string) Domain.Task {
+		t.Helper()
+		task, err := tasks.CreateTask(ctx, Domain.Task{Title: title, DueDate: time.Now().Add(time.Hour), Status: Domain.Pending})
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if _, err := comments.CreateComment(ctx, task.ID.Hex(), Domain.Comment{Body: "a comment on " + title}); err != nil {
+			t.Fatalf("CreateComment: %v", err)
+		}
+		return task
+	}
+	commentCount := func(task Domain.Task) int64 {
+		t.Helper()
+		page, err := commentRepo.ListComments(ctx, Domain.CommentQuery{TaskID: task.ID.Hex(), Limit: 10})
+		if err != nil {
+			t.Fatalf("ListComments: %v", err)
+		}
+		return page.Total
+	}
+	restore := func(task Domain.Task) {
+		t.Helper()
+		if _, err := tasks.RestoreTask(ctx, task.ID.Hex()); err != nil {
+			t.Fatalf("RestoreTask: %v", err)
+		}
+		page, err := comments.ListComments(ctx, Domain.CommentQuery{TaskID: task.ID.Hex()})
+		if err != nil {
+			t.Fatalf("ListComments after restore: %v", err)
+		}
+		if page.Total != 1 {
+			t.Errorf("task %q has %d comments after restore, want 1", task.Title, page.Total)
+		}
+	}
+
+	deleted, bulkDeleted, live := newTask("deleted"), newTask("bulk deleted"), newTask("live")
+
+	if err := tasks.DeleteTask(ctx, deleted.ID.Hex(), 0, Domain.SubtasksCascade); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	restore(deleted)
+	results, err := tasks.BulkTasks(ctx, []Domain.BulkOperation{{Op: Domain.BulkDelete, ID: bulkDeleted.ID.Hex()}}, true)
+	if err != nil || results[0].Err != nil {
+		t.Fatalf("BulkTasks = %+v, %v", results, err)
+	}
+	restore(bulkDeleted)
+
+	// Purging, whether of a single task or of the whole trash, deletes the
+	// comments for good.
+	if err := tasks.DeleteTask(ctx, deleted.ID.Hex(), 0, Domain.SubtasksCascade); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if err := tasks.PurgeTask(ctx, deleted.ID.Hex()); err != nil {
+		t.Fatalf("PurgeTask: %v", err)
+	}
+	if got := commentCount(deleted); got != 0 {
+		t.Errorf("purged task has %d comments, want 0", got)
+	}
+	if err := tasks.DeleteTask(ctx, bulkDeleted.ID.Hex(), 0, Domain.SubtasksCascade); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if purged, err := tasks.PurgeTrash(ctx, -time.Second); err != nil || purged != 1 {
+		t.Fatalf("PurgeTrash = %d, %v; want 1", purged, err)
+	}
+	if got := commentCount(bulkDeleted); got != 0 {
+		t.Errorf("task purged from the trash has %d comments, want 0", got)
+	}
+	if got := commentCount(live); got != 1 {
+		t.Errorf("live task has %d comments, want 1", got)
+	}
+}
+
+// racingTaskRepository changes the description of a task right before each
+// of the first races writes to it, like another client would between the
+// usecase reading and writing the task. It records the versions the writes
+// were guarded by.
+type racingTaskRepository struct {
+	Domain.TaskRepository
+	races    int
+	versions []int64
+}
+
+// race makes the concurrent change if any races are left.
+func (r *racingTaskRepository) race(ctx context.Context, id string) {
+	if r.races == 0 {
+		return
+	}
+	r.races--
+	description := "changed concurrently"
+	if _, err := r.TaskRepository.PatchTask(ctx, id, Domain.TaskPatch{Description: &description}, 0); err != nil {
+		panic(err)
+	}
+}
+
+// UpdateTask implements Domain.TaskRepository.
+func (r *racingTaskRepository) UpdateTask(ctx context.Context, id string, task Domain.Task, version int64) (Domain.Task, error) {
+	r.versions = append(r.versions, version)
+	r.race(ctx, id)
+	return r.TaskRepository.UpdateTask(ctx, id, task, version)
+}
+
+// PatchTask implements Domain.TaskRepository.
+func (r *racingTaskRepository) PatchTask(ctx context.Context, id string, patch Domain.TaskPatch, version int64) (Domain.Task, error) {
+	r.versions = append(r.versions, version)
+	r.race(ctx, id)
+	return r.TaskRepository.PatchTask(ctx, id, patch, version)
+}
+
+// DeleteTask implements Domain.TaskRepository.
+func (r *racingTaskRepository) DeleteTask(ctx context.Context, id string, version int64) error {
+	r.versions = append(r.versions, version)
+	r.race(ctx, id)
+	return r.TaskRepository.DeleteTask(ctx, id, version)
+}
+
+// WriteTasks implements Domain.TaskRepository.
+func (r *racingTaskRepository) WriteTasks(ctx context.Context, batch Domain.TaskBatch) ([]Domain.BulkResult, error) {
+	for _, op := range batch.Operations {
+		if op.Op != Domain.BulkCreate {
+			r.versions = append(r.versions, op.Version)
+			r.race(ctx, op.ID)
+		}
+	}
+	return r.TaskRepository.WriteTasks(ctx, batch)
+}
+
+func TestWritesAreGuardedByTheVersionRead(t *testing.T) {
+	repo := &racingTaskRepository{TaskRepository: Repositories.NewInMemoryTaskRepository()}
+	tasks, ctx := newTaskUsecase(repo, Repositories.NewInMemoryCommentRepository())
+	task, err := tasks.CreateTask(ctx, Domain.Task{Title: "Write report", DueDate: time.Now().Add(time.Hour), Status: Domain.Pending})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	id := task.ID.Hex()
+
+	writes := []struct {
+		name string
+		// merges is set for writes that keep the fields they do not set.
+		merges bool
+		write  func(version int64) (Domain.Task, error)
+	}{
+		{"UpdateTask", false, func(version int64) (Domain.Task, error) {
+			update := Domain.Task{Title: "Updated", Description: task.Description, DueDate: task.DueDate, Status: Domain.Pending}
+			return tasks.UpdateTask(ctx, id, update, version, Domain.ScopeThis)
+		}},
+		{"PatchTask", true, func(version int64) (Domain.Task, error) {
+			title := "Patched"
+			return tasks.PatchTask(ctx, id, Domain.TaskPatch{Title: &title}, version, Domain.ScopeThis)
+		}},
+	}
+	for _, w := range writes {
+		t.Run(w.name, func(t *testing.T) {
+			current, err := tasks.GetTaskByID(ctx, id)
+			if err != nil {
+				t.Fatalf("GetTaskByID: %v", err)
+			}
+
+			// With a version, a concurrent change is reported.
+			repo.races, repo.versions = 1, nil
+			if _, err := w.write(current.Version); !errors.Is(err, Domain.ErrVersionMismatch) {
+				t.Errorf("%s with a version after a concurrent change: got %v, want %v", w.name, err, Domain.ErrVersionMismatch)
+			}
+
+			// Without one, the write is still guarded by the version it
+			// was checked against, and retried on a fresh read.
+			repo.races, repo.versions = 2, nil
+			written, err := w.write(0)
+			if err != nil {
+				t.Fatalf("%s without a version: %v", w.name, err)
+			}
+			if len(repo.versions) != 3 || slices.Contains(repo.versions, 0) {
+				t.Errorf("%s wrote with versions %v, want three non-zero versions", w.name, repo.versions)
+			}
+			if w.merges && written.Description != "changed concurrently" {
+				t.Errorf("%s lost the concurrent change: description %q", w.name, written.Description)
+			}
+
+			// The retries give up eventually.
+			repo.races, repo.versions = 3, nil
+			if _, err := w.write(0); !errors.Is(err, Domain.ErrVersionMismatch) {
+				t.Errorf("%s without a version under constant changes: got %v, want %v", w.name, err, Domain.ErrVersionMismatch)
+			}
+		})
+	}
+}
+
+func TestDeleteIsGuardedByTheVersionRead(t *testing.T) {
+	repo := &racingTaskRepository{TaskRepository: Repositories.NewInMemoryTaskRepository()}
+	tasks, ctx := newTaskUsecase(repo, Repositories.NewInMemoryCommentRepository())
+	newTask := func(parent *primitive.ObjectID) Domain.Task {
+		t.Helper()
+		task, err := tasks.CreateTask(ctx, Domain.Task{Title: "Write report", DueDate: time.Now().Add(time.Hour), Status: Domain.Pending, ParentID: parent})
+		if err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		return task
+	}
+
+	// With a version, a concurrent change is reported.
+	task := newTask(nil)
+	repo.races, repo.versions = 1, nil
+	if err := tasks.DeleteTask(ctx, task.ID.Hex(), task.Version, Domain.SubtasksCascade); !errors.Is(err, Domain.ErrVersionMismatch) {
+		t.Errorf("DeleteTask with a version after a concurrent change: got %v, want %v", err, Domain.ErrVersionMismatch)
+	}
+
+	// Without one, the task and its subtasks are still deleted by the
+	// version they were checked against, retrying on a fresh read.
+	subtask := newTask(&task.ID)
+	repo.races, repo.versions = 1, nil
+	if err := tasks.DeleteTask(ctx, task.ID.Hex(), 0, Domain.SubtasksCascade); err != nil {
+		t.Fatalf("DeleteTask without a version: %v", err)
+	}
+	if len(repo.versions) != 3 || slices.Contains(repo.versions, 0) {
+		t.Errorf("DeleteTask deleted with versions %v, want three non-zero versions", repo.versions)
+	}
+	for _, deleted := range []Domain.Task{task, subtask} {
+		if _, err := tasks.GetTaskByID(ctx, deleted.ID.Hex()); !errors.Is(err, Domain.ErrNotFound) {
+			t.Errorf("GetTaskByID(%s) after DeleteTask: got %v, want %v", deleted.ID.Hex(), err, Domain.ErrNotFound)
+		}
+	}
+}
+
+func TestBulkOperationsAreCheckedAfterTheEarlierOnes(t *testing.T) {
+	// Tasks go through review before they are completed.
+	review := Domain.Status("review")
+	workflow := Domain.Workflow{
+		Initial: Domain.Pending,
+		States:  []Domain.Status{Domain.Pending, review, Domain.Completed},
+		Transitions: []Domain.WorkflowTransition{
+			{From: Domain.Pending, To: []Domain.Status{review}},
+			{From: review, To: []Domain.Status{Domain.Pending, Domain.Completed}},
+			{From: Domain.Completed, To: []Domain.Status{Domain.Pending}},
+		},
+	}
+	repo := &racingTaskRepository{TaskRepository: Repositories.NewInMemoryTaskRepository()}
+	tasks, ctx := newWorkflowTaskUsecase(repo, Repositories.NewInMemoryCommentRepository(), workflow)
+
+	newTask := func(title string, status Domain.Status, parent *primitive.ObjectID, blockers ...primitive.ObjectID) Domain.Task {
+		t.Helper()
+		task, err := tasks.CreateTask(ctx, Domain.Task{Title: title, DueDate: time.Now().Add(time.Hour), Status: status, ParentID: parent, BlockedBy: blockers})
+		if err != nil {
+			t.Fatalf("CreateTask(%s): %v", title, err)
+		}
+		return task
+	}
+	update := func(task Domain.Task, status Domain.Status) Domain.BulkOperation {
+		return Domain.BulkOperation{Op: Domain.BulkUpdate, ID: task.ID.Hex(), Task: Domain.Task{Title: task.Title, DueDate: task.DueDate, Status: status}}
+	}
+	bulk := func(operations ...Domain.BulkOperation) []error {
+		t.Helper()
+		results, err := tasks.BulkTasks(ctx, operations, false)
+		if err != nil {
+			t.Fatalf("BulkTasks: %v", err)
+		}
+		errs := make([]error, len(results))
+		for i, result := range results {
+			errs[i] = result.Err
+		}
+		return errs
+	}
+	status := func(task Domain.Task) Domain.Status {
+		t.Helper()
+		stored, err := tasks.GetTaskByID(ctx, task.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetTaskByID: %v", err)
+		}
+		return stored.Status
+	}
+
+	t.Run("transitions", func(t *testing.T) {
+		task := newTask("report", Domain.Pending, nil)
+		if errs := bulk(update(task, review), update(task, Domain.Completed)); errs[0] != nil || errs[1] != nil {
+			t.Fatalf("pending to review to completed: %v", errs)
+		}
+		// Completed tasks can only be reopened.
+		if errs := bulk(update(task, Domain.Pending), update(task, Domain.Completed)); errs[0] != nil || errs[1] == nil {
+			t.Errorf("completed to pending to completed: %v, want the second update to fail", errs)
+		}
+		if got := status(task); got != Domain.Pending {
+			t.Errorf("status %s, want %s", got, Domain.Pending)
+		}
+	})
+
+	t.Run("blockers", func(t *testing.T) {
+		blocker := newTask("blocker", Domain.Pending, nil)
+		if errs := bulk(update(blocker, review), update(blocker, Domain.Completed)); errs[0] != nil || errs[1] != nil {
+			t.Fatalf("completing the blocker: %v", errs)
+		}
+		blocked := newTask("blocked", review, nil, blocker.ID)
+		errs := bulk(update(blocker, Domain.Pending), update(blocked, Domain.Completed))
+		if errs[0] != nil || !errors.Is(errs[1], Domain.ErrTaskBlocked) {
+			t.Errorf("reopening the blocker and completing the blocked task: %v, want the second update to fail with %v", errs, Domain.ErrTaskBlocked)
+		}
+		if got := status(blocked); got != review {
+			t.Errorf("blocked task has status %s, want %s", got, review)
+		}
+	})
+
+	t.Run("subtasks", func(t *testing.T) {
+		parent := newTask("parent", review, nil)
+		subtask := newTask("subtask", review, &parent.ID)
+		// Deleting the open subtask unblocks the parent, but creating another
+		// one blocks it again.
+		errs := bulk(
+			Domain.BulkOperation{Op: Domain.BulkDelete, ID: subtask.ID.Hex()},
+			Domain.BulkOperation{Op: Domain.BulkCreate, Task: Domain.Task{Title: "another subtask", DueDate: time.Now().Add(time.Hour), ParentID: &parent.ID}},
+			update(parent, Domain.Completed),
+		)
+		if errs[0] != nil || errs[1] != nil || !errors.Is(errs[2], Domain.ErrTaskBlocked) {
+			t.Errorf("replacing the subtask and completing the parent: %v, want the update to fail with %v", errs, Domain.ErrTaskBlocked)
+		}
+		// A task deleted by the batch cannot become a parent.
+		leaf := newTask("leaf", review, nil)
+		errs = bulk(
+			Domain.BulkOperation{Op: Domain.BulkDelete, ID: leaf.ID.Hex()},
+			Domain.BulkOperation{Op: Domain.BulkCreate, Task: Domain.Task{Title: "orphan", DueDate: time.Now().Add(time.Hour), ParentID: &leaf.ID}},
+		)
+		if errs[0] != nil || !errors.Is(errs[1], Domain.ErrValidation) {
+			t.Errorf("deleting a task and creating a subtask of it: %v, want the create to fail validation", errs)
+		}
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		// Operations without a version are written with the version they
+		// were checked against, which earlier operations have increased.
+		task := newTask("versioned", Domain.Pending, nil)
+		repo.versions = nil
+		if errs := bulk(update(task, review), update(task, Domain.Pending)); errs[0] != nil || errs[1] != nil {
+			t.Fatalf("two updates of a task: %v", errs)
+		}
+		if want := []int64{task.Version, task.Version + 1}; !slices.Equal(repo.versions, want) {
+			t.Errorf("bulk updates written with versions %v, want %v", repo.versions, want)
+		}
+
+		// So a concurrent change is reported.
+		task, err := tasks.GetTaskByID(ctx, task.ID.Hex())
+		if err != nil {
+			t.Fatalf("GetTaskByID: %v", err)
+		}
+		repo.races, repo.versions = 1, nil
+		if errs := bulk(update(task, review)); !errors.Is(errs[0], Domain.ErrVersionMismatch) {
+			t.Errorf("bulk update after a concurrent change: %v, want %v", errs[0], Domain.ErrVersionMismatch)
+		}
+		if want := []int64{task.Version}; !slices.Equal(repo.versions, want) {
+			t.Errorf("bulk update written with versions %v, want %v", repo.versions, want)
+		}
+	})
+}
@@ -2,38 +2,386 @@ package Usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
 	"task_manager/Domain"
 	"task_manager/Infrastructure"
+	"time"
 )
 
 type UserUsecase interface {
+	// RegisterUser creates a user with Domain.RoleUser, whatever role user
+	// asks for. The password must satisfy the password policy.
 	RegisterUser(ctx context.Context, user Domain.User) (Domain.User, error)
-	LogIn(ctx context.Context, username, password string) (string, error)
+	// LogIn fails with Domain.ErrInvalidCredentials for unknown usernames
+	// and wrong passwords alike, and with Domain.ErrAccountDisabled for
+	// disabled users. Failures are counted per username and per clientIP;
+	// once either has too many, logins are rejected with a
+	// Domain.RetryAfterError until its delay or lockout has passed.
+	LogIn(ctx context.Context, username, password, clientIP string) (Infrastructure.TokenPair, error)
+	// RefreshToken exchanges a refresh token for a new token pair. Each refresh
+	// token can only be used once; presenting it again revokes its whole family.
+	// The new tokens carry the user's current role.
+	RefreshToken(ctx context.Context, refreshToken string) (Infrastructure.TokenPair, error)
+	// LogOut revokes the given access token and every token of its family.
+	LogOut(ctx context.Context, claims *Infrastructure.Claims) error
+	// ForgotPassword mails a single-use reset token to the user with the
+	// given username. To not reveal which usernames exist, neither by its
+	// result nor by how long it takes, it only validates the request and
+	// does the rest in the background, where unknown and disabled users and
+	// users without an email address are skipped.
+	ForgotPassword(ctx context.Context, username string) error
+	// ResetPassword sets a new password for the user a reset token was sent
+	// to and revokes every token issued to them before.
+	ResetPassword(ctx context.Context, token, password string) error
+	// ChangePassword replaces the password of the actor in ctx, who must
+	// know the current one. Like a reset, it revokes every token issued to
+	// them before, so it returns a new token pair.
+	ChangePassword(ctx context.Context, currentPassword, newPassword string) (Infrastructure.TokenPair, error)
+	// BootstrapAdmin creates an admin with the given credentials unless a
+	// user with that username exists, and reports whether it did. It is run
+	// by the server itself and does not need an actor.
+	BootstrapAdmin(ctx context.Context, username, password string) (bool, error)
+
+	// The remaining methods require Domain.PermUserManage. Actors cannot
+	// change the role of, disable or delete their own account.
+	ListUsers(ctx context.Context, query Domain.UserQuery) (Domain.UserPage, error)
+	GetUser(ctx context.Context, id string) (Domain.User, error)
+	SetUserRole(ctx context.Context, id string, role Domain.UserRole) (Domain.User, error)
+	// SetUserDisabled disables or enables a user. Disabled users keep their
+	// tasks but can no longer log in, and their tokens stop working.
+	SetUserDisabled(ctx context.Context, id string, disabled bool) (Domain.User, error)
+	// DeleteUser deletes a user. Their tasks, comments and assignments are
+	// kept.
+	DeleteUser(ctx context.Context, id string) error
+	// UnlockUser forgets the failed logins of a user, lifting any delay or
+	// lockout on their username. Lockouts of client IPs are not affected.
+	UnlockUser(ctx context.Context, id string) (Domain.User, error)
 }
 
+// passwordResetInterval is how long a user has to wait before another
+// reset token is sent to them.
+const passwordResetInterval = time.Minute
+
 type userUsecase struct {
 	userRepo        Domain.UserRepository
+	resetRepo       Domain.PasswordResetRepository
 	jwtService      Infrastructure.JWTService
 	passwordService Infrastructure.PasswordService
+	revocations     Domain.RevocationStore
+	mailer          Domain.MailSender
+	passwords       Domain.PasswordChecker
+	authz           Domain.Authorizer
+	resetTTL        time.Duration
+	attempts        Domain.LoginAttemptStore
+	userThrottle    Domain.LoginThrottle
+	ipThrottle      Domain.LoginThrottle
+
+	// dummyHash is compared against when a username does not exist, so
+	// that unknown usernames take as long to reject as wrong passwords.
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
 // LogIn implements UserUsecase.
-func (u *userUsecase) LogIn(ctx context.Context, username string, password string) (string, error) {
+func (u *userUsecase) LogIn(ctx context.Context, username string, password string, clientIP string) (Infrastructure.TokenPair, error) {
+	now := time.Now()
+	userKey, ipKey := loginUserKey(username), "ip:"+clientIP
+	// The attempt counts as a failure until the password turns out to be
+	// right, so that concurrent guesses cannot all pass the throttles.
+	userFailures, err := u.reserveLoginAttempt(ctx, userKey, u.userThrottle, now)
+	if err != nil {
+		return Infrastructure.TokenPair{}, err
+	}
+	ipFailures, err := u.reserveLoginAttempt(ctx, ipKey, u.ipThrottle, now)
+	if err != nil {
+		u.releaseLoginAttempt(ctx, userKey)
+		return Infrastructure.TokenPair{}, err
+	}
+
 	user, err := u.userRepo.GetUserByUsername(ctx, username)
-	if err != nil{
-		return "", err
+	if err != nil && !errors.Is(err, Domain.ErrUserNotFound) {
+		u.releaseLoginAttempt(ctx, userKey)
+		u.releaseLoginAttempt(ctx, ipKey)
+		return Infrastructure.TokenPair{}, err
+	}
+	if err != nil {
+		_ = u.passwordService.ComparePassword(u.getDummyHash(), password)
+	} else {
+		err = u.passwordService.ComparePassword(user.Password, password)
+	}
+	if err != nil {
+		logLockout(userKey, u.userThrottle, userFailures)
+		logLockout(ipKey, u.ipThrottle, ipFailures)
+		return Infrastructure.TokenPair{}, Domain.ErrInvalidCredentials
+	}
+
+	if err := u.attempts.ResetAttempts(ctx, userKey); err != nil {
+		log.Printf("Failed to reset login attempts of user %s: %v", user.ID.Hex(), err)
+	}
+	u.releaseLoginAttempt(ctx, ipKey)
+	if user.Disabled {
+		return Infrastructure.TokenPair{}, Domain.ErrAccountDisabled
+	}
+	return u.jwtService.GenerateTokenPair(user.ID.Hex(), user.Username, string(user.Role), "")
+}
+
+// loginUserKey is the login attempt key of a username.
+func loginUserKey(username string) string {
+	return "user:" + username
+}
+
+// reserveLoginAttempt records a failed login for key unless throttle makes
+// key wait, and returns the failures of key including this one. The
+// record is only changed if no other attempt has changed it since it was
+// checked; otherwise it is checked again.
+func (u *userUsecase) reserveLoginAttempt(ctx context.Context, key string, throttle Domain.LoginThrottle, now time.Time) (int, error) {
+	for {
+		attempts, err := u.attempts.GetAttempts(ctx, key, now)
+		if err != nil {
+			return 0, err
+		}
+		if retryAt := throttle.RetryAt(attempts); retryAt.After(now) {
+			return 0, Domain.NewRetryAfterError("too many failed login attempts; try again later", retryAt.Sub(now))
+		}
+		reserved, err := u.attempts.ReserveAttempt(ctx, key, attempts, now, now.Add(throttle.LockoutDuration))
+		if err != nil {
+			return 0, err
+		}
+		if reserved {
+			return attempts.Failures + 1, nil
+		}
 	}
+}
 
-	err = u.passwordService.ComparePassword(user.Password, password)
-	if err != nil{
-		return "", err
+// releaseLoginAttempt takes back the failure reserved for key by an attempt
+// that did not fail. Errors are only logged; at worst the attempt still
+// counts as a failure.
+func (u *userUsecase) releaseLoginAttempt(ctx context.Context, key string) {
+	if err := u.attempts.ReleaseAttempt(ctx, key); err != nil {
+		log.Printf("Failed to release login attempt for %s: %v", key, err)
 	}
-	return u.jwtService.GenerateToken(user.ID.Hex(), user.Username, string(user.Role))
+}
+
+// logLockout logs when a failed login brings key to the lockout threshold.
+func logLockout(key string, throttle Domain.LoginThrottle, failures int) {
+	if failures == throttle.LockoutThreshold {
+		log.Printf("Locked out %s for %s after %d failed logins", key, throttle.LockoutDuration, failures)
+	}
+}
+
+// getDummyHash returns a password hash that no password is compared
+// against successfully in practice.
+func (u *userUsecase) getDummyHash() string {
+	u.dummyHashOnce.Do(func() {
+		hash, err := u.passwordService.HashPassword(Infrastructure.NewTokenID())
+		if err != nil {
+			log.Printf("Failed to create dummy password hash: %v", err)
+		}
+		u.dummyHash = hash
+	})
+	return u.dummyHash
+}
+
+// RefreshToken implements UserUsecase.
+func (u *userUsecase) RefreshToken(ctx context.Context, refreshToken string) (Infrastructure.TokenPair, error) {
+	claims, err := u.jwtService.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return Infrastructure.TokenPair{}, err
+	}
+
+	revoked, err := u.revocations.IsRevoked(ctx, claims.Family)
+	if err != nil {
+		return Infrastructure.TokenPair{}, err
+	}
+	if revoked {
+		return Infrastructure.TokenPair{}, Domain.ErrTokenRevoked
+	}
+
+	// Consuming the refresh token and detecting reuse is a single atomic step.
+	fresh, err := u.revocations.Revoke(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return Infrastructure.TokenPair{}, err
+	}
+	if !fresh {
+		if err := u.revokeFamily(ctx, claims.Family); err != nil {
+			return Infrastructure.TokenPair{}, err
+		}
+		return Infrastructure.TokenPair{}, fmt.Errorf("%w: refresh token reuse detected", Domain.ErrTokenRevoked)
+	}
+
+	user, err := u.userRepo.GetUserByID(ctx, claims.UserID)
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		return Infrastructure.TokenPair{}, Domain.ErrAccountDeleted
+	}
+	if err != nil {
+		return Infrastructure.TokenPair{}, err
+	}
+	if user.Disabled {
+		return Infrastructure.TokenPair{}, Domain.ErrAccountDisabled
+	}
+	if !user.AcceptsTokenIssuedAt(time.Unix(claims.IssuedAt, 0)) {
+		return Infrastructure.TokenPair{}, Domain.ErrTokenRevoked
+	}
+	return u.jwtService.GenerateTokenPair(claims.UserID, user.Username, string(user.Role), claims.Family)
+}
+
+// LogOut implements UserUsecase.
+func (u *userUsecase) LogOut(ctx context.Context, claims *Infrastructure.Claims) error {
+	if _, err := u.revocations.Revoke(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return err
+	}
+	return u.revokeFamily(ctx, claims.Family)
+}
+
+// revokeFamily revokes every token of a family for as long as any of them can still be valid.
+func (u *userUsecase) revokeFamily(ctx context.Context, family string) error {
+	_, err := u.revocations.Revoke(ctx, family, time.Now().Add(u.jwtService.RefreshTokenTTL()))
+	return err
+}
+
+// ForgotPassword implements UserUsecase.
+func (u *userUsecase) ForgotPassword(ctx context.Context, username string) error {
+	if username == "" {
+		return Domain.NewValidationError("username", "cannot be empty")
+	}
+	go func() {
+		if err := u.sendPasswordReset(context.WithoutCancel(ctx), username); err != nil {
+			log.Printf("Failed to send a password reset to %q: %v", username, err)
+		}
+	}()
+	return nil
+}
+
+// sendPasswordReset creates a reset token for the user with the given
+// username and mails it to them, unless they cannot receive one or were
+// sent one less than passwordResetInterval ago.
+func (u *userUsecase) sendPasswordReset(ctx context.Context, username string) error {
+	user, err := u.userRepo.GetUserByUsername(ctx, username)
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Disabled || user.Email == "" {
+		return nil
+	}
+
+	token := Infrastructure.NewTokenID()
+	now := time.Now().UTC()
+	reset := Domain.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: Domain.HashResetToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(u.resetTTL),
+	}
+	created, err := u.resetRepo.CreateReset(ctx, reset, now.Add(-passwordResetInterval))
+	if err != nil || !created {
+		return err
+	}
+
+	mail := Domain.Mail{
+		To:      user.Email,
+		Subject: "Reset your Task Manager password",
+		Body: fmt.Sprintf("Someone asked to reset the password of the Task Manager account %s.\n\n"+
+			"To choose a new password, send this token to POST /password/reset:\n\n%s\n\n"+
+			"The token can be used once and expires at %s. If you did not ask for a reset, ignore this email.\n",
+			user.Username, token, reset.ExpiresAt.Format(time.RFC1123)),
+	}
+	return u.mailer.Send(ctx, mail)
+}
+
+// ResetPassword implements UserUsecase.
+func (u *userUsecase) ResetPassword(ctx context.Context, token, password string) error {
+	if token == "" {
+		return Domain.NewValidationError("token", "cannot be empty")
+	}
+
+	now := time.Now().UTC()
+	reset, err := u.resetRepo.ConsumeReset(ctx, Domain.HashResetToken(token), now)
+	if errors.Is(err, Domain.ErrResetTokenNotFound) {
+		return Domain.NewValidationError("token", "is invalid or has expired")
+	}
+	if err != nil {
+		return err
+	}
+	user, err := u.userRepo.GetUserByID(ctx, reset.UserID.Hex())
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		return Domain.NewValidationError("token", "is invalid or has expired")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := u.passwords.CheckPassword(user.Username, password); err != nil {
+		// Put the token back, so that the user can try another password.
+		if _, rerr := u.resetRepo.CreateReset(ctx, reset, reset.CreatedAt); rerr != nil {
+			log.Printf("Failed to restore password reset of user %s: %v", user.ID.Hex(), rerr)
+		}
+		return err
+	}
+	return u.setPassword(ctx, user, password, now)
+}
+
+// ChangePassword implements UserUsecase.
+func (u *userUsecase) ChangePassword(ctx context.Context, currentPassword, newPassword string) (Infrastructure.TokenPair, error) {
+	actor, ok := Domain.ActorFromContext(ctx)
+	if !ok {
+		return Infrastructure.TokenPair{}, Domain.ErrNoActor
+	}
+	user, err := u.userRepo.GetUserByID(ctx, actor.UserID)
+	if err != nil {
+		return Infrastructure.TokenPair{}, err
+	}
+
+	verr := &Domain.ValidationError{}
+	if u.passwordService.ComparePassword(user.Password, currentPassword) != nil {
+		verr.Add("current_password", "is incorrect")
+	}
+	if newPassword == currentPassword && newPassword != "" {
+		verr.Add("new_password", "must differ from the current password")
+	}
+	var perr *Domain.ValidationError
+	if err := u.passwords.CheckPassword(user.Username, newPassword); errors.As(err, &perr) {
+		for _, f := range perr.Fields {
+			verr.Add("new_password", f.Message)
+		}
+	} else if err != nil {
+		return Infrastructure.TokenPair{}, err
+	}
+	if err := verr.ErrOrNil(); err != nil {
+		return Infrastructure.TokenPair{}, err
+	}
+
+	if err := u.setPassword(ctx, user, newPassword, time.Now().UTC()); err != nil {
+		return Infrastructure.TokenPair{}, err
+	}
+	return u.jwtService.GenerateTokenPair(user.ID.Hex(), user.Username, string(user.Role), "")
+}
+
+// setPassword hashes and stores a new password for user, revoking every
+// token issued to them before changedAt.
+func (u *userUsecase) setPassword(ctx context.Context, user Domain.User, password string, changedAt time.Time) error {
+	hashedPassword, err := u.passwordService.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return u.userRepo.SetPassword(ctx, user.ID.Hex(), hashedPassword, changedAt)
 }
 
 // RegisterUser implements UserUsecase.
 func (u *userUsecase) RegisterUser(ctx context.Context, user Domain.User) (Domain.User, error) {
-	if err := user.Validate(); err != nil{
+	user.Role = Domain.RoleUser
+	user.Disabled = false
+	return u.createUser(ctx, user)
+}
+
+// createUser validates user and stores it with its password hashed.
+func (u *userUsecase) createUser(ctx context.Context, user Domain.User) (Domain.User, error) {
+	if err := user.Validate(u.passwords); err != nil{
 		return Domain.User{}, err
 	}
 
@@ -46,10 +394,129 @@ func (u *userUsecase) RegisterUser(ctx context.Context, user Domain.User) (Domai
 	return u.userRepo.CreateUser(ctx, user)
 }
 
-func NewUserUsecase(userRepo Domain.UserRepository, jwtService Infrastructure.JWTService, passwordService Infrastructure.PasswordService) UserUsecase {
+// BootstrapAdmin implements UserUsecase.
+func (u *userUsecase) BootstrapAdmin(ctx context.Context, username, password string) (bool, error) {
+	_, err := u.userRepo.GetUserByUsername(ctx, username)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, Domain.ErrUserNotFound) {
+		return false, err
+	}
+	_, err = u.createUser(ctx, Domain.User{Username: username, Password: password, Role: Domain.RoleAdmin})
+	if errors.Is(err, Domain.ErrUsernameTaken) {
+		// Another server instance created it first.
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListUsers implements UserUsecase.
+func (u *userUsecase) ListUsers(ctx context.Context, query Domain.UserQuery) (Domain.UserPage, error) {
+	if _, err := authorize(ctx, u.authz, Domain.PermUserManage); err != nil {
+		return Domain.UserPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return Domain.UserPage{}, err
+	}
+	return u.userRepo.ListUsers(ctx, query)
+}
+
+// GetUser implements UserUsecase.
+func (u *userUsecase) GetUser(ctx context.Context, id string) (Domain.User, error) {
+	if _, err := authorize(ctx, u.authz, Domain.PermUserManage); err != nil {
+		return Domain.User{}, err
+	}
+	user, err := u.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	user.Password = ""
+	return user, nil
+}
+
+// SetUserRole implements UserUsecase.
+func (u *userUsecase) SetUserRole(ctx context.Context, id string, role Domain.UserRole) (Domain.User, error) {
+	if !role.IsValid() {
+		return Domain.User{}, Domain.NewValidationError("role", fmt.Sprintf("invalid role: %s", role))
+	}
+	user, err := u.managedUser(ctx, id, "change the role of")
+	if err != nil {
+		return Domain.User{}, err
+	}
+	user.Role = role
+	return u.userRepo.UpdateUser(ctx, user)
+}
+
+// SetUserDisabled implements UserUsecase.
+func (u *userUsecase) SetUserDisabled(ctx context.Context, id string, disabled bool) (Domain.User, error) {
+	action := "disable"
+	if !disabled {
+		action = "enable"
+	}
+	user, err := u.managedUser(ctx, id, action)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	user.Disabled = disabled
+	return u.userRepo.UpdateUser(ctx, user)
+}
+
+// DeleteUser implements UserUsecase.
+func (u *userUsecase) DeleteUser(ctx context.Context, id string) error {
+	if _, err := u.managedUser(ctx, id, "delete"); err != nil {
+		return err
+	}
+	return u.userRepo.DeleteUser(ctx, id)
+}
+
+// UnlockUser implements UserUsecase.
+func (u *userUsecase) UnlockUser(ctx context.Context, id string) (Domain.User, error) {
+	user, err := u.GetUser(ctx, id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if err := u.attempts.ResetAttempts(ctx, loginUserKey(user.Username)); err != nil {
+		return Domain.User{}, err
+	}
+	return user, nil
+}
+
+// managedUser returns user id if the actor in ctx may manage it. action
+// describes the change for the error returned when the actor tries to make
+// it to their own account.
+func (u *userUsecase) managedUser(ctx context.Context, id, action string) (Domain.User, error) {
+	actor, err := authorize(ctx, u.authz, Domain.PermUserManage)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	user, err := u.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return Domain.User{}, err
+	}
+	if user.ID.Hex() == actor.UserID {
+		return Domain.User{}, Domain.NewError(Domain.ErrForbidden, fmt.Sprintf("cannot %s your own account", action))
+	}
+	return user, nil
+}
+
+// NewUserUsecase creates a new UserUsecase. New passwords must pass
+// passwords, and password reset tokens sent through mailer are valid for
+// resetTTL. Failed logins are recorded in attempts and throttled per
+// username with userThrottle and per client IP with ipThrottle.
+func NewUserUsecase(userRepo Domain.UserRepository, resetRepo Domain.PasswordResetRepository, jwtService Infrastructure.JWTService, passwordService Infrastructure.PasswordService, revocations Domain.RevocationStore, mailer Domain.MailSender, passwords Domain.PasswordChecker, authz Domain.Authorizer, resetTTL time.Duration, attempts Domain.LoginAttemptStore, userThrottle, ipThrottle Domain.LoginThrottle) UserUsecase {
 	return &userUsecase{
 		userRepo:        userRepo,
+		resetRepo:       resetRepo,
 		jwtService:      jwtService,
 		passwordService: passwordService,
+		revocations:     revocations,
+		mailer:          mailer,
+		passwords:       passwords,
+		authz:           authz,
+		resetTTL:        resetTTL,
+		attempts:        attempts,
+		userThrottle:    userThrottle,
+		ipThrottle:      ipThrottle,
 	}
 }
@@ -0,0 +1,264 @@
+package Usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"task_manager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// webhookBatchSize is the number of deliveries a single DeliverDue run claims
+// at a time.
+const webhookBatchSize = 50
+
+// WebhookUsecase defines the outgoing webhook business logic. Managing
+// subscriptions and deliveries requires Domain.PermWebhookManage.
+type WebhookUsecase interface {
+	CreateSubscription(ctx context.Context, sub Domain.WebhookSubscription) (Domain.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id string) (Domain.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]Domain.WebhookSubscription, error)
+	// DeleteSubscription removes a subscription together with the
+	// deliveries it still has pending.
+	DeleteSubscription(ctx context.Context, id string) error
+	// ListDeliveries lists recent deliveries, newest first. Listing the
+	// deliveries with status Domain.DeliveryDead shows the dead-letter list.
+	ListDeliveries(ctx context.Context, query Domain.WebhookDeliveryQuery) (Domain.WebhookDeliveryPage, error)
+	// Redeliver queues a new delivery of the same event as an earlier one,
+	// whatever became of it.
+	Redeliver(ctx context.Context, id string) (Domain.WebhookDelivery, error)
+	// EnqueueTaskEvent queues a delivery of event for every subscription
+	// to its type. It is called by the task usecase after each change.
+	EnqueueTaskEvent(ctx context.Context, event Domain.TaskEvent) error
+	// DeliverDue attempts every delivery that is due at now and returns how
+	// many succeeded. It is run by the server itself and does not need an
+	// actor.
+	DeliverDue(ctx context.Context, now time.Time) (int, error)
+}
+
+// webhookUsecase implements WebhookUsecase.
+type webhookUsecase struct {
+	webhookRepo Domain.WebhookRepository
+	sender      Domain.WebhookSender
+	retry       Domain.WebhookRetryPolicy
+	// lease is how long a claimed delivery is reserved for its attempt.
+	lease time.Duration
+	authz Domain.Authorizer
+}
+
+// webhookPayload is the JSON body of a webhook delivery.
+type webhookPayload struct {
+	ID   primitive.ObjectID   `json:"id"`
+	Type Domain.TaskEventType `json:"type"`
+	Time time.Time            `json:"time"`
+	Task *Domain.Task         `json:"task"`
+}
+
+// CreateSubscription implements WebhookUsecase.
+func (w *webhookUsecase) CreateSubscription(ctx context.Context, sub Domain.WebhookSubscription) (Domain.WebhookSubscription, error) {
+	if err := w.authorize(ctx); err != nil {
+		return Domain.WebhookSubscription{}, err
+	}
+	if err := sub.Validate(); err != nil {
+		return Domain.WebhookSubscription{}, err
+	}
+	slices.Sort(sub.Events)
+	sub.Events = slices.Compact(sub.Events)
+	sub.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	return w.webhookRepo.CreateSubscription(ctx, sub)
+}
+
+// GetSubscription implements WebhookUsecase.
+func (w *webhookUsecase) GetSubscription(ctx context.Context, id string) (Domain.WebhookSubscription, error) {
+	if err := w.authorize(ctx); err != nil {
+		return Domain.WebhookSubscription{}, err
+	}
+	return w.webhookRepo.GetSubscription(ctx, id)
+}
+
+// ListSubscriptions implements WebhookUsecase.
+func (w *webhookUsecase) ListSubscriptions(ctx context.Context) ([]Domain.WebhookSubscription, error) {
+	if err := w.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return w.webhookRepo.ListSubscriptions(ctx)
+}
+
+// DeleteSubscription implements WebhookUsecase.
+func (w *webhookUsecase) DeleteSubscription(ctx context.Context, id string) error {
+	if err := w.authorize(ctx); err != nil {
+		return err
+	}
+	return w.webhookRepo.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries implements WebhookUsecase.
+func (w *webhookUsecase) ListDeliveries(ctx context.Context, query Domain.WebhookDeliveryQuery) (Domain.WebhookDeliveryPage, error) {
+	if err := w.authorize(ctx); err != nil {
+		return Domain.WebhookDeliveryPage{}, err
+	}
+	if err := query.Normalize(); err != nil {
+		return Domain.WebhookDeliveryPage{}, err
+	}
+	return w.webhookRepo.ListDeliveries(ctx, query)
+}
+
+// Redeliver implements WebhookUsecase.
+func (w *webhookUsecase) Redeliver(ctx context.Context, id string) (Domain.WebhookDelivery, error) {
+	if err := w.authorize(ctx); err != nil {
+		return Domain.WebhookDelivery{}, err
+	}
+	original, err := w.webhookRepo.GetDelivery(ctx, id)
+	if err != nil {
+		return Domain.WebhookDelivery{}, err
+	}
+	// The subscription may have been deleted since.
+	if _, err := w.webhookRepo.GetSubscription(ctx, original.SubscriptionID.Hex()); err != nil {
+		return Domain.WebhookDelivery{}, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	created, err := w.webhookRepo.CreateDeliveries(ctx, []Domain.WebhookDelivery{{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         Domain.DeliveryPending,
+		NextAttemptAt:  &now,
+		CreatedAt:      now,
+	}})
+	if err != nil {
+		return Domain.WebhookDelivery{}, err
+	}
+	return created[0], nil
+}
+
+// EnqueueTaskEvent implements WebhookUsecase.
+func (w *webhookUsecase) EnqueueTaskEvent(ctx context.Context, event Domain.TaskEvent) error {
+	subs, err := w.webhookRepo.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	eventID := primitive.NewObjectID()
+	var payload []byte
+	var deliveries []Domain.WebhookDelivery
+	for _, sub := range subs {
+		if !slices.Contains(sub.Events, event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(webhookPayload{ID: eventID, Type: event.Type, Time: now, Task: event.Task}); err != nil {
+				return Domain.Internal("failed to encode webhook payload", err)
+			}
+		}
+		deliveries = append(deliveries, Domain.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      event.Type,
+			Payload:        string(payload),
+			Status:         Domain.DeliveryPending,
+			NextAttemptAt:  &now,
+			CreatedAt:      now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	_, err = w.webhookRepo.CreateDeliveries(ctx, deliveries)
+	return err
+}
+
+// DeliverDue implements WebhookUsecase.
+func (w *webhookUsecase) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	subs := make(map[primitive.ObjectID]*Domain.WebhookSubscription)
+
+	succeeded := 0
+	for {
+		claimed, err := w.webhookRepo.ClaimDueDeliveries(ctx, now, webhookBatchSize, w.lease)
+		if err != nil {
+			return succeeded, err
+		}
+		for _, delivery := range claimed {
+			sub, cached := subs[delivery.SubscriptionID]
+			if !cached {
+				found, err := w.webhookRepo.GetSubscription(ctx, delivery.SubscriptionID.Hex())
+				if err != nil && !errors.Is(err, Domain.ErrWebhookNotFound) {
+					return succeeded, err
+				}
+				if err == nil {
+					sub = &found
+				}
+				subs[delivery.SubscriptionID] = sub
+			}
+
+			ok, err := w.attempt(ctx, sub, delivery)
+			if err != nil {
+				return succeeded, err
+			}
+			if ok {
+				succeeded++
+			}
+		}
+		if len(claimed) < webhookBatchSize {
+			return succeeded, nil
+		}
+	}
+}
+
+// attempt sends a claimed delivery once and stores the outcome, scheduling
+// a retry or moving the delivery to the dead-letter list if it failed. It
+// reports whether the delivery succeeded. sub is nil if the subscription has
+// been deleted.
+func (w *webhookUsecase) attempt(ctx context.Context, sub *Domain.WebhookSubscription, delivery Domain.WebhookDelivery) (bool, error) {
+	start := time.Now().UTC().Truncate(time.Millisecond)
+	delivery.Attempts++
+	delivery.LastAttemptAt = &start
+
+	var status int
+	var err error
+	if sub == nil {
+		err = Domain.ErrWebhookNotFound
+	} else {
+		status, err = w.sender.Send(ctx, *sub, delivery)
+	}
+	delivery.LastStatusCode = status
+
+	switch {
+	case err == nil:
+		delivery.Status = Domain.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+	case sub == nil || delivery.Attempts >= w.retry.MaxAttempts:
+		log.Printf("Webhook delivery %s failed for good after %d attempts: %v", delivery.ID.Hex(), delivery.Attempts, err)
+		delivery.Status = Domain.DeliveryDead
+		delivery.NextAttemptAt = nil
+		delivery.LastError = err.Error()
+	default:
+		next := start.Add(w.retry.Backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.LastError = err.Error()
+	}
+	if _, err := w.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		return false, err
+	}
+	return delivery.Status == Domain.DeliverySucceeded, nil
+}
+
+// authorize checks that the actor in ctx may manage webhooks.
+func (w *webhookUsecase) authorize(ctx context.Context) error {
+	_, err := authorize(ctx, w.authz, Domain.PermWebhookManage)
+	return err
+}
+
+// NewWebhookUsecase creates a new WebhookUsecase. A delivery is reserved for
+// lease while it is being attempted, so lease must exceed the sender's
+// timeout.
+func NewWebhookUsecase(webhookRepo Domain.WebhookRepository, sender Domain.WebhookSender, retry Domain.WebhookRetryPolicy, lease time.Duration, authz Domain.Authorizer) WebhookUsecase {
+	return &webhookUsecase{webhookRepo: webhookRepo, sender: sender, retry: retry, lease: lease, authz: authz}
+}
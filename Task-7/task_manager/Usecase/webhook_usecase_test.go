@@ -0,0 +1,158 @@
+package Usecase_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"task_manager/Domain"
+	"task_manager/Infrastructure"
+	"task_manager/Repositories"
+	"task_manager/Usecase"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// webhookReceiver is a webhook endpoint that fails its first failures
+// requests with 500 and accepts the rest, checking every signature.
+type webhookReceiver struct {
+	t        *testing.T
+	secret   string
+	mu       sync.Mutex
+	failures int
+	eventIDs []string
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	timestamp := req.Header.Get(Infrastructure.WebhookTimestampHeader)
+	signature := req.Header.Get(Infrastructure.WebhookSignatureHeader)
+	if !Infrastructure.VerifyWebhook(r.secret, timestamp, signature, body, time.Minute, time.Now()) {
+		r.t.Errorf("delivery with invalid signature %q at %q", signature, timestamp)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.eventIDs = append(r.eventIDs, req.Header.Get(Infrastructure.WebhookIDHeader))
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requests returns the event IDs of the requests received so far.
+func (r *webhookReceiver) requests() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.eventIDs...)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	const secret = "a-very-long-secret"
+	receiver := &webhookReceiver{t: t, secret: secret, failures: 3}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	repo := Repositories.NewInMemoryWebhookRepository()
+	retry := Domain.WebhookRetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: 90 * time.Second}
+	webhooks := Usecase.NewWebhookUsecase(repo, Infrastructure.NewHTTPWebhookSender(5*time.Second), retry, time.Minute, Domain.DefaultPolicy())
+	ctx := Domain.ContextWithActor(context.Background(), Domain.Actor{UserID: primitive.NewObjectID().Hex(), Username: "admin", Role: Domain.RoleAdmin})
+
+	sub, err := webhooks.CreateSubscription(ctx, Domain.WebhookSubscription{URL: server.URL, Secret: secret, Events: []Domain.TaskEventType{Domain.TaskCreated}})
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	task := &Domain.Task{ID: primitive.NewObjectID(), Title: "Write report", Status: Domain.Pending}
+	if err := webhooks.EnqueueTaskEvent(ctx, Domain.TaskEvent{Type: Domain.TaskCreated, Task: task}); err != nil {
+		t.Fatalf("EnqueueTaskEvent: %v", err)
+	}
+	// Events the subscription does not ask for are not delivered.
+	if err := webhooks.EnqueueTaskEvent(ctx, Domain.TaskEvent{Type: Domain.TaskDeleted, Task: task}); err != nil {
+		t.Fatalf("EnqueueTaskEvent: %v", err)
+	}
+
+	deliver := func(now time.Time, want int) {
+		t.Helper()
+		succeeded, err := webhooks.DeliverDue(ctx, now)
+		if err != nil {
+			t.Fatalf("DeliverDue: %v", err)
+		}
+		if succeeded != want {
+			t.Errorf("DeliverDue succeeded %d times, want %d", succeeded, want)
+		}
+	}
+	delivery := func(status Domain.WebhookDeliveryStatus) Domain.WebhookDelivery {
+		t.Helper()
+		page, err := webhooks.ListDeliveries(ctx, Domain.WebhookDeliveryQuery{SubscriptionID: sub.ID.Hex(), Status: status})
+		if err != nil {
+			t.Fatalf("ListDeliveries: %v", err)
+		}
+		if len(page.Deliveries) != 1 {
+			t.Fatalf("ListDeliveries(%s) returned %d deliveries, want 1", status, len(page.Deliveries))
+		}
+		return page.Deliveries[0]
+	}
+
+	// The first two attempts fail and are retried after the base delay and
+	// then after the doubled delay, capped at the maximum.
+	clock := time.Now()
+	for attempt, wantBackoff := range []time.Duration{time.Minute, 90 * time.Second} {
+		deliver(clock, 0)
+		pending := delivery(Domain.DeliveryPending)
+		if pending.Attempts != attempt+1 || pending.LastStatusCode != http.StatusInternalServerError || pending.LastError == "" {
+			t.Errorf("after attempt %d: attempts %d, status %d, error %q", attempt+1, pending.Attempts, pending.LastStatusCode, pending.LastError)
+		}
+		if backoff := pending.NextAttemptAt.Sub(*pending.LastAttemptAt); backoff != wantBackoff {
+			t.Errorf("after attempt %d: retried after %s, want %s", attempt+1, backoff, wantBackoff)
+		}
+		// Nothing is sent before the retry is due.
+		deliver(pending.NextAttemptAt.Add(-time.Second), 0)
+		if got := len(receiver.requests()); got != attempt+1 {
+			t.Errorf("receiver got %d requests before the retry was due, want %d", got, attempt+1)
+		}
+		clock = *pending.NextAttemptAt
+	}
+
+	// The last attempt fails as well and moves the delivery to the
+	// dead-letter list, from where it is no longer retried.
+	deliver(clock, 0)
+	dead := delivery(Domain.DeliveryDead)
+	if dead.Attempts != retry.MaxAttempts || dead.NextAttemptAt != nil {
+		t.Errorf("dead delivery: attempts %d, next attempt %v", dead.Attempts, dead.NextAttemptAt)
+	}
+	deliver(clock.Add(24*time.Hour), 0)
+	if got := len(receiver.requests()); got != retry.MaxAttempts {
+		t.Fatalf("receiver got %d requests, want %d", got, retry.MaxAttempts)
+	}
+
+	// A manual redelivery sends the same event again, and the receiver has
+	// recovered by now.
+	redelivery, err := webhooks.Redeliver(ctx, dead.ID.Hex())
+	if err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	if redelivery.ID == dead.ID || redelivery.EventID != dead.EventID || redelivery.Status != Domain.DeliveryPending {
+		t.Errorf("Redeliver = %+v, want a new pending delivery of event %s", redelivery, dead.EventID.Hex())
+	}
+	deliver(time.Now(), 1)
+	if succeeded := delivery(Domain.DeliverySucceeded); succeeded.ID != redelivery.ID || succeeded.Attempts != 1 || succeeded.LastStatusCode != http.StatusNoContent {
+		t.Errorf("redelivery: %+v", succeeded)
+	}
+	delivery(Domain.DeliveryDead)
+
+	requests := receiver.requests()
+	if len(requests) != retry.MaxAttempts+1 {
+		t.Fatalf("receiver got %d requests, want %d", len(requests), retry.MaxAttempts+1)
+	}
+	for _, id := range requests {
+		if id != dead.EventID.Hex() {
+			t.Errorf("receiver got event %s, want only %s", id, dead.EventID.Hex())
+		}
+	}
+}